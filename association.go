@@ -0,0 +1,143 @@
+package main
+
+import "log"
+
+// reservedOptions are the QueryOption constructors a preload option must
+// not be named like.
+var reservedOptions = map[string]bool{"WithLimit": true, "WithOffset": true, "WithOrder": true, "WithDeleted": true}
+
+// preloadOption returns the name of the option preloading the association
+// held by field, e.g. WithStrain, or "" when that name is taken by another
+// option.
+func preloadOption(field string) string {
+	option := "With" + keyField(&FieldInfo{FieldName: field})
+	if reservedOptions[option] {
+		return ""
+	}
+	return option
+}
+
+// generatePreloadOptions writes a With<Field> option preloading the
+// association held by each field of models, once per field name: Mouse and
+// IdentifiedGenotypes share WithStrain. Its type only implements the
+// <Model>QueryOption of the models having such a field, which
+// generatePreloads adds, so a read refuses the preloads of other models at
+// compile time.
+func (g *Generator) generatePreloadOptions(models []*StructInfo) {
+	done := make(map[string]bool)
+	for _, si := range models {
+		for _, rel := range si.Relationships {
+			option := preloadOption(rel.FieldName)
+			if option == "" {
+				log.Printf("warning: %s.%s: With%[2]s is taken, no preload option is generated", si.StructName, rel.FieldName)
+				continue
+			}
+			preload := keyField(&FieldInfo{FieldName: rel.FieldName}) + "Preload"
+			if done[option] || g.existing[option] || g.existing[preload] {
+				continue
+			}
+			done[option] = true
+			g.Printf("// %s is the option %s returns.\n", preload, option)
+			g.Printf("type %s struct{}\n\n", preload)
+			g.Printf("// %s preloads the %s of the rows a read returns. Only the reads of\n", option, rel.FieldName)
+			g.Printf("// models with a %s association accept it.\n", rel.FieldName)
+			g.Printf("func %s() %s {\n", option, preload)
+			g.Printf("return %s{}\n", preload)
+			g.Printf("}\n\n")
+		}
+	}
+}
+
+// generatePreloads writes the <Model>QueryOption type the reads of si take,
+// which QueryOption and the preload options of the associations of si
+// implement.
+func (g *Generator) generatePreloads(si *StructInfo) {
+	if len(si.Relationships) == 0 {
+		return
+	}
+	name := si.StructName
+	option := queryOption(si)
+	g.Printf("// %s tunes the rows a %s read returns: it is a QueryOption\n", option, name)
+	g.Printf("// or the With<Field> option preloading an association of %s.\n", name)
+	g.Printf("type %s interface {\n", option)
+	g.Printf("apply%s(*queryOptions)\n", name)
+	g.Printf("}\n\n")
+	g.Printf("func (o QueryOption) apply%s(q *queryOptions) { o(q) }\n\n", name)
+	for _, rel := range si.Relationships {
+		if preloadOption(rel.FieldName) == "" {
+			continue
+		}
+		preload := keyField(&FieldInfo{FieldName: rel.FieldName}) + "Preload"
+		g.Printf("func (%s) apply%s(q *queryOptions) { q.preloads = append(q.preloads, %q) }\n\n", preload, name, rel.FieldName)
+	}
+	g.Printf("func %s(opts []%s) *queryOptions {\n", optionsFunc(si), option)
+	g.Printf("o := newQueryOptions(nil)\n")
+	g.Printf("for _, opt := range opts {\n")
+	g.Printf("opt.apply%s(o)\n", name)
+	g.Printf("}\n")
+	g.Printf("return o\n")
+	g.Printf("}\n\n")
+}
+
+// queryOption returns the type of the options the reads of si take:
+// <Model>QueryOption when si has associations to preload, QueryOption
+// otherwise.
+func queryOption(si *StructInfo) string {
+	if len(si.Relationships) == 0 {
+		return "QueryOption"
+	}
+	return si.StructName + "QueryOption"
+}
+
+// optionsFunc returns the function collecting the options of
+// queryOption(si), e.g. newMouseQueryOptions.
+func optionsFunc(si *StructInfo) string {
+	return "new" + queryOption(si) + "s"
+}
+
+// generateAssociations writes the Add, Remove, Replace and Count methods of
+// every many2many association of si, e.g. AddStrainTypes.
+func (g *Generator) generateAssociations(si *StructInfo) {
+	name := si.StructName
+	model := g.model(name)
+	repo := name + "Repo"
+	ctx := g.use("context")
+	for _, rel := range si.Relationships {
+		if rel.Kind != Many2Many {
+			continue
+		}
+		field := keyField(&FieldInfo{FieldName: rel.FieldName})
+		related := g.relatedType(rel)
+
+		g.Printf("// Add%s associates related with m, creating the %ss that do not\n", field, rel.Related)
+		g.Printf("// exist yet, through table %q.\n", rel.JoinTable)
+		g.Printf("func (r *%s) Add%s(ctx %s.Context, m *%s, related ...*%s) error {\n", repo, field, ctx, model, related)
+		g.Printf("return r.db.WithContext(ctx).Model(m).Association(%q).Append(related)\n", rel.FieldName)
+		g.Printf("}\n\n")
+
+		g.Printf("// Remove%s dissociates related from m. The %ss are kept.\n", field, rel.Related)
+		g.Printf("func (r *%s) Remove%s(ctx %s.Context, m *%s, related ...*%s) error {\n", repo, field, ctx, model, related)
+		g.Printf("return r.db.WithContext(ctx).Model(m).Association(%q).Delete(related)\n", rel.FieldName)
+		g.Printf("}\n\n")
+
+		g.Printf("// Replace%s makes related the only %ss associated with m.\n", field, rel.Related)
+		g.Printf("func (r *%s) Replace%s(ctx %s.Context, m *%s, related ...*%s) error {\n", repo, field, ctx, model, related)
+		g.Printf("return r.db.WithContext(ctx).Model(m).Association(%q).Replace(related)\n", rel.FieldName)
+		g.Printf("}\n\n")
+
+		g.Printf("// Count%s returns the number of %ss associated with m.\n", field, rel.Related)
+		g.Printf("func (r *%s) Count%s(ctx %s.Context, m *%s) (int64, error) {\n", repo, field, ctx, model)
+		g.Printf("a := r.db.WithContext(ctx).Model(m).Association(%q)\n", rel.FieldName)
+		g.Printf("n := a.Count()\n")
+		g.Printf("return n, a.Error\n")
+		g.Printf("}\n\n")
+	}
+}
+
+// relatedType returns the expression naming the model rel associates.
+func (g *Generator) relatedType(rel *Relationship) string {
+	if rel.RelatedPkgPath == "" || rel.RelatedPkgPath == g.pkg.Path {
+		return g.model(rel.Related)
+	}
+	return g.use(rel.RelatedPkgPath) + "." + rel.Related
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"log"
+	"strings"
+)
+
+// generateCursors writes the cursor helpers shared by the ListPage methods
+// of every repository of the output package.
+func (g *Generator) generateCursors() {
+	base64, json, errors := g.use("encoding/base64"), g.use("encoding/json"), g.use("errors")
+	clause := g.use("gorm.io/gorm/clause")
+	g.Printf("// ErrInvalidCursor is returned by the ListPage methods given a cursor\n")
+	g.Printf("// they did not issue, or issued for another sort.\n")
+	g.Printf("var ErrInvalidCursor = %s.New(\"invalid cursor\")\n\n", errors)
+
+	g.Printf("// cursor is the position a ListPage method resumes after: the sort\n")
+	g.Printf("// column of the last row read, its value and its primary key.\n")
+	g.Printf("type cursor struct {\n")
+	g.Printf("Sort  string          `json:\"s,omitempty\"`\n")
+	g.Printf("Value %s.RawMessage `json:\"v,omitempty\"`\n", json)
+	g.Printf("Key   %s.RawMessage `json:\"k\"`\n", json)
+	g.Printf("}\n\n")
+
+	g.Printf("// encodeCursor returns the opaque token of a cursor.\n")
+	g.Printf("func encodeCursor(sort string, value, key any) (string, error) {\n")
+	g.Printf("c := cursor{Sort: sort}\n")
+	g.Printf("var err error\n")
+	g.Printf("if sort != \"\" {\n")
+	g.Printf("if c.Value, err = %s.Marshal(value); err != nil {\n", json)
+	g.Printf("return \"\", err\n")
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("if c.Key, err = %s.Marshal(key); err != nil {\n", json)
+	g.Printf("return \"\", err\n")
+	g.Printf("}\n")
+	g.Printf("b, err := %s.Marshal(c)\n", json)
+	g.Printf("if err != nil {\n")
+	g.Printf("return \"\", err\n")
+	g.Printf("}\n")
+	g.Printf("return %s.RawURLEncoding.EncodeToString(b), nil\n", base64)
+	g.Printf("}\n\n")
+
+	g.Printf("// decodeCursor decodes the primary key of token into key and returns the\n")
+	g.Printf("// encoded value of the sort column.\n")
+	g.Printf("func decodeCursor(token, sort string, key any) (%s.RawMessage, error) {\n", json)
+	g.Printf("b, err := %s.RawURLEncoding.DecodeString(token)\n", base64)
+	g.Printf("if err != nil {\n")
+	g.Printf("return nil, ErrInvalidCursor\n")
+	g.Printf("}\n")
+	g.Printf("var c cursor\n")
+	g.Printf("if err := %s.Unmarshal(b, &c); err != nil || c.Sort != sort {\n", json)
+	g.Printf("return nil, ErrInvalidCursor\n")
+	g.Printf("}\n")
+	g.Printf("if err := %s.Unmarshal(c.Key, key); err != nil {\n", json)
+	g.Printf("return nil, ErrInvalidCursor\n")
+	g.Printf("}\n")
+	g.Printf("return c.Value, nil\n")
+	g.Printf("}\n\n")
+
+	g.Printf("// keysetAfter matches the rows sorting after values on columns. It spells\n")
+	g.Printf("// out the row value comparison (a, b) > (?, ?), which SQL Server lacks.\n")
+	g.Printf("// The disjunction is wrapped in And: gorm joins a lone Or to the previous\n")
+	g.Printf("// conditions with OR.\n")
+	g.Printf("func keysetAfter(columns []string, values []any) %s.Expression {\n", clause)
+	g.Printf("ors := make([]%s.Expression, len(columns))\n", clause)
+	g.Printf("for i := range columns {\n")
+	g.Printf("ands := make([]%s.Expression, 0, i+1)\n", clause)
+	g.Printf("for j := 0; j < i; j++ {\n")
+	g.Printf("ands = append(ands, %s.Eq{Column: %[1]s.Column{Table: %[1]s.CurrentTable, Name: columns[j]}, Value: values[j]})\n", clause)
+	g.Printf("}\n")
+	g.Printf("ands = append(ands, %s.Gt{Column: %[1]s.Column{Table: %[1]s.CurrentTable, Name: columns[i]}, Value: values[i]})\n", clause)
+	g.Printf("ors[i] = %s.And(ands...)\n", clause)
+	g.Printf("}\n")
+	g.Printf("return %s.And(%[1]s.Or(ors...))\n", clause)
+	g.Printf("}\n\n")
+
+	g.Printf("// keysetOrder sorts rows on columns in ascending order.\n")
+	g.Printf("func keysetOrder(columns []string) %s.OrderBy {\n", clause)
+	g.Printf("orders := make([]%s.OrderByColumn, len(columns))\n", clause)
+	g.Printf("for i, column := range columns {\n")
+	g.Printf("orders[i] = %s.OrderByColumn{Column: %[1]s.Column{Table: %[1]s.CurrentTable, Name: column}}\n", clause)
+	g.Printf("}\n")
+	g.Printf("return %s.OrderBy{Columns: orders}\n", clause)
+	g.Printf("}\n\n")
+}
+
+// generateListPage writes the <Model>Sort type, naming the indexed columns
+// rows can be sorted on before their primary key, and the ListPage method
+// reading rows a page at a time.
+func (g *Generator) generateListPage(si *StructInfo) {
+	name := si.StructName
+	model := g.model(name)
+	lower := lowerFirst(name)
+	repo, sort := name+"Repo", name+"Sort"
+	ctx, json, fmt := g.use("context"), g.use("encoding/json"), g.use("fmt")
+	clause := g.use("gorm.io/gorm/clause")
+	fields := g.sortFields(si)
+
+	g.Printf("// %s is the column ListPage sorts %s rows on before their primary\n", sort, name)
+	g.Printf("// key. Only indexed columns can be sorted on.\n")
+	g.Printf("type %s string\n\n", sort)
+	g.Printf("// Columns ListPage can sort %s rows on.\n", name)
+	g.Printf("const (\n")
+	g.Printf("%sByKey %s = \"\" // the primary key alone\n", sort, sort)
+	for _, fi := range fields {
+		g.Printf("%sBy%s %s = %q\n", sort, keyField(fi), sort, si.ColumnMap[fi.FieldName])
+	}
+	g.Printf(")\n\n")
+
+	g.Printf("// columns returns the columns rows are sorted on.\n")
+	g.Printf("func (s %s) columns() ([]string, error) {\n", sort)
+	g.Printf("switch s {\n")
+	g.Printf("case %sByKey:\n", sort)
+	g.Printf("return %sKeyColumns, nil\n", lower)
+	if len(fields) > 0 {
+		cases := make([]string, len(fields))
+		for i, fi := range fields {
+			cases[i] = sort + "By" + keyField(fi)
+		}
+		g.Printf("case %s:\n", strings.Join(cases, ", "))
+		g.Printf("return append([]string{string(s)}, %sKeyColumns...), nil\n", lower)
+	}
+	g.Printf("}\n")
+	g.Printf("return nil, %s.Errorf(\"unknown %s %%q\", string(s))\n", fmt, sort)
+	g.Printf("}\n\n")
+
+	g.Printf("// value returns the value of the sort column of m.\n")
+	g.Printf("func (s %s) value(m *%s) any {\n", sort, model)
+	g.Printf("switch s {\n")
+	for _, fi := range fields {
+		g.Printf("case %sBy%s:\n", sort, keyField(fi))
+		g.Printf("return m.%s\n", fi.FieldName)
+	}
+	g.Printf("}\n")
+	g.Printf("return nil\n")
+	g.Printf("}\n\n")
+
+	g.Printf("// decode decodes a value of the sort column encoded by value.\n")
+	g.Printf("func (s %s) decode(raw %s.RawMessage) (any, error) {\n", sort, json)
+	g.Printf("switch s {\n")
+	for _, fi := range fields {
+		g.Printf("case %sBy%s:\n", sort, keyField(fi))
+		g.Printf("var v %s\n", g.typeOf(fi))
+		g.Printf("err := %s.Unmarshal(raw, &v)\n", json)
+		g.Printf("return v, err\n")
+	}
+	g.Printf("}\n")
+	g.Printf("return nil, nil\n")
+	g.Printf("}\n\n")
+
+	g.Printf("// ListPage returns up to limit rows matching filter, sorted by sort then\n")
+	g.Printf("// primary key, that come after the page the cursor was returned with,\n")
+	g.Printf("// from the first row when it is empty. It also returns the cursor of the\n")
+	g.Printf("// next page, empty after the last one. Unlike offsets, cursors do not skip\n")
+	g.Printf("// nor repeat rows when rows are inserted between pages. Rows whose sort\n")
+	g.Printf("// column is NULL are left out: databases disagree on where NULLs sort,\n")
+	g.Printf("// and a cursor cannot tell them from zero values.\n")
+	g.Printf("func (r *%s) ListPage(ctx %s.Context, filter *%sFilter, sort %s, cursor string, limit int) ([]*%s, string, error) {\n", repo, ctx, name, sort, model)
+	g.Printf("if limit <= 0 {\n")
+	g.Printf("return nil, \"\", %s.Errorf(\"page limit %%d is not positive\", limit)\n", fmt)
+	g.Printf("}\n")
+	g.Printf("columns, err := sort.columns()\n")
+	g.Printf("if err != nil {\n")
+	g.Printf("return nil, \"\", err\n")
+	g.Printf("}\n")
+	g.Printf("db := filter.apply(r.db.WithContext(ctx))\n")
+	g.Printf("if sort != %sByKey {\n", sort)
+	g.Printf("db = db.Where(%s.Neq{Column: %[1]s.Column{Table: %[1]s.CurrentTable, Name: string(sort)}, Value: nil})\n", clause)
+	g.Printf("}\n")
+	g.Printf("if cursor != \"\" {\n")
+	g.Printf("var k %sKey\n", name)
+	g.Printf("raw, err := decodeCursor(cursor, string(sort), &k)\n")
+	g.Printf("if err != nil {\n")
+	g.Printf("return nil, \"\", err\n")
+	g.Printf("}\n")
+	g.Printf("values := k.values()\n")
+	g.Printf("if sort != %sByKey {\n", sort)
+	g.Printf("v, err := sort.decode(raw)\n")
+	g.Printf("if err != nil {\n")
+	g.Printf("return nil, \"\", ErrInvalidCursor\n")
+	g.Printf("}\n")
+	g.Printf("values = append([]any{v}, values...)\n")
+	g.Printf("}\n")
+	g.Printf("db = db.Where(keysetAfter(columns, values))\n")
+	g.Printf("}\n")
+	g.Printf("var ms []*%s\n", model)
+	g.Printf("if err := db.Clauses(keysetOrder(columns)).Limit(limit + 1).Find(&ms).Error; err != nil {\n")
+	g.Printf("return nil, \"\", err\n")
+	g.Printf("}\n")
+	g.Printf("if len(ms) <= limit {\n")
+	g.Printf("return ms, \"\", nil\n")
+	g.Printf("}\n")
+	g.Printf("ms = ms[:limit]\n")
+	g.Printf("last := ms[limit-1]\n")
+	g.Printf("next, err := encodeCursor(string(sort), sort.value(last), r.KeyOf(last))\n")
+	g.Printf("if err != nil {\n")
+	g.Printf("return nil, \"\", err\n")
+	g.Printf("}\n")
+	g.Printf("return ms, next, nil\n")
+	g.Printf("}\n\n")
+}
+
+// sortFields returns the fields ListPage can sort rows on: the leading
+// columns of the indexes of si, leaving out the primary key, columns that
+// cannot be ordered and fields of embedded struct pointers.
+func (g *Generator) sortFields(si *StructInfo) []*FieldInfo {
+	var fields []*FieldInfo
+	seen := make(map[string]bool)
+	for _, idx := range si.Indexes {
+		if len(idx.Fields) == 0 {
+			continue
+		}
+		fi := si.LookUpField(idx.Fields[0].FieldName)
+		if fi == nil || seen[fi.FieldName] || isPrimaryKey(si, fi) || !fi.Permission.Read {
+			continue
+		}
+		seen[fi.FieldName] = true
+		predicate := g.predicate(fi)
+		if !strings.HasPrefix(predicate, "OrderedPredicate") && predicate != "StringPredicate" {
+			continue
+		}
+		if nilGuard(fi, "m") != "" {
+			log.Printf("warning: %s: %s belongs to an embedded struct pointer, ListPage cannot sort on it", si.StructName, fi.FieldName)
+			continue
+		}
+		fields = append(fields, fi)
+	}
+	return fields
+}
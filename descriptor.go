@@ -0,0 +1,127 @@
+package main
+
+import (
+	"go/types"
+	"strings"
+)
+
+// generateField writes the Field type the descriptors of every model of the
+// output package share.
+func (g *Generator) generateField() {
+	clause := g.use("gorm.io/gorm/clause")
+	g.Printf("// Field describes the column of a model field, under the names gorm\n")
+	g.Printf("// resolves for it.\n")
+	g.Printf("type Field struct {\n")
+	g.Printf("Name   string // Go selector, e.g. SourcePosition.HouseID\n")
+	g.Printf("Column string // e.g. src_house_id\n")
+	g.Printf("Table  string\n")
+	g.Printf("Type   string // Go type, e.g. *time.Time\n")
+	g.Printf("}\n\n")
+	g.Printf("// String returns the column of f, to write conditions such as\n")
+	g.Printf("// Mouse_.BirthdayOrArrivalDate.String() + \" > ?\".\n")
+	g.Printf("func (f Field) String() string {\n")
+	g.Printf("return f.Column\n")
+	g.Printf("}\n\n")
+	g.Printf("// Clause returns the column of f qualified by its table, for clause\n")
+	g.Printf("// expressions.\n")
+	g.Printf("func (f Field) Clause() %s.Column {\n", clause)
+	g.Printf("return %s.Column{Table: f.Table, Name: f.Column}\n", clause)
+	g.Printf("}\n\n")
+}
+
+// descriptorGroup is a struct of the descriptor of a model: the model itself
+// or one of its named embedded structs.
+type descriptorGroup struct {
+	prefix string // Go selector of the embedded struct, "" for the model
+	fields []*FieldInfo
+	groups []string // names of the embedded structs holding fields
+}
+
+// generateDescriptor writes the <Model>_ variable describing the columns of
+// si, with a Field per column and a nested struct per named embedded struct,
+// e.g. Transfer_.SourcePosition.HouseID.
+func (g *Generator) generateDescriptor(si *StructInfo) {
+	name := si.StructName
+	typeName := func(prefix string) string {
+		return lowerFirst(name) + strings.ReplaceAll(prefix, ".", "") + "Fields"
+	}
+	groups := map[string]*descriptorGroup{"": {}}
+	order := []string{""}
+	group := func(prefix string) *descriptorGroup {
+		if gr, ok := groups[prefix]; ok {
+			return gr
+		}
+		gr := &descriptorGroup{prefix: prefix}
+		groups[prefix] = gr
+		order = append(order, prefix)
+		return gr
+	}
+	for _, fi := range si.Columns(func(*FieldInfo) bool { return true }) {
+		parts := strings.Split(fi.FieldName, ".")
+		for i := 1; i < len(parts); i++ {
+			parent := group(strings.Join(parts[:i-1], "."))
+			prefix := strings.Join(parts[:i], ".")
+			if _, ok := groups[prefix]; !ok {
+				parent.groups = append(parent.groups, parts[i-1])
+			}
+			group(prefix)
+		}
+		gr := group(strings.Join(parts[:len(parts)-1], "."))
+		gr.fields = append(gr.fields, fi)
+	}
+
+	for _, prefix := range order {
+		gr := groups[prefix]
+		g.Printf("type %s struct {\n", typeName(prefix))
+		for _, fi := range gr.fields {
+			g.Printf("%s Field\n", fi.Name())
+		}
+		for _, sub := range gr.groups {
+			g.Printf("%s %s\n", sub, typeName(join(prefix, sub)))
+		}
+		g.Printf("}\n\n")
+	}
+
+	g.Printf("// %s_ describes the columns of table %q.\n", name, si.TableName)
+	g.Printf("var %s_ = ", name)
+	g.writeGroup(si, groups, "", typeName)
+	g.Printf("\n\n")
+}
+
+// writeGroup writes the composite literal of the descriptor struct of the
+// group with prefix.
+func (g *Generator) writeGroup(si *StructInfo, groups map[string]*descriptorGroup, prefix string, typeName func(string) string) {
+	gr := groups[prefix]
+	g.Printf("%s{\n", typeName(prefix))
+	for _, fi := range gr.fields {
+		g.Printf("%s: Field{Name: %q, Column: %q, Table: %q, Type: %q},\n", fi.Name(), fi.FieldName, si.ColumnMap[fi.FieldName], si.TableName, g.typeName(fi))
+	}
+	for _, sub := range gr.groups {
+		g.Printf("%s: ", sub)
+		g.writeGroup(si, groups, join(prefix, sub), typeName)
+		g.Printf(",\n")
+	}
+	g.Printf("}")
+}
+
+// typeName returns the Go type of fi as written in the output package,
+// without importing the packages it names.
+func (g *Generator) typeName(fi *FieldInfo) string {
+	if fi.Type == nil || fi.Type.typ == nil {
+		return fi.FieldType
+	}
+	return types.TypeString(fi.Type.typ, func(other *types.Package) string {
+		if other == g.pkg.Types && g.samePkg {
+			return ""
+		}
+		return other.Name()
+	})
+}
+
+// join joins Go selectors.
+func join(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
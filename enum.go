@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"go/types"
+	"log"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// EnumInfo is a named integer or string type together with the constants
+// declared for it, typically in an iota block.
+type EnumInfo struct {
+	TypeName   string
+	PkgPath    string
+	Underlying string
+	// Constants are the constant names in declaration order, leaving out
+	// those repeating the value of an earlier one; Values holds their Go
+	// literals.
+	Constants []string
+	Values    []string
+	// Labels are the text forms of the constants: the names of the enums
+	// tag when it agrees with the constants, the constant names stripped of
+	// the type name and lower-cased otherwise.
+	Labels []string
+	// Problems lists how the enums tag disagrees with the constants.
+	Problems []string
+}
+
+// enumOf returns the enum t is, or nil when t is not a named integer or
+// string type with constants.
+func (pkg *Package) enumOf(t types.Type) *EnumInfo {
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return nil
+	}
+	basic, ok := named.Underlying().(*types.Basic)
+	if !ok || basic.Info()&(types.IsInteger|types.IsString) == 0 {
+		return nil
+	}
+	var consts []*types.Const
+	scope := named.Obj().Pkg().Scope()
+	for _, name := range scope.Names() {
+		if c, ok := scope.Lookup(name).(*types.Const); ok && types.Identical(c.Type(), named) {
+			consts = append(consts, c)
+		}
+	}
+	if len(consts) == 0 {
+		return nil
+	}
+	sort.Slice(consts, func(i, j int) bool { return consts[i].Pos() < consts[j].Pos() })
+	enum := &EnumInfo{
+		TypeName:   named.Obj().Name(),
+		PkgPath:    named.Obj().Pkg().Path(),
+		Underlying: basic.Name(),
+	}
+	seen := make(map[string]bool)
+	for _, c := range consts {
+		value := c.Val().ExactString()
+		if seen[value] {
+			continue
+		}
+		seen[value] = true
+		enum.Constants = append(enum.Constants, c.Name())
+		enum.Values = append(enum.Values, value)
+		enum.Labels = append(enum.Labels, strings.ToLower(strings.TrimPrefix(c.Name(), enum.TypeName)))
+	}
+	return enum
+}
+
+// withTag returns a copy of enum checked against the labels of an enums
+// struct tag. The tag may leave out a leading zero constant, which then
+// stands for an unset value.
+func (enum *EnumInfo) withTag(tag string) *EnumInfo {
+	checked := *enum
+	checked.Labels = append([]string(nil), enum.Labels...)
+	checked.Problems = nil
+	if tag == "" {
+		return &checked
+	}
+	labels := toColumns(tag)
+	consts := enum.Constants
+	skip := 0
+	if len(labels) == len(consts)-1 && enum.Values[0] == "0" {
+		skip = 1
+	}
+	if len(labels) != len(consts)-skip {
+		checked.Problems = append(checked.Problems, fmt.Sprintf("enums tag has %d names for %d %s constants", len(labels), len(consts), enum.TypeName))
+		return &checked
+	}
+	for i, label := range labels {
+		c := consts[i+skip]
+		want, got := normalizeLabel(strings.TrimPrefix(c, enum.TypeName)), normalizeLabel(label)
+		if !strings.HasSuffix(want, got) && !strings.HasPrefix(want, got) {
+			checked.Problems = append(checked.Problems, fmt.Sprintf("enums tag name %q at position %d does not match constant %s", label, i+1, c))
+		}
+	}
+	if len(checked.Problems) == 0 {
+		copy(checked.Labels[skip:], labels)
+	}
+	return &checked
+}
+
+func normalizeLabel(s string) string {
+	return strings.Map(func(r rune) rune {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, s)
+}
+
+// generateEnum writes String, IsValid and a <Type>Values function for enum,
+// once per output file, and MarshalText and UnmarshalText when g.enumText is
+// set: they change how encoding/json and other encoders write the enum.
+// Methods can only be declared in the package of the type, and those the
+// package already declares outside of the output file are left alone.
+func (g *Generator) generateEnum(enum *EnumInfo) {
+	key := enum.PkgPath + "." + enum.TypeName
+	if g.enums[key] {
+		return
+	}
+	g.enums[key] = true
+	if !g.samePkg || enum.PkgPath != g.pkg.Path {
+		log.Printf("warning: methods of enum %s are only generated into package %s", enum.TypeName, enum.PkgPath)
+		return
+	}
+	typ := enum.TypeName
+	recv := strings.ToLower(typ[:1])
+	labels := lowerFirst(typ) + "Labels"
+	verb := "%d"
+	if enum.Underlying == "string" {
+		verb = "%q"
+	}
+	invalid := fmt.Sprintf("fmt.Errorf(\"invalid %s %s\", %s(%s))", typ, verb, enum.Underlying, recv)
+
+	if !g.declared("", labels) {
+		g.Printf("// %s maps the %s constants to their text form.\n", labels, typ)
+		g.Printf("var %s = map[%s]string{\n", labels, typ)
+		for i, c := range enum.Constants {
+			g.Printf("%s: %q,\n", c, enum.Labels[i])
+		}
+		g.Printf("}\n\n")
+	}
+	if !g.declared(typ, "String") {
+		g.Printf("// String returns the text form of %s.\n", recv)
+		g.Printf("func (%s %s) String() string {\n", recv, typ)
+		g.use("fmt")
+		g.Printf("if label, ok := %s[%s]; ok {\nreturn label\n}\n", labels, recv)
+		g.Printf("return fmt.Sprintf(\"%s(%s)\", %s(%s))\n", typ, verb, enum.Underlying, recv)
+		g.Printf("}\n\n")
+	}
+	if !g.declared(typ, "IsValid") {
+		g.Printf("// IsValid reports whether %s is one of the %s constants.\n", recv, typ)
+		g.Printf("func (%s %s) IsValid() bool {\n", recv, typ)
+		g.Printf("_, ok := %s[%s]\nreturn ok\n", labels, recv)
+		g.Printf("}\n\n")
+	}
+	if g.enumText && !g.declared(typ, "MarshalText") {
+		g.Printf("// MarshalText implements encoding.TextMarshaler.\n")
+		g.Printf("func (%s %s) MarshalText() ([]byte, error) {\n", recv, typ)
+		g.use("fmt")
+		g.Printf("label, ok := %s[%s]\nif !ok {\nreturn nil, %s\n}\nreturn []byte(label), nil\n", labels, recv, invalid)
+		g.Printf("}\n\n")
+	}
+	if g.enumText && !g.declared(typ, "UnmarshalText") {
+		g.Printf("// UnmarshalText implements encoding.TextUnmarshaler.\n")
+		g.Printf("func (%s *%s) UnmarshalText(text []byte) error {\n", recv, typ)
+		g.use("fmt")
+		g.Printf("for value, label := range %s {\nif label == string(text) {\n*%s = value\nreturn nil\n}\n}\n", labels, recv)
+		g.Printf("return fmt.Errorf(\"invalid %s %%q\", text)\n", typ)
+		g.Printf("}\n\n")
+	}
+	if !g.declared("", typ+"Values") {
+		g.Printf("// %sValues returns the %s constants in declaration order.\n", typ, typ)
+		g.Printf("func %sValues() []%s {\n", typ, typ)
+		g.Printf("return []%s{%s}\n", typ, strings.Join(enum.Constants, ", "))
+		g.Printf("}\n\n")
+	}
+}
+
+// declared reports whether the package declares name in another file than
+// the output file, as a method of typ or at package level when typ is empty.
+func (g *Generator) declared(typ, name string) bool {
+	if g.pkg.Types == nil {
+		return false
+	}
+	scope := g.pkg.Types.Scope()
+	obj := scope.Lookup(name)
+	if typ != "" {
+		obj = nil
+		if tn, ok := scope.Lookup(typ).(*types.TypeName); ok {
+			obj, _, _ = types.LookupFieldOrMethod(types.NewPointer(tn.Type()), false, g.pkg.Types, name)
+		}
+	}
+	return obj != nil && g.pkg.Fset.Position(obj.Pos()).Filename != g.outFile
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEnumWithTag(t *testing.T) {
+	gender := &EnumInfo{
+		TypeName:   "Gender",
+		Underlying: "uint8",
+		Constants:  []string{"GenderUnknown", "GenderMale", "GenderFemale"},
+		Values:     []string{"0", "1", "2"},
+		Labels:     []string{"unknown", "male", "female"},
+	}
+	level := &EnumInfo{
+		TypeName:   "Level",
+		Underlying: "int",
+		Constants:  []string{"LevelLow", "LevelHigh"},
+		Values:     []string{"1", "2"},
+		Labels:     []string{"low", "high"},
+	}
+	tests := []struct {
+		enum     *EnumInfo
+		tag      string
+		labels   []string
+		problems []string
+	}{
+		{gender, "", []string{"unknown", "male", "female"}, nil},
+		{gender, "unknown,male,female", []string{"unknown", "male", "female"}, nil},
+		{gender, "none,male,female", []string{"unknown", "male", "female"}, []string{
+			`enums tag name "none" at position 1 does not match constant GenderUnknown`,
+		}},
+		{gender, "Male, FEMALE", []string{"unknown", "Male", "FEMALE"}, nil},
+		{gender, "m,f", []string{"unknown", "m", "f"}, nil},
+		{gender, "female,male", []string{"unknown", "male", "female"}, []string{
+			`enums tag name "female" at position 1 does not match constant GenderMale`,
+		}},
+		{gender, "male", []string{"unknown", "male", "female"}, []string{"enums tag has 1 names for 3 Gender constants"}},
+		{level, "high", []string{"low", "high"}, []string{"enums tag has 1 names for 2 Level constants"}},
+		{level, "l,h", []string{"l", "h"}, nil},
+	}
+	for _, tt := range tests {
+		got := tt.enum.withTag(tt.tag)
+		if !reflect.DeepEqual(got.Labels, tt.labels) || !reflect.DeepEqual(got.Problems, tt.problems) {
+			t.Errorf("%s.withTag(%q) = %q %q, want %q %q", tt.enum.TypeName, tt.tag, got.Labels, got.Problems, tt.labels, tt.problems)
+		}
+	}
+	if want := []string{"unknown", "male", "female"}; !reflect.DeepEqual(gender.Labels, want) {
+		t.Errorf("withTag changed the labels of the enum to %q", gender.Labels)
+	}
+}
+
+func TestGenerateEnumText(t *testing.T) {
+	pkg, err := LoadPackage(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	si, err := pkg.ParseStruct("Mouse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var enum *EnumInfo
+	for _, fi := range si.FieldInfo {
+		if fi.Name() == "Gender" {
+			enum = fi.Enum
+		}
+	}
+	if enum == nil {
+		t.Fatal("Mouse.Gender is not an enum")
+	}
+	for _, enumText := range []bool{false, true} {
+		g := NewGenerator(pkg, pkg.Name, filepath.Join(pkg.Dir, "mouse_crud.go"))
+		g.enumText = enumText
+		g.generateEnum(enum)
+		src := g.buf.String()
+		for _, method := range []string{"String", "IsValid", "MarshalText", "UnmarshalText"} {
+			want := enumText || method == "String" || method == "IsValid"
+			if got := strings.Contains(src, ") "+method+"("); got != want {
+				t.Errorf("enumText %v: %s generated %v, want %v", enumText, method, got, want)
+			}
+		}
+	}
+}
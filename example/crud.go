@@ -0,0 +1,5291 @@
+// Code generated by gormaid. DO NOT EDIT.
+
+package example
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// QueryOption tunes the rows a Get, List or Count method reads.
+type QueryOption func(*queryOptions)
+
+type queryOptions struct {
+	limit    int
+	offset   int
+	orders   []clause.OrderByColumn
+	deleted  bool
+	preloads []string
+}
+
+// WithLimit reads at most n rows.
+func WithLimit(n int) QueryOption {
+	return func(o *queryOptions) { o.limit = n }
+}
+
+// WithOffset skips the first n rows.
+func WithOffset(n int) QueryOption {
+	return func(o *queryOptions) { o.offset = n }
+}
+
+// WithOrder sorts the rows by column, in descending order when desc is
+// set. Repeat it to sort on several columns. Rows are sorted by primary
+// key after the given orders, or alone when there are none.
+func WithOrder(column string, desc bool) QueryOption {
+	return func(o *queryOptions) {
+		o.orders = append(o.orders, clause.OrderByColumn{Column: clause.Column{Table: clause.CurrentTable, Name: column}, Desc: desc})
+	}
+}
+
+// WithDeleted also reads the soft deleted rows.
+func WithDeleted() QueryOption {
+	return func(o *queryOptions) { o.deleted = true }
+}
+
+func newQueryOptions(opts []QueryOption) *queryOptions {
+	o := &queryOptions{limit: -1, offset: -1}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// scope adds the soft deleted rows to those of db when asked to.
+func (o *queryOptions) scope(db *gorm.DB) *gorm.DB {
+	if o.deleted {
+		return db.Unscoped()
+	}
+	return db
+}
+
+// read scopes db and preloads the associations of the rows it reads.
+func (o *queryOptions) read(db *gorm.DB) *gorm.DB {
+	db = o.scope(db)
+	for _, association := range o.preloads {
+		db = db.Preload(association)
+	}
+	return db
+}
+
+// page reads, sorts, skips and limits the rows of db, sorting last by
+// keyColumns for rows tied on the orders to keep the pages stable.
+func (o *queryOptions) page(db *gorm.DB, keyColumns []string) *gorm.DB {
+	db = o.read(db)
+	orders := append([]clause.OrderByColumn{}, o.orders...)
+	for _, column := range keyColumns {
+		orders = append(orders, clause.OrderByColumn{Column: clause.Column{Table: clause.CurrentTable, Name: column}})
+	}
+	if len(orders) > 0 {
+		db = db.Clauses(clause.OrderBy{Columns: orders})
+	}
+	return db.Limit(o.limit).Offset(o.offset)
+}
+
+// maxKeyParams bounds the parameters of a statement matching a batch of
+// keys, below the limit of every dialect.
+const maxKeyParams = 999
+
+// keysIn matches the rows whose columns hold one of keys, each key listing
+// the values of columns in order. Composite keys are compared as row
+// values, except on SQL Server which lacks them. A lone Or would be joined
+// to the previous conditions with OR, hence the And around it.
+func keysIn(db *gorm.DB, columns []string, keys [][]any) clause.Expression {
+	cols := make([]clause.Column, len(columns))
+	for i, column := range columns {
+		cols[i] = clause.Column{Table: clause.CurrentTable, Name: column}
+	}
+	values := make([]any, len(keys))
+	switch {
+	case len(cols) == 1:
+		for i, key := range keys {
+			values[i] = key[0]
+		}
+		return clause.IN{Column: cols[0], Values: values}
+	case db.Dialector.Name() == "sqlserver":
+		ors := make([]clause.Expression, len(keys))
+		for i, key := range keys {
+			eqs := make([]clause.Expression, len(cols))
+			for j, col := range cols {
+				eqs[j] = clause.Eq{Column: col, Value: key[j]}
+			}
+			ors[i] = clause.And(eqs...)
+		}
+		return clause.And(clause.Or(ors...))
+	}
+	for i, key := range keys {
+		values[i] = key
+	}
+	return clause.IN{Column: cols, Values: values}
+}
+
+// ErrNilKey is wrapped by the errors of writes given a row whose nil
+// embedded struct holds primary key columns: they would be stored as NULL,
+// which no key matches.
+var ErrNilKey = errors.New("nil embedded struct in primary key")
+
+// NotFoundError is returned when no row matches a lookup by primary key or
+// unique index. errors.Is matches it with gorm.ErrRecordNotFound.
+type NotFoundError struct {
+	Model string // the model looked up, e.g. User
+	Index string // the unique index used, or "primary key"
+	Key   []any  // the values looked up, in index order
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s not found by %s %v", e.Model, e.Index, e.Key)
+}
+
+// Is reports whether target is gorm.ErrRecordNotFound.
+func (e *NotFoundError) Is(target error) bool {
+	return target == gorm.ErrRecordNotFound
+}
+
+// notFound turns the gorm.ErrRecordNotFound of a lookup into a
+// *NotFoundError, other errors are returned as is.
+func notFound(err error, model, index string, key ...any) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &NotFoundError{Model: model, Index: index, Key: key}
+	}
+	return err
+}
+
+// updated returns the error of res, an update by primary key, or a
+// *NotFoundError when no row has the key. Some databases, such as MySQL,
+// count the rows an update changes rather than those it matches, so an
+// update affecting no row asks exists whether the row is there.
+func updated(res *gorm.DB, exists func() (bool, error), model string, key []any) error {
+	if res.Error != nil || res.RowsAffected > 0 {
+		return res.Error
+	}
+	if ok, err := exists(); err != nil || ok {
+		return err
+	}
+	return &NotFoundError{Model: model, Index: "primary key", Key: key}
+}
+
+// Ptr returns a pointer to v, to fill the Eq and range fields of predicates.
+func Ptr[T any](v T) *T {
+	return &v
+}
+
+// Predicate tests the value of a column. Every field that is set must
+// hold; the zero Predicate matches every row. A nil In matches every row
+// while an empty one matches none.
+type Predicate[T any] struct {
+	Eq     *T
+	NotEq  *T
+	In     []T
+	NotIn  []T
+	IsNull *bool // false for IS NOT NULL
+}
+
+func (p Predicate[T]) conditions(column string) []clause.Expression {
+	col := clause.Column{Table: clause.CurrentTable, Name: column}
+	var conds []clause.Expression
+	if p.Eq != nil {
+		conds = append(conds, clause.Eq{Column: col, Value: *p.Eq})
+	}
+	if p.NotEq != nil {
+		conds = append(conds, clause.Neq{Column: col, Value: *p.NotEq})
+	}
+	if p.In != nil {
+		conds = append(conds, clause.IN{Column: col, Values: anySlice(p.In)})
+	}
+	if len(p.NotIn) > 0 {
+		conds = append(conds, clause.Not(clause.IN{Column: col, Values: anySlice(p.NotIn)}))
+	}
+	if p.IsNull != nil && *p.IsNull {
+		conds = append(conds, clause.Eq{Column: col, Value: nil})
+	} else if p.IsNull != nil {
+		conds = append(conds, clause.Neq{Column: col, Value: nil})
+	}
+	return conds
+}
+
+// OrderedPredicate is a Predicate on a column whose values are ordered,
+// such as numbers and times.
+type OrderedPredicate[T any] struct {
+	Eq     *T
+	NotEq  *T
+	In     []T
+	NotIn  []T
+	IsNull *bool // false for IS NOT NULL
+	Gt     *T
+	Gte    *T
+	Lt     *T
+	Lte    *T
+}
+
+func (p OrderedPredicate[T]) conditions(column string) []clause.Expression {
+	conds := Predicate[T]{Eq: p.Eq, NotEq: p.NotEq, In: p.In, NotIn: p.NotIn, IsNull: p.IsNull}.conditions(column)
+	col := clause.Column{Table: clause.CurrentTable, Name: column}
+	if p.Gt != nil {
+		conds = append(conds, clause.Gt{Column: col, Value: *p.Gt})
+	}
+	if p.Gte != nil {
+		conds = append(conds, clause.Gte{Column: col, Value: *p.Gte})
+	}
+	if p.Lt != nil {
+		conds = append(conds, clause.Lt{Column: col, Value: *p.Lt})
+	}
+	if p.Lte != nil {
+		conds = append(conds, clause.Lte{Column: col, Value: *p.Lte})
+	}
+	return conds
+}
+
+// StringPredicate is an OrderedPredicate on a text column that can also
+// be matched against a LIKE pattern.
+type StringPredicate struct {
+	Eq     *string
+	NotEq  *string
+	In     []string
+	NotIn  []string
+	IsNull *bool // false for IS NOT NULL
+	Gt     *string
+	Gte    *string
+	Lt     *string
+	Lte    *string
+	Like   *string
+}
+
+func (p StringPredicate) conditions(column string) []clause.Expression {
+	conds := OrderedPredicate[string]{Eq: p.Eq, NotEq: p.NotEq, In: p.In, NotIn: p.NotIn, IsNull: p.IsNull, Gt: p.Gt, Gte: p.Gte, Lt: p.Lt, Lte: p.Lte}.conditions(column)
+	if p.Like != nil {
+		conds = append(conds, clause.Like{Column: clause.Column{Table: clause.CurrentTable, Name: column}, Value: *p.Like})
+	}
+	return conds
+}
+
+func anySlice[T any](values []T) []any {
+	s := make([]any, len(values))
+	for i, v := range values {
+		s[i] = v
+	}
+	return s
+}
+
+// UpsertOption tells an Upsert method what to do with the row already
+// holding the key of a row it inserts. By default every updatable column
+// of that row is overwritten.
+type UpsertOption func(*upsertOptions)
+
+type upsertOptions struct {
+	columns   []string
+	doNothing bool
+}
+
+// UpdateAll overwrites every updatable column of the existing row.
+func UpdateAll() UpsertOption {
+	return func(o *upsertOptions) { o.columns, o.doNothing = nil, false }
+}
+
+// UpdateOnly overwrites the given columns of the existing row. They must
+// all be updatable. Without columns, the row is kept as with DoNothing.
+func UpdateOnly(columns ...string) UpsertOption {
+	return func(o *upsertOptions) { o.columns, o.doNothing = append([]string{}, columns...), false }
+}
+
+// DoNothing keeps the existing row as it is.
+func DoNothing() UpsertOption {
+	return func(o *upsertOptions) { o.columns, o.doNothing = nil, true }
+}
+
+// onConflict returns the clause resolving conflicts on the target columns
+// with the options in opts, where the columns of updatable may be
+// overwritten. A non empty where restricts target to a partial index.
+func onConflict(target []string, where string, updatable []string, opts []UpsertOption) (clause.OnConflict, error) {
+	var o upsertOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	c := clause.OnConflict{Columns: make([]clause.Column, len(target))}
+	for i, column := range target {
+		c.Columns[i] = clause.Column{Name: column}
+	}
+	if where != "" {
+		c.TargetWhere = clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: where}}}
+	}
+	columns := updatable
+	if o.columns != nil {
+		columns = o.columns
+		allowed := make(map[string]bool, len(updatable))
+		for _, column := range updatable {
+			allowed[column] = true
+		}
+		for _, column := range columns {
+			if !allowed[column] {
+				return c, fmt.Errorf("upsert: column %q cannot be updated", column)
+			}
+		}
+	}
+	if o.doNothing || len(columns) == 0 {
+		c.DoNothing = true
+	} else {
+		c.DoUpdates = clause.AssignmentColumns(columns)
+	}
+	return c, nil
+}
+
+// ErrInvalidCursor is returned by the ListPage methods given a cursor
+// they did not issue, or issued for another sort.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursor is the position a ListPage method resumes after: the sort
+// column of the last row read, its value and its primary key.
+type cursor struct {
+	Sort  string          `json:"s,omitempty"`
+	Value json.RawMessage `json:"v,omitempty"`
+	Key   json.RawMessage `json:"k"`
+}
+
+// encodeCursor returns the opaque token of a cursor.
+func encodeCursor(sort string, value, key any) (string, error) {
+	c := cursor{Sort: sort}
+	var err error
+	if sort != "" {
+		if c.Value, err = json.Marshal(value); err != nil {
+			return "", err
+		}
+	}
+	if c.Key, err = json.Marshal(key); err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decodeCursor decodes the primary key of token into key and returns the
+// encoded value of the sort column.
+func decodeCursor(token, sort string, key any) (json.RawMessage, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(b, &c); err != nil || c.Sort != sort {
+		return nil, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(c.Key, key); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return c.Value, nil
+}
+
+// keysetAfter matches the rows sorting after values on columns. It spells
+// out the row value comparison (a, b) > (?, ?), which SQL Server lacks.
+// The disjunction is wrapped in And: gorm joins a lone Or to the previous
+// conditions with OR.
+func keysetAfter(columns []string, values []any) clause.Expression {
+	ors := make([]clause.Expression, len(columns))
+	for i := range columns {
+		ands := make([]clause.Expression, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: columns[j]}, Value: values[j]})
+		}
+		ands = append(ands, clause.Gt{Column: clause.Column{Table: clause.CurrentTable, Name: columns[i]}, Value: values[i]})
+		ors[i] = clause.And(ands...)
+	}
+	return clause.And(clause.Or(ors...))
+}
+
+// keysetOrder sorts rows on columns in ascending order.
+func keysetOrder(columns []string) clause.OrderBy {
+	orders := make([]clause.OrderByColumn, len(columns))
+	for i, column := range columns {
+		orders[i] = clause.OrderByColumn{Column: clause.Column{Table: clause.CurrentTable, Name: column}}
+	}
+	return clause.OrderBy{Columns: orders}
+}
+
+// ErrStop is returned by the callback of an Each or ForEachBatch method to
+// stop iterating without error.
+var ErrStop = errors.New("stop iteration")
+
+// eachBatchSize is the number of rows the Each and Stream methods read
+// per query.
+const eachBatchSize = 500
+
+// ErrNotInTx is returned by the ForUpdate methods of a repository that was
+// not obtained from WithTx or RunInTx: outside of a transaction, row locks
+// are released as soon as the rows are read.
+var ErrNotInTx = errors.New("row locks need a transaction from WithTx or RunInTx")
+
+// LockOption tunes the row locks a ForUpdate method takes.
+type LockOption func(*clause.Locking)
+
+// SkipLocked leaves out the rows another transaction has locked instead
+// of waiting for them.
+func SkipLocked() LockOption {
+	return func(l *clause.Locking) { l.Options = "SKIP LOCKED" }
+}
+
+// NoWait fails instead of waiting for the rows another transaction has
+// locked.
+func NoWait() LockOption {
+	return func(l *clause.Locking) { l.Options = "NOWAIT" }
+}
+
+// ForShare takes shared locks, which let other transactions read the rows
+// but not update them.
+func ForShare() LockOption {
+	return func(l *clause.Locking) { l.Strength = "SHARE" }
+}
+
+// lockRows locks the rows db reads as opts tell, provided db is the
+// transaction of a repository from WithTx or RunInTx. SQLite, which
+// locks the whole database instead of rows, reads them as they are.
+func lockRows(db *gorm.DB, inTx bool, opts []LockOption) (*gorm.DB, error) {
+	if _, ok := db.Statement.ConnPool.(gorm.TxCommitter); !inTx || !ok {
+		return nil, ErrNotInTx
+	}
+	l := clause.Locking{Strength: "UPDATE"}
+	for _, opt := range opts {
+		opt(&l)
+	}
+	switch name := db.Dialector.Name(); name {
+	case "sqlite":
+		return db, nil
+	case "sqlserver":
+		return nil, fmt.Errorf("row locks are not supported on %s", name)
+	}
+	return db.Clauses(l), nil
+}
+
+// Field describes the column of a model field, under the names gorm
+// resolves for it.
+type Field struct {
+	Name   string // Go selector, e.g. SourcePosition.HouseID
+	Column string // e.g. src_house_id
+	Table  string
+	Type   string // Go type, e.g. *time.Time
+}
+
+// String returns the column of f, to write conditions such as
+// Mouse_.BirthdayOrArrivalDate.String() + " > ?".
+func (f Field) String() string {
+	return f.Column
+}
+
+// Clause returns the column of f qualified by its table, for clause
+// expressions.
+func (f Field) Clause() clause.Column {
+	return clause.Column{Table: f.Table, Name: f.Column}
+}
+
+// fakeUnique is a unique index the fake repositories enforce.
+type fakeUnique struct {
+	index   string
+	columns []string
+	key     bool // the primary key
+}
+
+// fakeSchema describes a model to the fakeTable holding its rows.
+type fakeSchema[M any] struct {
+	model         string
+	columns       []string // every column
+	readColumns   []string
+	createColumns []string
+	updateColumns []string
+	keyColumns    []string
+	autoUpdate    []string     // the columns gorm sets to the update time
+	uniques       []fakeUnique // the unique indexes besides the primary key
+	softDelete    string       // the soft delete column, "" when deletes are for good
+	nullDefaults  []string     // the creatable columns defaulting to NULL
+	// value returns the value of column in m, nil when m holds NULL.
+	value func(m *M, column string) any
+	// copy copies column from src to dst.
+	copy func(dst, src *M, column string)
+	// stamp sets the columns gorm sets to the creation time, when create is
+	// set, and to the update time.
+	stamp func(m *M, now time.Time, create bool)
+	// increment gives m the auto-increment key following *last when its key
+	// is zero, and keeps *last at the greatest key. It is nil when the key
+	// is not auto-incremented.
+	increment func(m *M, last *int64)
+	// delete sets the soft delete column of m to at, NULL when at is nil.
+	delete func(m *M, at *time.Time)
+}
+
+// fakeTable holds the rows of a fake repository and does the work of its
+// methods, which hold mu while they call it, except for page and
+// forEachBatch that lock mu themselves.
+type fakeTable[M any] struct {
+	// Now returns the time rows are stamped with on create, update and
+	// soft delete, time.Now when nil.
+	Now func() time.Time
+
+	schema  *fakeSchema[M]
+	mu      sync.Mutex
+	rows    []*M
+	lastID  int64
+	related map[string][]any // associated values by association and key
+	// nulls holds the columns of rows that are NULL although their field
+	// is not nil: gorm leaves zero values out of inserts when the column
+	// has a default, here NULL.
+	nulls map[*M]map[string]bool
+}
+
+// clock returns the time gorm would stamp rows with.
+func (t *fakeTable[M]) clock() time.Time {
+	if t.Now != nil {
+		return t.Now()
+	}
+	return time.Now()
+}
+
+// value returns the value of column in m, nil when it is NULL.
+func (t *fakeTable[M]) value(m *M, column string) any {
+	if t.nulls[m][column] {
+		return nil
+	}
+	return t.schema.value(m, column)
+}
+
+// setNulls records the columns of row that are NULL.
+func (t *fakeTable[M]) setNulls(row *M, nulls map[string]bool) {
+	if len(nulls) == 0 {
+		delete(t.nulls, row)
+		return
+	}
+	if t.nulls == nil {
+		t.nulls = make(map[*M]map[string]bool)
+	}
+	t.nulls[row] = nulls
+}
+
+// values returns the values of columns in m.
+func (t *fakeTable[M]) values(m *M, columns []string) []any {
+	values := make([]any, len(columns))
+	for i, column := range columns {
+		values[i] = t.value(m, column)
+	}
+	return values
+}
+
+// visible reports whether row is read, soft deleted rows only when deleted
+// is set.
+func (t *fakeTable[M]) visible(row *M, deleted bool) bool {
+	return deleted || t.schema.softDelete == "" || sqlValue(t.value(row, t.schema.softDelete)) == nil
+}
+
+// find returns the row holding values in columns, nil when there is none.
+func (t *fakeTable[M]) find(columns []string, values []any, deleted bool) *M {
+	for _, row := range t.rows {
+		if t.visible(row, deleted) && compareRows(t.values(row, columns), values) == 0 {
+			return row
+		}
+	}
+	return nil
+}
+
+// where returns the keep function of the match method of a filter.
+func (t *fakeTable[M]) where(match func(value func(column string) any) bool) func(*M) bool {
+	return func(m *M) bool {
+		return match(func(column string) any { return t.value(m, column) })
+	}
+}
+
+// match returns the rows keep accepts, soft deleted ones only when deleted
+// is set.
+func (t *fakeTable[M]) match(keep func(*M) bool, deleted bool) []*M {
+	var rows []*M
+	for _, row := range t.rows {
+		if t.visible(row, deleted) && keep(row) {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// clone returns a copy of the columns of row.
+func (t *fakeTable[M]) clone(row *M, columns []string) *M {
+	m := new(M)
+	for _, column := range columns {
+		t.schema.copy(m, row, column)
+	}
+	return m
+}
+
+// read returns copies of the readable columns of rows, as gorm reads them.
+func (t *fakeTable[M]) read(rows []*M) []*M {
+	ms := make([]*M, len(rows))
+	for i, row := range rows {
+		ms[i] = t.clone(row, t.schema.readColumns)
+	}
+	return ms
+}
+
+// conflicting returns the row other than self holding the values of row in
+// the columns of u, deleted or not. As in SQL, NULLs only conflict in
+// the primary key, which the database does not let be NULL.
+func (t *fakeTable[M]) conflicting(row, self *M, u fakeUnique) *M {
+	values := t.values(row, u.columns)
+	for _, v := range values {
+		if !u.key && sqlValue(v) == nil {
+			return nil
+		}
+	}
+	for _, other := range t.rows {
+		if other != self && compareRows(t.values(other, u.columns), values) == 0 {
+			return other
+		}
+	}
+	return nil
+}
+
+// conflict returns the error of the database storing row, in place of self
+// when it is not nil, as another row has its primary key or the values of
+// one of its unique indexes.
+func (t *fakeTable[M]) conflict(row, self *M) error {
+	indexes := t.schema.uniques
+	if len(t.schema.keyColumns) > 0 {
+		indexes = append([]fakeUnique{{index: "primary key", columns: t.schema.keyColumns, key: true}}, indexes...)
+	}
+	for _, u := range indexes {
+		if t.conflicting(row, self, u) != nil {
+			return fmt.Errorf("%w: %s %s %v", gorm.ErrDuplicatedKey, t.schema.model, u.index, t.values(row, u.columns))
+		}
+	}
+	return nil
+}
+
+// insert stamps m as gorm does on create and stores its creatable columns,
+// unless another row has the same key.
+func (t *fakeTable[M]) insert(m *M) error {
+	t.schema.stamp(m, t.clock(), true)
+	if t.schema.increment != nil {
+		t.schema.increment(m, &t.lastID)
+	}
+	row := t.clone(m, t.schema.createColumns)
+	nulls := make(map[string]bool)
+	for _, column := range t.schema.nullDefaults {
+		if v := t.schema.value(row, column); v != nil && reflect.ValueOf(v).IsZero() {
+			nulls[column] = true
+		}
+	}
+	t.setNulls(row, nulls)
+	if err := t.conflict(row, nil); err != nil {
+		delete(t.nulls, row)
+		return err
+	}
+	t.rows = append(t.rows, row)
+	return nil
+}
+
+// update writes columns of m to row, after stamping the update time on m
+// when stamp is set, unless another row then has the same key.
+func (t *fakeTable[M]) update(row, m *M, columns []string, stamp bool) error {
+	if stamp {
+		t.schema.stamp(m, t.clock(), false)
+		columns = append(columns[:len(columns):len(columns)], t.schema.autoUpdate...)
+	}
+	next := t.clone(row, t.schema.columns)
+	for _, column := range columns {
+		t.schema.copy(next, m, column)
+	}
+	nulls := make(map[string]bool)
+	for column := range t.nulls[row] {
+		nulls[column] = true
+	}
+	for _, column := range columns {
+		delete(nulls, column)
+	}
+	t.setNulls(next, nulls)
+	err := t.conflict(next, row)
+	delete(t.nulls, next)
+	if err != nil {
+		return err
+	}
+	*row = *next
+	t.setNulls(row, nulls)
+	return nil
+}
+
+// upsert inserts m or, when a row holds its values in the conflict target
+// of c, updates that row as c tells and gives m its auto-increment key,
+// which the database returns for the updated row.
+func (t *fakeTable[M]) upsert(m *M, c clause.OnConflict) error {
+	target := make([]string, len(c.Columns))
+	for i, column := range c.Columns {
+		target[i] = column.Name
+	}
+	u := fakeUnique{columns: target, key: strings.Join(target, ",") == strings.Join(t.schema.keyColumns, ",")}
+	row := t.conflicting(t.clone(m, t.schema.createColumns), nil, u)
+	if row == nil {
+		return t.insert(m)
+	}
+	if c.DoNothing {
+		return nil
+	}
+	t.schema.stamp(m, t.clock(), true)
+	columns := make([]string, len(c.DoUpdates))
+	for i, assignment := range c.DoUpdates {
+		columns[i] = assignment.Column.Name
+	}
+	if err := t.update(row, m, columns, false); err != nil {
+		return err
+	}
+	if t.schema.increment != nil {
+		for _, column := range t.schema.keyColumns {
+			t.schema.copy(m, row, column)
+		}
+	}
+	return nil
+}
+
+// remove soft deletes rows or, for good when purge is set or the model has
+// no soft delete column, removes them.
+func (t *fakeTable[M]) remove(rows []*M, purge bool) {
+	if t.schema.softDelete != "" && !purge {
+		now := t.clock()
+		for _, row := range rows {
+			t.schema.delete(row, &now)
+		}
+		return
+	}
+	removed := make(map[*M]bool, len(rows))
+	for _, row := range rows {
+		removed[row] = true
+		delete(t.nulls, row)
+	}
+	kept := make([]*M, 0, len(t.rows))
+	for _, row := range t.rows {
+		if !removed[row] {
+			kept = append(kept, row)
+		}
+	}
+	t.rows = kept
+}
+
+// restore brings back the soft deleted row, bumping its update time as
+// gorm does.
+func (t *fakeTable[M]) restore(row *M) {
+	t.schema.delete(row, nil)
+	t.schema.stamp(row, t.clock(), false)
+}
+
+// save copies the rows and returns the func putting the copy back.
+func (t *fakeTable[M]) save() func() {
+	rows := make([]*M, len(t.rows))
+	nulls := make(map[*M]map[string]bool, len(t.nulls))
+	for i, row := range t.rows {
+		saved := *row
+		rows[i] = &saved
+		if columns, ok := t.nulls[row]; ok {
+			nulls[&saved] = make(map[string]bool, len(columns))
+			for column := range columns {
+				nulls[&saved][column] = true
+			}
+		}
+	}
+	related := make(map[string][]any, len(t.related))
+	for key, values := range t.related {
+		related[key] = append([]any(nil), values...)
+	}
+	lastID := t.lastID
+	return func() {
+		t.rows, t.nulls, t.related, t.lastID = rows, nulls, related, lastID
+	}
+}
+
+// transaction calls fn and restores the rows when it fails, as a database
+// rolls back a statement writing several rows.
+func (t *fakeTable[M]) transaction(fn func() error) error {
+	restore := t.save()
+	if err := fn(); err != nil {
+		restore()
+		return err
+	}
+	return nil
+}
+
+// snapshot saves the rows like save, for Store.RunInTx to restore them
+// when its function fails.
+func (t *fakeTable[M]) snapshot() func() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	restore := t.save()
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		restore()
+	}
+}
+
+// sort sorts rows on orders, then by primary key as the repositories do.
+// Like in SQL, unknown columns are an error.
+func (t *fakeTable[M]) sort(rows []*M, orders []clause.OrderByColumn) error {
+	orders = append([]clause.OrderByColumn{}, orders...)
+	for _, column := range t.schema.keyColumns {
+		orders = append(orders, clause.OrderByColumn{Column: clause.Column{Name: column}})
+	}
+	for _, order := range orders {
+		known := false
+		for _, column := range t.schema.columns {
+			known = known || column == order.Column.Name
+		}
+		if !known {
+			return fmt.Errorf("%s has no column %q", t.schema.model, order.Column.Name)
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		for _, order := range orders {
+			c := compareValues(t.value(rows[i], order.Column.Name), t.value(rows[j], order.Column.Name))
+			if order.Desc {
+				c = -c
+			}
+			if c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+	return nil
+}
+
+// after returns the rows sorting after values on columns.
+func (t *fakeTable[M]) after(rows []*M, columns []string, values []any) []*M {
+	var kept []*M
+	for _, row := range rows {
+		if compareRows(t.values(row, columns), values) > 0 {
+			kept = append(kept, row)
+		}
+	}
+	return kept
+}
+
+// list returns the rows keep accepts, sorted and paged by o.
+func (t *fakeTable[M]) list(keep func(*M) bool, o *queryOptions) ([]*M, error) {
+	rows := t.match(keep, o.deleted)
+	if err := t.sort(rows, o.orders); err != nil {
+		return nil, err
+	}
+	if o.offset > len(rows) {
+		rows = nil
+	} else if o.offset > 0 {
+		rows = rows[o.offset:]
+	}
+	if o.limit >= 0 && o.limit < len(rows) {
+		rows = rows[:o.limit]
+	}
+	return t.read(rows), nil
+}
+
+// page returns up to limit rows keep accepts, sorted on columns, after the
+// values on columns when they are not nil. It also reports whether more
+// rows follow.
+func (t *fakeTable[M]) page(keep func(*M) bool, columns []string, values []any, limit int) ([]*M, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	rows := t.match(keep, false)
+	if values != nil {
+		rows = t.after(rows, columns, values)
+	}
+	orders := make([]clause.OrderByColumn, len(columns))
+	for i, column := range columns {
+		orders[i] = clause.OrderByColumn{Column: clause.Column{Name: column}}
+	}
+	t.sort(rows, orders)
+	if len(rows) <= limit {
+		return t.read(rows), false
+	}
+	return t.read(rows[:limit]), true
+}
+
+// forEachBatch calls fn with the rows keep accepts like the ForEachBatch
+// method of a repository, reading a batch at a time so that fn can write
+// the rows.
+func (t *fakeTable[M]) forEachBatch(ctx context.Context, keep func(*M) bool, batchSize int, fn func([]*M) error) error {
+	if batchSize <= 0 {
+		return fmt.Errorf("batch size %d is not positive", batchSize)
+	}
+	var after []any
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		ms, more := t.page(keep, t.schema.keyColumns, after, batchSize)
+		if len(ms) == 0 {
+			return nil
+		}
+		after = t.values(ms[len(ms)-1], t.schema.keyColumns)
+		if err := fn(ms); err != nil {
+			if errors.Is(err, ErrStop) {
+				return nil
+			}
+			return err
+		}
+		if !more {
+			return nil
+		}
+	}
+}
+
+// associationKey returns the key of the values associated with m through
+// association.
+func (t *fakeTable[M]) associationKey(association string, m *M) string {
+	return fmt.Sprint(association, t.values(m, t.schema.keyColumns))
+}
+
+// associate associates values with m through association, skipping those
+// already associated.
+func (t *fakeTable[M]) associate(association string, m *M, values []any) {
+	if t.related == nil {
+		t.related = make(map[string][]any)
+	}
+	key := t.associationKey(association, m)
+	for _, v := range values {
+		if !containsDeep(t.related[key], v) {
+			t.related[key] = append(t.related[key], v)
+		}
+	}
+}
+
+// dissociate removes values from those associated with m through
+// association.
+func (t *fakeTable[M]) dissociate(association string, m *M, values []any) {
+	key := t.associationKey(association, m)
+	var kept []any
+	for _, v := range t.related[key] {
+		if !containsDeep(values, v) {
+			kept = append(kept, v)
+		}
+	}
+	t.related[key] = kept
+}
+
+// associated returns the values associated with m through association.
+func (t *fakeTable[M]) associated(association string, m *M) []any {
+	return t.related[t.associationKey(association, m)]
+}
+
+// containsDeep reports whether values holds a value deeply equal to v.
+func containsDeep(values []any, v any) bool {
+	for _, value := range values {
+		if reflect.DeepEqual(value, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// sqlValue returns the value a database holds for v: nil for a nil pointer,
+// the value of a driver.Valuer, v otherwise.
+func sqlValue(v any) any {
+	for {
+		rv := reflect.ValueOf(v)
+		if !rv.IsValid() || rv.Kind() == reflect.Pointer && rv.IsNil() {
+			return nil
+		}
+		if valuer, ok := v.(driver.Valuer); ok {
+			if value, err := valuer.Value(); err == nil {
+				return value
+			}
+			return v
+		}
+		if rv.Kind() != reflect.Pointer {
+			return v
+		}
+		v = rv.Elem().Interface()
+	}
+}
+
+// compareValues compares the database values of a and b, NULL sorting
+// first.
+func compareValues(a, b any) int {
+	a, b = sqlValue(a), sqlValue(b)
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	if ta, ok := a.(time.Time); ok {
+		if tb, ok := b.(time.Time); ok {
+			switch {
+			case ta.Before(tb):
+				return -1
+			case ta.After(tb):
+				return 1
+			}
+			return 0
+		}
+	}
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	switch {
+	case va.Kind() == reflect.String && vb.Kind() == reflect.String:
+		return compareOrdered(va.String(), vb.String())
+	case va.Kind() == reflect.Bool && vb.Kind() == reflect.Bool:
+		switch {
+		case va.Bool() == vb.Bool():
+			return 0
+		case vb.Bool():
+			return -1
+		}
+		return 1
+	case va.CanInt() && vb.CanInt():
+		return compareOrdered(va.Int(), vb.Int())
+	case va.CanUint() && vb.CanUint():
+		return compareOrdered(va.Uint(), vb.Uint())
+	case (va.CanInt() || va.CanUint() || va.CanFloat()) && (vb.CanInt() || vb.CanUint() || vb.CanFloat()):
+		return compareOrdered(toFloat(va), toFloat(vb))
+	}
+	return compareOrdered(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// compareRows compares rows of values column by column.
+func compareRows(a, b []any) int {
+	for i := range a {
+		if c := compareValues(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func compareOrdered[T int64 | uint64 | float64 | string](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func toFloat(v reflect.Value) float64 {
+	switch {
+	case v.CanInt():
+		return float64(v.Int())
+	case v.CanUint():
+		return float64(v.Uint())
+	}
+	return v.Float()
+}
+
+// likeMatch reports whether s matches the LIKE pattern, where % stands for
+// any text and _ for any character. It is case sensitive, as in PostgreSQL.
+func likeMatch(s, pattern string) bool {
+	var expr strings.Builder
+	expr.WriteString("^(?s:")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			expr.WriteString(".*")
+		case '_':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString(")$")
+	ok, _ := regexp.MatchString(expr.String(), s)
+	return ok
+}
+
+// match reports whether v, the value of a column, satisfies p as the
+// conditions of p do in SQL, where NULL satisfies no comparison.
+func (p Predicate[T]) match(v any) bool {
+	v = sqlValue(v)
+	if p.IsNull != nil && *p.IsNull != (v == nil) {
+		return false
+	}
+	if v == nil {
+		return p.Eq == nil && p.NotEq == nil && p.In == nil && len(p.NotIn) == 0
+	}
+	return (p.Eq == nil || compareValues(v, *p.Eq) == 0) &&
+		(p.NotEq == nil || compareValues(v, *p.NotEq) != 0) &&
+		(p.In == nil || containsValue(p.In, v)) &&
+		!containsValue(p.NotIn, v)
+}
+
+func (p OrderedPredicate[T]) match(v any) bool {
+	if !(Predicate[T]{Eq: p.Eq, NotEq: p.NotEq, In: p.In, NotIn: p.NotIn, IsNull: p.IsNull}).match(v) {
+		return false
+	}
+	if sqlValue(v) == nil {
+		return p.Gt == nil && p.Gte == nil && p.Lt == nil && p.Lte == nil
+	}
+	return (p.Gt == nil || compareValues(v, *p.Gt) > 0) &&
+		(p.Gte == nil || compareValues(v, *p.Gte) >= 0) &&
+		(p.Lt == nil || compareValues(v, *p.Lt) < 0) &&
+		(p.Lte == nil || compareValues(v, *p.Lte) <= 0)
+}
+
+func (p StringPredicate) match(v any) bool {
+	if !(OrderedPredicate[string]{Eq: p.Eq, NotEq: p.NotEq, In: p.In, NotIn: p.NotIn, IsNull: p.IsNull, Gt: p.Gt, Gte: p.Gte, Lt: p.Lt, Lte: p.Lte}).match(v) {
+		return false
+	}
+	if p.Like == nil {
+		return true
+	}
+	s, ok := sqlValue(v).(string)
+	return ok && likeMatch(s, *p.Like)
+}
+
+// containsValue reports whether values holds v.
+func containsValue[T any](values []T, v any) bool {
+	for _, value := range values {
+		if compareValues(v, value) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Columns of table "owners" that generated code reads, creates and updates.
+var (
+	ownerReadColumns   = []string{"id", "email", "name", "nickname", "created_at", "deleted_at"}
+	ownerCreateColumns = []string{"id", "email", "name", "nickname", "created_at", "deleted_at"}
+	ownerUpdateColumns = []string{"email", "name", "nickname"}
+	ownerKeyColumns    = []string{"id"}
+)
+
+type ownerFields struct {
+	ID        Field
+	Email     Field
+	Name      Field
+	Nickname  Field
+	CreatedAt Field
+	DeletedAt Field
+}
+
+// Owner_ describes the columns of table "owners".
+var Owner_ = ownerFields{
+	ID:        Field{Name: "ID", Column: "id", Table: "owners", Type: "uint"},
+	Email:     Field{Name: "Email", Column: "email", Table: "owners", Type: "string"},
+	Name:      Field{Name: "Name", Column: "name", Table: "owners", Type: "string"},
+	Nickname:  Field{Name: "Nickname", Column: "nickname", Table: "owners", Type: "*string"},
+	CreatedAt: Field{Name: "CreatedAt", Column: "created_at", Table: "owners", Type: "time.Time"},
+	DeletedAt: Field{Name: "DeletedAt", Column: "deleted_at", Table: "owners", Type: "gorm.DeletedAt"},
+}
+
+// OwnerFilter selects Owner rows: the predicates that are set must all hold.
+type OwnerFilter struct {
+	ID        OrderedPredicate[uint]      // id
+	Email     StringPredicate             // email
+	Name      StringPredicate             // name
+	Nickname  StringPredicate             // nickname
+	CreatedAt OrderedPredicate[time.Time] // created_at
+	DeletedAt Predicate[gorm.DeletedAt]   // deleted_at
+}
+
+// apply adds the conditions of f to db. A nil f adds none.
+func (f *OwnerFilter) apply(db *gorm.DB) *gorm.DB {
+	if f == nil {
+		return db
+	}
+	var conds []clause.Expression
+	conds = append(conds, f.ID.conditions("id")...)
+	conds = append(conds, f.Email.conditions("email")...)
+	conds = append(conds, f.Name.conditions("name")...)
+	conds = append(conds, f.Nickname.conditions("nickname")...)
+	conds = append(conds, f.CreatedAt.conditions("created_at")...)
+	conds = append(conds, f.DeletedAt.conditions("deleted_at")...)
+	if len(conds) == 0 {
+		return db
+	}
+	return db.Where(clause.And(conds...))
+}
+
+// OwnerRepo reads and writes the rows of table "owners".
+type OwnerRepo struct {
+	db *gorm.DB
+	tx bool // db is a transaction from WithTx
+}
+
+// NewOwnerRepo returns a OwnerRepo using db. Unless db is opened with
+// gorm.Config{TranslateError: true}, writes conflicting with the primary
+// key or a unique index return the error of the driver rather than one
+// matching gorm.ErrDuplicatedKey, as OwnerRepositoryFake returns.
+func NewOwnerRepo(db *gorm.DB) *OwnerRepo {
+	return &OwnerRepo{db: db}
+}
+
+// WithTx returns a OwnerRepo reading and writing through tx, a transaction
+// begun by the caller, e.g. in a gorm Transaction callback.
+func (r *OwnerRepo) WithTx(tx *gorm.DB) OwnerRepository {
+	return &OwnerRepo{db: tx, tx: true}
+}
+
+// Create inserts m.
+func (r *OwnerRepo) Create(ctx context.Context, m *Owner) error {
+	return r.db.WithContext(ctx).Select(ownerCreateColumns).Create(m).Error
+}
+
+// CreateInBatches inserts ms, batchSize rows per statement.
+func (r *OwnerRepo) CreateInBatches(ctx context.Context, ms []*Owner, batchSize int) error {
+	return r.db.WithContext(ctx).Select(ownerCreateColumns).CreateInBatches(ms, batchSize).Error
+}
+
+// OwnerKey is the primary key of a Owner.
+type OwnerKey struct {
+	ID uint
+}
+
+// condition matches the row with key k.
+func (k OwnerKey) condition() clause.Expression {
+	return clause.And(
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: "id"}, Value: k.ID},
+	)
+}
+
+// values returns the key columns of k in order.
+func (k OwnerKey) values() []any {
+	return []any{k.ID}
+}
+
+// OwnerPatch lists the columns of a Owner to update. Nil fields are left
+// untouched, the others are written even when they hold a zero value.
+type OwnerPatch struct {
+	Email    *string  // email
+	Name     *string  // name
+	Nickname **string // nickname
+}
+
+// apply copies the fields set in p to m and returns their columns.
+func (p *OwnerPatch) apply(m *Owner) []string {
+	var columns []string
+	if p.Email != nil {
+		m.Email = *p.Email
+		columns = append(columns, "email")
+	}
+	if p.Name != nil {
+		m.Name = *p.Name
+		columns = append(columns, "name")
+	}
+	if p.Nickname != nil {
+		m.Nickname = *p.Nickname
+		columns = append(columns, "nickname")
+	}
+	return columns
+}
+
+// Get returns the Owner with the given primary key, or a
+// *NotFoundError. Of opts, only WithDeleted and preloads apply.
+func (r *OwnerRepo) Get(ctx context.Context, id uint, opts ...QueryOption) (*Owner, error) {
+	return r.GetByKey(ctx, OwnerKey{ID: id}, opts...)
+}
+
+// Update writes the updatable columns of m, zero values included, to
+// the row with the primary key of m, or returns a *NotFoundError.
+func (r *OwnerRepo) Update(ctx context.Context, m *Owner) error {
+	k := r.KeyOf(m)
+	res := r.db.WithContext(ctx).Model(m).Where(k.condition()).Select(ownerUpdateColumns).Updates(m)
+	return updated(res, func() (bool, error) { return r.ExistsByKey(ctx, k) }, "Owner", k.values())
+}
+
+// UpdateFields writes the fields set in p to the row with primary key k,
+// or returns a *NotFoundError. Columns gorm updates automatically, such
+// as UpdatedAt, are bumped. An empty p writes nothing.
+func (r *OwnerRepo) UpdateFields(ctx context.Context, k OwnerKey, p *OwnerPatch) error {
+	var m Owner
+	columns := p.apply(&m)
+	if len(columns) == 0 {
+		return nil
+	}
+	res := r.db.WithContext(ctx).Model(&m).Where(k.condition()).Select(columns).Updates(&m)
+	return updated(res, func() (bool, error) { return r.ExistsByKey(ctx, k) }, "Owner", k.values())
+}
+
+// Delete soft deletes the Owner with the given primary key, or returns
+// a *NotFoundError.
+func (r *OwnerRepo) Delete(ctx context.Context, id uint) error {
+	return r.DeleteByKey(ctx, OwnerKey{ID: id})
+}
+
+// KeyOf returns the primary key of m. Key fields of nil embedded structs
+// are zero, and the writes refuse such rows with ErrNilKey.
+func (r *OwnerRepo) KeyOf(m *Owner) OwnerKey {
+	k := OwnerKey{ID: m.ID}
+	return k
+}
+
+// GetByKey returns the Owner with primary key k, or a *NotFoundError.
+// Of opts, only WithDeleted and preloads apply.
+func (r *OwnerRepo) GetByKey(ctx context.Context, k OwnerKey, opts ...QueryOption) (*Owner, error) {
+	var m Owner
+	db := newQueryOptions(opts).read(r.db.WithContext(ctx))
+	if err := db.Where(k.condition()).Take(&m).Error; err != nil {
+		return nil, notFound(err, "Owner", "primary key", k.values()...)
+	}
+	return &m, nil
+}
+
+// GetByKeys returns the Owners with the given primary keys, in no
+// particular order. Keys without row are left out. Large sets of keys
+// are looked up in several queries. Of opts, only WithDeleted and
+// preloads apply.
+func (r *OwnerRepo) GetByKeys(ctx context.Context, keys []OwnerKey, opts ...QueryOption) ([]*Owner, error) {
+	o := newQueryOptions(opts)
+	var ms []*Owner
+	size := maxKeyParams / len(ownerKeyColumns)
+	for start := 0; start < len(keys); start += size {
+		end := start + size
+		if end > len(keys) {
+			end = len(keys)
+		}
+		values := make([][]any, 0, end-start)
+		for _, k := range keys[start:end] {
+			values = append(values, k.values())
+		}
+		var batch []*Owner
+		if err := o.read(r.db.WithContext(ctx)).Where(keysIn(r.db, ownerKeyColumns, values)).Find(&batch).Error; err != nil {
+			return nil, err
+		}
+		ms = append(ms, batch...)
+	}
+	return ms, nil
+}
+
+// DeleteByKey soft deletes the Owner with primary key k, or returns a
+// *NotFoundError. RestoreByKey brings it back, PurgeByKey removes it
+// for good.
+func (r *OwnerRepo) DeleteByKey(ctx context.Context, k OwnerKey) error {
+	res := r.db.WithContext(ctx).Where(k.condition()).Delete(&Owner{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return &NotFoundError{Model: "Owner", Index: "primary key", Key: k.values()}
+	}
+	return nil
+}
+
+// ExistsByKey reports whether a Owner has primary key k.
+func (r *OwnerRepo) ExistsByKey(ctx context.Context, k OwnerKey) (bool, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&Owner{}).Where(k.condition()).Limit(1).Count(&n).Error
+	return n > 0, err
+}
+
+// Restore brings back the soft deleted Owner with the given primary key,
+// or returns a *NotFoundError.
+func (r *OwnerRepo) Restore(ctx context.Context, id uint) error {
+	return r.RestoreByKey(ctx, OwnerKey{ID: id})
+}
+
+// RestoreByKey brings back the soft deleted Owner with primary key k, or
+// returns a *NotFoundError.
+func (r *OwnerRepo) RestoreByKey(ctx context.Context, k OwnerKey) error {
+	res := r.db.WithContext(ctx).Unscoped().Model(&Owner{}).Where(k.condition(), clause.Neq{Column: clause.Column{Table: clause.CurrentTable, Name: "deleted_at"}, Value: nil}).Update("deleted_at", nil)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return &NotFoundError{Model: "Owner", Index: "primary key", Key: k.values()}
+	}
+	return nil
+}
+
+// Purge removes for good the Owner with the given primary key, deleted or
+// not, or returns a *NotFoundError.
+func (r *OwnerRepo) Purge(ctx context.Context, id uint) error {
+	return r.PurgeByKey(ctx, OwnerKey{ID: id})
+}
+
+// PurgeByKey removes for good the Owner with primary key k, deleted or
+// not, or returns a *NotFoundError.
+func (r *OwnerRepo) PurgeByKey(ctx context.Context, k OwnerKey) error {
+	res := r.db.WithContext(ctx).Unscoped().Where(k.condition()).Delete(&Owner{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return &NotFoundError{Model: "Owner", Index: "primary key", Key: k.values()}
+	}
+	return nil
+}
+
+// GetByEmail returns the Owner with the given email, unique by index idx_owners_email,
+// or a *NotFoundError. Of opts, only WithDeleted and preloads apply.
+func (r *OwnerRepo) GetByEmail(ctx context.Context, email string, opts ...QueryOption) (*Owner, error) {
+	var m Owner
+	err := newQueryOptions(opts).read(r.db.WithContext(ctx)).Where(
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: "email"}, Value: email},
+	).Take(&m).Error
+	if err != nil {
+		return nil, notFound(err, "Owner", "idx_owners_email", email)
+	}
+	return &m, nil
+}
+
+// Upsert inserts m or, when a row has the same primary key,
+// updates that row as opts tell. MySQL resolves conflicts on any unique
+// key of the table.
+func (r *OwnerRepo) Upsert(ctx context.Context, m *Owner, opts ...UpsertOption) error {
+	c, err := onConflict(ownerKeyColumns, "", ownerUpdateColumns, opts)
+	if err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Clauses(c).Select(ownerCreateColumns).Create(m).Error
+}
+
+// UpsertBatch upserts ms like Upsert, batchSize rows per
+// statement.
+func (r *OwnerRepo) UpsertBatch(ctx context.Context, ms []*Owner, batchSize int, opts ...UpsertOption) error {
+	c, err := onConflict(ownerKeyColumns, "", ownerUpdateColumns, opts)
+	if err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Clauses(c).Select(ownerCreateColumns).CreateInBatches(ms, batchSize).Error
+}
+
+// UpsertByEmail inserts m or, when a row has the same unique index idx_owners_email,
+// updates that row as opts tell. MySQL resolves conflicts on any unique
+// key of the table.
+func (r *OwnerRepo) UpsertByEmail(ctx context.Context, m *Owner, opts ...UpsertOption) error {
+	c, err := onConflict([]string{"email"}, "", ownerUpdateColumns, opts)
+	if err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Clauses(c).Select(ownerCreateColumns).Create(m).Error
+}
+
+// UpsertBatchByEmail upserts ms like UpsertByEmail, batchSize rows per
+// statement.
+func (r *OwnerRepo) UpsertBatchByEmail(ctx context.Context, ms []*Owner, batchSize int, opts ...UpsertOption) error {
+	c, err := onConflict([]string{"email"}, "", ownerUpdateColumns, opts)
+	if err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Clauses(c).Select(ownerCreateColumns).CreateInBatches(ms, batchSize).Error
+}
+
+// List returns the rows matching filter, all of them when filter is nil,
+// sorted and paged by opts. Soft deleted rows are left out unless opts
+// include WithDeleted.
+func (r *OwnerRepo) List(ctx context.Context, filter *OwnerFilter, opts ...QueryOption) ([]*Owner, error) {
+	var ms []*Owner
+	db := filter.apply(r.db.WithContext(ctx))
+	if err := newQueryOptions(opts).page(db, ownerKeyColumns).Find(&ms).Error; err != nil {
+		return nil, err
+	}
+	return ms, nil
+}
+
+// OwnerSort is the column ListPage sorts Owner rows on before their primary
+// key. Only indexed columns can be sorted on.
+type OwnerSort string
+
+// Columns ListPage can sort Owner rows on.
+const (
+	OwnerSortByKey      OwnerSort = "" // the primary key alone
+	OwnerSortByEmail    OwnerSort = "email"
+	OwnerSortByNickname OwnerSort = "nickname"
+)
+
+// columns returns the columns rows are sorted on.
+func (s OwnerSort) columns() ([]string, error) {
+	switch s {
+	case OwnerSortByKey:
+		return ownerKeyColumns, nil
+	case OwnerSortByEmail, OwnerSortByNickname:
+		return append([]string{string(s)}, ownerKeyColumns...), nil
+	}
+	return nil, fmt.Errorf("unknown OwnerSort %q", string(s))
+}
+
+// value returns the value of the sort column of m.
+func (s OwnerSort) value(m *Owner) any {
+	switch s {
+	case OwnerSortByEmail:
+		return m.Email
+	case OwnerSortByNickname:
+		return m.Nickname
+	}
+	return nil
+}
+
+// decode decodes a value of the sort column encoded by value.
+func (s OwnerSort) decode(raw json.RawMessage) (any, error) {
+	switch s {
+	case OwnerSortByEmail:
+		var v string
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	case OwnerSortByNickname:
+		var v *string
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	}
+	return nil, nil
+}
+
+// ListPage returns up to limit rows matching filter, sorted by sort then
+// primary key, that come after the page the cursor was returned with,
+// from the first row when it is empty. It also returns the cursor of the
+// next page, empty after the last one. Unlike offsets, cursors do not skip
+// nor repeat rows when rows are inserted between pages. Rows whose sort
+// column is NULL are left out: databases disagree on where NULLs sort,
+// and a cursor cannot tell them from zero values.
+func (r *OwnerRepo) ListPage(ctx context.Context, filter *OwnerFilter, sort OwnerSort, cursor string, limit int) ([]*Owner, string, error) {
+	if limit <= 0 {
+		return nil, "", fmt.Errorf("page limit %d is not positive", limit)
+	}
+	columns, err := sort.columns()
+	if err != nil {
+		return nil, "", err
+	}
+	db := filter.apply(r.db.WithContext(ctx))
+	if sort != OwnerSortByKey {
+		db = db.Where(clause.Neq{Column: clause.Column{Table: clause.CurrentTable, Name: string(sort)}, Value: nil})
+	}
+	if cursor != "" {
+		var k OwnerKey
+		raw, err := decodeCursor(cursor, string(sort), &k)
+		if err != nil {
+			return nil, "", err
+		}
+		values := k.values()
+		if sort != OwnerSortByKey {
+			v, err := sort.decode(raw)
+			if err != nil {
+				return nil, "", ErrInvalidCursor
+			}
+			values = append([]any{v}, values...)
+		}
+		db = db.Where(keysetAfter(columns, values))
+	}
+	var ms []*Owner
+	if err := db.Clauses(keysetOrder(columns)).Limit(limit + 1).Find(&ms).Error; err != nil {
+		return nil, "", err
+	}
+	if len(ms) <= limit {
+		return ms, "", nil
+	}
+	ms = ms[:limit]
+	last := ms[limit-1]
+	next, err := encodeCursor(string(sort), sort.value(last), r.KeyOf(last))
+	if err != nil {
+		return nil, "", err
+	}
+	return ms, next, nil
+}
+
+// ForEachBatch calls fn with the rows matching filter, in primary key
+// order and batchSize rows at a time. It stops at the first error of fn,
+// returning it unless it is ErrStop, or when ctx is done.
+func (r *OwnerRepo) ForEachBatch(ctx context.Context, filter *OwnerFilter, batchSize int, fn func([]*Owner) error) error {
+	if batchSize <= 0 {
+		return fmt.Errorf("batch size %d is not positive", batchSize)
+	}
+	var after []any
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		db := filter.apply(r.db.WithContext(ctx))
+		if after != nil {
+			db = db.Where(keysetAfter(ownerKeyColumns, after))
+		}
+		var ms []*Owner
+		if err := db.Clauses(keysetOrder(ownerKeyColumns)).Limit(batchSize).Find(&ms).Error; err != nil {
+			return err
+		}
+		if len(ms) == 0 {
+			return nil
+		}
+		// Read the key before fn gets a chance to change it.
+		after = r.KeyOf(ms[len(ms)-1]).values()
+		if err := fn(ms); err != nil {
+			if errors.Is(err, ErrStop) {
+				return nil
+			}
+			return err
+		}
+		if len(ms) < batchSize {
+			return nil
+		}
+	}
+}
+
+// Each calls fn with every row matching filter, in primary key order,
+// holding only a batch of rows in memory. It stops like ForEachBatch.
+func (r *OwnerRepo) Each(ctx context.Context, filter *OwnerFilter, fn func(*Owner) error) error {
+	return r.ForEachBatch(ctx, filter, eachBatchSize, func(ms []*Owner) error {
+		for _, m := range ms {
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Stream sends the rows matching filter on the first channel, in primary
+// key order, and closes it after the last row, at the first error or
+// when ctx is done. The second channel then receives the error, nil when
+// every row was sent. Cancel ctx to stop reading early.
+func (r *OwnerRepo) Stream(ctx context.Context, filter *OwnerFilter) (<-chan *Owner, <-chan error) {
+	ch := make(chan *Owner)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		err := r.Each(ctx, filter, func(m *Owner) error {
+			select {
+			case ch <- m:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		close(ch)
+		errc <- err
+	}()
+	return ch, errc
+}
+
+// GetForUpdate returns the Owner with the given primary key, locked until
+// the end of the transaction of r, or a *NotFoundError.
+func (r *OwnerRepo) GetForUpdate(ctx context.Context, id uint, opts ...LockOption) (*Owner, error) {
+	db, err := lockRows(r.db.WithContext(ctx), r.tx, opts)
+	if err != nil {
+		return nil, err
+	}
+	k := OwnerKey{ID: id}
+	var m Owner
+	if err := db.Where(k.condition()).Take(&m).Error; err != nil {
+		return nil, notFound(err, "Owner", "primary key", k.values()...)
+	}
+	return &m, nil
+}
+
+// ListForUpdate returns the rows matching filter, locked until the end of
+// the transaction of r. Rows are locked in primary key order, the same in
+// every transaction, to avoid deadlocks.
+func (r *OwnerRepo) ListForUpdate(ctx context.Context, filter *OwnerFilter, opts ...LockOption) ([]*Owner, error) {
+	db, err := lockRows(r.db.WithContext(ctx), r.tx, opts)
+	if err != nil {
+		return nil, err
+	}
+	var ms []*Owner
+	if err := filter.apply(db).Clauses(keysetOrder(ownerKeyColumns)).Find(&ms).Error; err != nil {
+		return nil, err
+	}
+	return ms, nil
+}
+
+// Count returns the number of rows matching filter, of all rows when
+// filter is nil. Of opts, only WithDeleted applies.
+func (r *OwnerRepo) Count(ctx context.Context, filter *OwnerFilter, opts ...QueryOption) (int64, error) {
+	var n int64
+	err := filter.apply(newQueryOptions(opts).scope(r.db.WithContext(ctx).Model(&Owner{}))).Count(&n).Error
+	return n, err
+}
+
+// DeleteWhere soft deletes the rows matching filter and returns how many
+// there were. Like gorm, it refuses to delete every row: filter must set
+// a predicate.
+func (r *OwnerRepo) DeleteWhere(ctx context.Context, filter *OwnerFilter) (int64, error) {
+	res := filter.apply(r.db.WithContext(ctx)).Delete(&Owner{})
+	return res.RowsAffected, res.Error
+}
+
+// ListDeleted returns the soft deleted rows matching filter, sorted and
+// paged by opts.
+func (r *OwnerRepo) ListDeleted(ctx context.Context, filter *OwnerFilter, opts ...QueryOption) ([]*Owner, error) {
+	var ms []*Owner
+	db := filter.apply(r.db.WithContext(ctx).Unscoped().Where(clause.Neq{Column: clause.Column{Table: clause.CurrentTable, Name: "deleted_at"}, Value: nil}))
+	if err := newQueryOptions(opts).page(db, ownerKeyColumns).Find(&ms).Error; err != nil {
+		return nil, err
+	}
+	return ms, nil
+}
+
+// PurgeWhere removes for good the rows matching filter, deleted or not,
+// and returns how many there were. Like DeleteWhere, it refuses to
+// remove every row.
+func (r *OwnerRepo) PurgeWhere(ctx context.Context, filter *OwnerFilter) (int64, error) {
+	res := filter.apply(r.db.WithContext(ctx).Unscoped()).Delete(&Owner{})
+	return res.RowsAffected, res.Error
+}
+
+// OwnerRepository is the interface of OwnerRepo, for code to be tested with a
+// OwnerRepositoryMock instead of a database.
+type OwnerRepository interface {
+	WithTx(tx *gorm.DB) OwnerRepository
+	Create(ctx context.Context, m *Owner) error
+	CreateInBatches(ctx context.Context, ms []*Owner, batchSize int) error
+	Get(ctx context.Context, id uint, opts ...QueryOption) (*Owner, error)
+	Update(ctx context.Context, m *Owner) error
+	UpdateFields(ctx context.Context, k OwnerKey, p *OwnerPatch) error
+	Delete(ctx context.Context, id uint) error
+	KeyOf(m *Owner) OwnerKey
+	GetByKey(ctx context.Context, k OwnerKey, opts ...QueryOption) (*Owner, error)
+	GetByKeys(ctx context.Context, keys []OwnerKey, opts ...QueryOption) ([]*Owner, error)
+	DeleteByKey(ctx context.Context, k OwnerKey) error
+	ExistsByKey(ctx context.Context, k OwnerKey) (bool, error)
+	Restore(ctx context.Context, id uint) error
+	RestoreByKey(ctx context.Context, k OwnerKey) error
+	Purge(ctx context.Context, id uint) error
+	PurgeByKey(ctx context.Context, k OwnerKey) error
+	GetByEmail(ctx context.Context, email string, opts ...QueryOption) (*Owner, error)
+	Upsert(ctx context.Context, m *Owner, opts ...UpsertOption) error
+	UpsertBatch(ctx context.Context, ms []*Owner, batchSize int, opts ...UpsertOption) error
+	UpsertByEmail(ctx context.Context, m *Owner, opts ...UpsertOption) error
+	UpsertBatchByEmail(ctx context.Context, ms []*Owner, batchSize int, opts ...UpsertOption) error
+	List(ctx context.Context, filter *OwnerFilter, opts ...QueryOption) ([]*Owner, error)
+	ListPage(ctx context.Context, filter *OwnerFilter, sort OwnerSort, cursor string, limit int) ([]*Owner, string, error)
+	ForEachBatch(ctx context.Context, filter *OwnerFilter, batchSize int, fn func([]*Owner) error) error
+	Each(ctx context.Context, filter *OwnerFilter, fn func(*Owner) error) error
+	Stream(ctx context.Context, filter *OwnerFilter) (<-chan *Owner, <-chan error)
+	GetForUpdate(ctx context.Context, id uint, opts ...LockOption) (*Owner, error)
+	ListForUpdate(ctx context.Context, filter *OwnerFilter, opts ...LockOption) ([]*Owner, error)
+	Count(ctx context.Context, filter *OwnerFilter, opts ...QueryOption) (int64, error)
+	DeleteWhere(ctx context.Context, filter *OwnerFilter) (int64, error)
+	ListDeleted(ctx context.Context, filter *OwnerFilter, opts ...QueryOption) ([]*Owner, error)
+	PurgeWhere(ctx context.Context, filter *OwnerFilter) (int64, error)
+}
+
+var (
+	_ OwnerRepository = (*OwnerRepo)(nil)
+	_ OwnerRepository = (*OwnerRepositoryMock)(nil)
+)
+
+// OwnerRepositoryMock implements OwnerRepository by calling the func field named after
+// each method, e.g. GetFunc for Get, and records the calls, which the
+// <Method>Calls methods return. Calling a method whose func is nil
+// panics. A OwnerRepositoryMock is safe for concurrent use once its funcs are set.
+type OwnerRepositoryMock struct {
+	WithTxFunc             func(tx *gorm.DB) OwnerRepository
+	CreateFunc             func(ctx context.Context, m *Owner) error
+	CreateInBatchesFunc    func(ctx context.Context, ms []*Owner, batchSize int) error
+	GetFunc                func(ctx context.Context, id uint, opts ...QueryOption) (*Owner, error)
+	UpdateFunc             func(ctx context.Context, m *Owner) error
+	UpdateFieldsFunc       func(ctx context.Context, k OwnerKey, p *OwnerPatch) error
+	DeleteFunc             func(ctx context.Context, id uint) error
+	KeyOfFunc              func(m *Owner) OwnerKey
+	GetByKeyFunc           func(ctx context.Context, k OwnerKey, opts ...QueryOption) (*Owner, error)
+	GetByKeysFunc          func(ctx context.Context, keys []OwnerKey, opts ...QueryOption) ([]*Owner, error)
+	DeleteByKeyFunc        func(ctx context.Context, k OwnerKey) error
+	ExistsByKeyFunc        func(ctx context.Context, k OwnerKey) (bool, error)
+	RestoreFunc            func(ctx context.Context, id uint) error
+	RestoreByKeyFunc       func(ctx context.Context, k OwnerKey) error
+	PurgeFunc              func(ctx context.Context, id uint) error
+	PurgeByKeyFunc         func(ctx context.Context, k OwnerKey) error
+	GetByEmailFunc         func(ctx context.Context, email string, opts ...QueryOption) (*Owner, error)
+	UpsertFunc             func(ctx context.Context, m *Owner, opts ...UpsertOption) error
+	UpsertBatchFunc        func(ctx context.Context, ms []*Owner, batchSize int, opts ...UpsertOption) error
+	UpsertByEmailFunc      func(ctx context.Context, m *Owner, opts ...UpsertOption) error
+	UpsertBatchByEmailFunc func(ctx context.Context, ms []*Owner, batchSize int, opts ...UpsertOption) error
+	ListFunc               func(ctx context.Context, filter *OwnerFilter, opts ...QueryOption) ([]*Owner, error)
+	ListPageFunc           func(ctx context.Context, filter *OwnerFilter, sort OwnerSort, cursor string, limit int) ([]*Owner, string, error)
+	ForEachBatchFunc       func(ctx context.Context, filter *OwnerFilter, batchSize int, fn func([]*Owner) error) error
+	EachFunc               func(ctx context.Context, filter *OwnerFilter, fn func(*Owner) error) error
+	StreamFunc             func(ctx context.Context, filter *OwnerFilter) (<-chan *Owner, <-chan error)
+	GetForUpdateFunc       func(ctx context.Context, id uint, opts ...LockOption) (*Owner, error)
+	ListForUpdateFunc      func(ctx context.Context, filter *OwnerFilter, opts ...LockOption) ([]*Owner, error)
+	CountFunc              func(ctx context.Context, filter *OwnerFilter, opts ...QueryOption) (int64, error)
+	DeleteWhereFunc        func(ctx context.Context, filter *OwnerFilter) (int64, error)
+	ListDeletedFunc        func(ctx context.Context, filter *OwnerFilter, opts ...QueryOption) ([]*Owner, error)
+	PurgeWhereFunc         func(ctx context.Context, filter *OwnerFilter) (int64, error)
+
+	mu    sync.Mutex
+	calls struct {
+		WithTx []struct {
+			Tx *gorm.DB
+		}
+		Create []struct {
+			Ctx context.Context
+			M   *Owner
+		}
+		CreateInBatches []struct {
+			Ctx       context.Context
+			Ms        []*Owner
+			BatchSize int
+		}
+		Get []struct {
+			Ctx  context.Context
+			Id   uint
+			Opts []QueryOption
+		}
+		Update []struct {
+			Ctx context.Context
+			M   *Owner
+		}
+		UpdateFields []struct {
+			Ctx context.Context
+			K   OwnerKey
+			P   *OwnerPatch
+		}
+		Delete []struct {
+			Ctx context.Context
+			Id  uint
+		}
+		KeyOf []struct {
+			M *Owner
+		}
+		GetByKey []struct {
+			Ctx  context.Context
+			K    OwnerKey
+			Opts []QueryOption
+		}
+		GetByKeys []struct {
+			Ctx  context.Context
+			Keys []OwnerKey
+			Opts []QueryOption
+		}
+		DeleteByKey []struct {
+			Ctx context.Context
+			K   OwnerKey
+		}
+		ExistsByKey []struct {
+			Ctx context.Context
+			K   OwnerKey
+		}
+		Restore []struct {
+			Ctx context.Context
+			Id  uint
+		}
+		RestoreByKey []struct {
+			Ctx context.Context
+			K   OwnerKey
+		}
+		Purge []struct {
+			Ctx context.Context
+			Id  uint
+		}
+		PurgeByKey []struct {
+			Ctx context.Context
+			K   OwnerKey
+		}
+		GetByEmail []struct {
+			Ctx   context.Context
+			Email string
+			Opts  []QueryOption
+		}
+		Upsert []struct {
+			Ctx  context.Context
+			M    *Owner
+			Opts []UpsertOption
+		}
+		UpsertBatch []struct {
+			Ctx       context.Context
+			Ms        []*Owner
+			BatchSize int
+			Opts      []UpsertOption
+		}
+		UpsertByEmail []struct {
+			Ctx  context.Context
+			M    *Owner
+			Opts []UpsertOption
+		}
+		UpsertBatchByEmail []struct {
+			Ctx       context.Context
+			Ms        []*Owner
+			BatchSize int
+			Opts      []UpsertOption
+		}
+		List []struct {
+			Ctx    context.Context
+			Filter *OwnerFilter
+			Opts   []QueryOption
+		}
+		ListPage []struct {
+			Ctx    context.Context
+			Filter *OwnerFilter
+			Sort   OwnerSort
+			Cursor string
+			Limit  int
+		}
+		ForEachBatch []struct {
+			Ctx       context.Context
+			Filter    *OwnerFilter
+			BatchSize int
+			Fn        func([]*Owner) error
+		}
+		Each []struct {
+			Ctx    context.Context
+			Filter *OwnerFilter
+			Fn     func(*Owner) error
+		}
+		Stream []struct {
+			Ctx    context.Context
+			Filter *OwnerFilter
+		}
+		GetForUpdate []struct {
+			Ctx  context.Context
+			Id   uint
+			Opts []LockOption
+		}
+		ListForUpdate []struct {
+			Ctx    context.Context
+			Filter *OwnerFilter
+			Opts   []LockOption
+		}
+		Count []struct {
+			Ctx    context.Context
+			Filter *OwnerFilter
+			Opts   []QueryOption
+		}
+		DeleteWhere []struct {
+			Ctx    context.Context
+			Filter *OwnerFilter
+		}
+		ListDeleted []struct {
+			Ctx    context.Context
+			Filter *OwnerFilter
+			Opts   []QueryOption
+		}
+		PurgeWhere []struct {
+			Ctx    context.Context
+			Filter *OwnerFilter
+		}
+	}
+}
+
+// WithTx calls WithTxFunc.
+func (mock *OwnerRepositoryMock) WithTx(tx *gorm.DB) OwnerRepository {
+	if mock.WithTxFunc == nil {
+		panic("OwnerRepositoryMock.WithTxFunc: method is nil but OwnerRepository.WithTx was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.WithTx = append(mock.calls.WithTx, struct {
+		Tx *gorm.DB
+	}{
+		Tx: tx,
+	})
+	mock.mu.Unlock()
+	return mock.WithTxFunc(tx)
+}
+
+// WithTxCalls returns the calls made to WithTx so far.
+func (mock *OwnerRepositoryMock) WithTxCalls() []struct {
+	Tx *gorm.DB
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Tx *gorm.DB
+	}(nil), mock.calls.WithTx...)
+}
+
+// Create calls CreateFunc.
+func (mock *OwnerRepositoryMock) Create(ctx context.Context, m *Owner) error {
+	if mock.CreateFunc == nil {
+		panic("OwnerRepositoryMock.CreateFunc: method is nil but OwnerRepository.Create was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Create = append(mock.calls.Create, struct {
+		Ctx context.Context
+		M   *Owner
+	}{
+		Ctx: ctx,
+		M:   m,
+	})
+	mock.mu.Unlock()
+	return mock.CreateFunc(ctx, m)
+}
+
+// CreateCalls returns the calls made to Create so far.
+func (mock *OwnerRepositoryMock) CreateCalls() []struct {
+	Ctx context.Context
+	M   *Owner
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx context.Context
+		M   *Owner
+	}(nil), mock.calls.Create...)
+}
+
+// CreateInBatches calls CreateInBatchesFunc.
+func (mock *OwnerRepositoryMock) CreateInBatches(ctx context.Context, ms []*Owner, batchSize int) error {
+	if mock.CreateInBatchesFunc == nil {
+		panic("OwnerRepositoryMock.CreateInBatchesFunc: method is nil but OwnerRepository.CreateInBatches was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.CreateInBatches = append(mock.calls.CreateInBatches, struct {
+		Ctx       context.Context
+		Ms        []*Owner
+		BatchSize int
+	}{
+		Ctx:       ctx,
+		Ms:        ms,
+		BatchSize: batchSize,
+	})
+	mock.mu.Unlock()
+	return mock.CreateInBatchesFunc(ctx, ms, batchSize)
+}
+
+// CreateInBatchesCalls returns the calls made to CreateInBatches so far.
+func (mock *OwnerRepositoryMock) CreateInBatchesCalls() []struct {
+	Ctx       context.Context
+	Ms        []*Owner
+	BatchSize int
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx       context.Context
+		Ms        []*Owner
+		BatchSize int
+	}(nil), mock.calls.CreateInBatches...)
+}
+
+// Get calls GetFunc.
+func (mock *OwnerRepositoryMock) Get(ctx context.Context, id uint, opts ...QueryOption) (*Owner, error) {
+	if mock.GetFunc == nil {
+		panic("OwnerRepositoryMock.GetFunc: method is nil but OwnerRepository.Get was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Get = append(mock.calls.Get, struct {
+		Ctx  context.Context
+		Id   uint
+		Opts []QueryOption
+	}{
+		Ctx:  ctx,
+		Id:   id,
+		Opts: opts,
+	})
+	mock.mu.Unlock()
+	return mock.GetFunc(ctx, id, opts...)
+}
+
+// GetCalls returns the calls made to Get so far.
+func (mock *OwnerRepositoryMock) GetCalls() []struct {
+	Ctx  context.Context
+	Id   uint
+	Opts []QueryOption
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx  context.Context
+		Id   uint
+		Opts []QueryOption
+	}(nil), mock.calls.Get...)
+}
+
+// Update calls UpdateFunc.
+func (mock *OwnerRepositoryMock) Update(ctx context.Context, m *Owner) error {
+	if mock.UpdateFunc == nil {
+		panic("OwnerRepositoryMock.UpdateFunc: method is nil but OwnerRepository.Update was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Update = append(mock.calls.Update, struct {
+		Ctx context.Context
+		M   *Owner
+	}{
+		Ctx: ctx,
+		M:   m,
+	})
+	mock.mu.Unlock()
+	return mock.UpdateFunc(ctx, m)
+}
+
+// UpdateCalls returns the calls made to Update so far.
+func (mock *OwnerRepositoryMock) UpdateCalls() []struct {
+	Ctx context.Context
+	M   *Owner
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx context.Context
+		M   *Owner
+	}(nil), mock.calls.Update...)
+}
+
+// UpdateFields calls UpdateFieldsFunc.
+func (mock *OwnerRepositoryMock) UpdateFields(ctx context.Context, k OwnerKey, p *OwnerPatch) error {
+	if mock.UpdateFieldsFunc == nil {
+		panic("OwnerRepositoryMock.UpdateFieldsFunc: method is nil but OwnerRepository.UpdateFields was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.UpdateFields = append(mock.calls.UpdateFields, struct {
+		Ctx context.Context
+		K   OwnerKey
+		P   *OwnerPatch
+	}{
+		Ctx: ctx,
+		K:   k,
+		P:   p,
+	})
+	mock.mu.Unlock()
+	return mock.UpdateFieldsFunc(ctx, k, p)
+}
+
+// UpdateFieldsCalls returns the calls made to UpdateFields so far.
+func (mock *OwnerRepositoryMock) UpdateFieldsCalls() []struct {
+	Ctx context.Context
+	K   OwnerKey
+	P   *OwnerPatch
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx context.Context
+		K   OwnerKey
+		P   *OwnerPatch
+	}(nil), mock.calls.UpdateFields...)
+}
+
+// Delete calls DeleteFunc.
+func (mock *OwnerRepositoryMock) Delete(ctx context.Context, id uint) error {
+	if mock.DeleteFunc == nil {
+		panic("OwnerRepositoryMock.DeleteFunc: method is nil but OwnerRepository.Delete was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Delete = append(mock.calls.Delete, struct {
+		Ctx context.Context
+		Id  uint
+	}{
+		Ctx: ctx,
+		Id:  id,
+	})
+	mock.mu.Unlock()
+	return mock.DeleteFunc(ctx, id)
+}
+
+// DeleteCalls returns the calls made to Delete so far.
+func (mock *OwnerRepositoryMock) DeleteCalls() []struct {
+	Ctx context.Context
+	Id  uint
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx context.Context
+		Id  uint
+	}(nil), mock.calls.Delete...)
+}
+
+// KeyOf calls KeyOfFunc.
+func (mock *OwnerRepositoryMock) KeyOf(m *Owner) OwnerKey {
+	if mock.KeyOfFunc == nil {
+		panic("OwnerRepositoryMock.KeyOfFunc: method is nil but OwnerRepository.KeyOf was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.KeyOf = append(mock.calls.KeyOf, struct {
+		M *Owner
+	}{
+		M: m,
+	})
+	mock.mu.Unlock()
+	return mock.KeyOfFunc(m)
+}
+
+// KeyOfCalls returns the calls made to KeyOf so far.
+func (mock *OwnerRepositoryMock) KeyOfCalls() []struct {
+	M *Owner
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		M *Owner
+	}(nil), mock.calls.KeyOf...)
+}
+
+// GetByKey calls GetByKeyFunc.
+func (mock *OwnerRepositoryMock) GetByKey(ctx context.Context, k OwnerKey, opts ...QueryOption) (*Owner, error) {
+	if mock.GetByKeyFunc == nil {
+		panic("OwnerRepositoryMock.GetByKeyFunc: method is nil but OwnerRepository.GetByKey was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.GetByKey = append(mock.calls.GetByKey, struct {
+		Ctx  context.Context
+		K    OwnerKey
+		Opts []QueryOption
+	}{
+		Ctx:  ctx,
+		K:    k,
+		Opts: opts,
+	})
+	mock.mu.Unlock()
+	return mock.GetByKeyFunc(ctx, k, opts...)
+}
+
+// GetByKeyCalls returns the calls made to GetByKey so far.
+func (mock *OwnerRepositoryMock) GetByKeyCalls() []struct {
+	Ctx  context.Context
+	K    OwnerKey
+	Opts []QueryOption
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx  context.Context
+		K    OwnerKey
+		Opts []QueryOption
+	}(nil), mock.calls.GetByKey...)
+}
+
+// GetByKeys calls GetByKeysFunc.
+func (mock *OwnerRepositoryMock) GetByKeys(ctx context.Context, keys []OwnerKey, opts ...QueryOption) ([]*Owner, error) {
+	if mock.GetByKeysFunc == nil {
+		panic("OwnerRepositoryMock.GetByKeysFunc: method is nil but OwnerRepository.GetByKeys was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.GetByKeys = append(mock.calls.GetByKeys, struct {
+		Ctx  context.Context
+		Keys []OwnerKey
+		Opts []QueryOption
+	}{
+		Ctx:  ctx,
+		Keys: keys,
+		Opts: opts,
+	})
+	mock.mu.Unlock()
+	return mock.GetByKeysFunc(ctx, keys, opts...)
+}
+
+// GetByKeysCalls returns the calls made to GetByKeys so far.
+func (mock *OwnerRepositoryMock) GetByKeysCalls() []struct {
+	Ctx  context.Context
+	Keys []OwnerKey
+	Opts []QueryOption
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx  context.Context
+		Keys []OwnerKey
+		Opts []QueryOption
+	}(nil), mock.calls.GetByKeys...)
+}
+
+// DeleteByKey calls DeleteByKeyFunc.
+func (mock *OwnerRepositoryMock) DeleteByKey(ctx context.Context, k OwnerKey) error {
+	if mock.DeleteByKeyFunc == nil {
+		panic("OwnerRepositoryMock.DeleteByKeyFunc: method is nil but OwnerRepository.DeleteByKey was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.DeleteByKey = append(mock.calls.DeleteByKey, struct {
+		Ctx context.Context
+		K   OwnerKey
+	}{
+		Ctx: ctx,
+		K:   k,
+	})
+	mock.mu.Unlock()
+	return mock.DeleteByKeyFunc(ctx, k)
+}
+
+// DeleteByKeyCalls returns the calls made to DeleteByKey so far.
+func (mock *OwnerRepositoryMock) DeleteByKeyCalls() []struct {
+	Ctx context.Context
+	K   OwnerKey
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx context.Context
+		K   OwnerKey
+	}(nil), mock.calls.DeleteByKey...)
+}
+
+// ExistsByKey calls ExistsByKeyFunc.
+func (mock *OwnerRepositoryMock) ExistsByKey(ctx context.Context, k OwnerKey) (bool, error) {
+	if mock.ExistsByKeyFunc == nil {
+		panic("OwnerRepositoryMock.ExistsByKeyFunc: method is nil but OwnerRepository.ExistsByKey was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.ExistsByKey = append(mock.calls.ExistsByKey, struct {
+		Ctx context.Context
+		K   OwnerKey
+	}{
+		Ctx: ctx,
+		K:   k,
+	})
+	mock.mu.Unlock()
+	return mock.ExistsByKeyFunc(ctx, k)
+}
+
+// ExistsByKeyCalls returns the calls made to ExistsByKey so far.
+func (mock *OwnerRepositoryMock) ExistsByKeyCalls() []struct {
+	Ctx context.Context
+	K   OwnerKey
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx context.Context
+		K   OwnerKey
+	}(nil), mock.calls.ExistsByKey...)
+}
+
+// Restore calls RestoreFunc.
+func (mock *OwnerRepositoryMock) Restore(ctx context.Context, id uint) error {
+	if mock.RestoreFunc == nil {
+		panic("OwnerRepositoryMock.RestoreFunc: method is nil but OwnerRepository.Restore was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Restore = append(mock.calls.Restore, struct {
+		Ctx context.Context
+		Id  uint
+	}{
+		Ctx: ctx,
+		Id:  id,
+	})
+	mock.mu.Unlock()
+	return mock.RestoreFunc(ctx, id)
+}
+
+// RestoreCalls returns the calls made to Restore so far.
+func (mock *OwnerRepositoryMock) RestoreCalls() []struct {
+	Ctx context.Context
+	Id  uint
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx context.Context
+		Id  uint
+	}(nil), mock.calls.Restore...)
+}
+
+// RestoreByKey calls RestoreByKeyFunc.
+func (mock *OwnerRepositoryMock) RestoreByKey(ctx context.Context, k OwnerKey) error {
+	if mock.RestoreByKeyFunc == nil {
+		panic("OwnerRepositoryMock.RestoreByKeyFunc: method is nil but OwnerRepository.RestoreByKey was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.RestoreByKey = append(mock.calls.RestoreByKey, struct {
+		Ctx context.Context
+		K   OwnerKey
+	}{
+		Ctx: ctx,
+		K:   k,
+	})
+	mock.mu.Unlock()
+	return mock.RestoreByKeyFunc(ctx, k)
+}
+
+// RestoreByKeyCalls returns the calls made to RestoreByKey so far.
+func (mock *OwnerRepositoryMock) RestoreByKeyCalls() []struct {
+	Ctx context.Context
+	K   OwnerKey
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx context.Context
+		K   OwnerKey
+	}(nil), mock.calls.RestoreByKey...)
+}
+
+// Purge calls PurgeFunc.
+func (mock *OwnerRepositoryMock) Purge(ctx context.Context, id uint) error {
+	if mock.PurgeFunc == nil {
+		panic("OwnerRepositoryMock.PurgeFunc: method is nil but OwnerRepository.Purge was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Purge = append(mock.calls.Purge, struct {
+		Ctx context.Context
+		Id  uint
+	}{
+		Ctx: ctx,
+		Id:  id,
+	})
+	mock.mu.Unlock()
+	return mock.PurgeFunc(ctx, id)
+}
+
+// PurgeCalls returns the calls made to Purge so far.
+func (mock *OwnerRepositoryMock) PurgeCalls() []struct {
+	Ctx context.Context
+	Id  uint
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx context.Context
+		Id  uint
+	}(nil), mock.calls.Purge...)
+}
+
+// PurgeByKey calls PurgeByKeyFunc.
+func (mock *OwnerRepositoryMock) PurgeByKey(ctx context.Context, k OwnerKey) error {
+	if mock.PurgeByKeyFunc == nil {
+		panic("OwnerRepositoryMock.PurgeByKeyFunc: method is nil but OwnerRepository.PurgeByKey was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.PurgeByKey = append(mock.calls.PurgeByKey, struct {
+		Ctx context.Context
+		K   OwnerKey
+	}{
+		Ctx: ctx,
+		K:   k,
+	})
+	mock.mu.Unlock()
+	return mock.PurgeByKeyFunc(ctx, k)
+}
+
+// PurgeByKeyCalls returns the calls made to PurgeByKey so far.
+func (mock *OwnerRepositoryMock) PurgeByKeyCalls() []struct {
+	Ctx context.Context
+	K   OwnerKey
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx context.Context
+		K   OwnerKey
+	}(nil), mock.calls.PurgeByKey...)
+}
+
+// GetByEmail calls GetByEmailFunc.
+func (mock *OwnerRepositoryMock) GetByEmail(ctx context.Context, email string, opts ...QueryOption) (*Owner, error) {
+	if mock.GetByEmailFunc == nil {
+		panic("OwnerRepositoryMock.GetByEmailFunc: method is nil but OwnerRepository.GetByEmail was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.GetByEmail = append(mock.calls.GetByEmail, struct {
+		Ctx   context.Context
+		Email string
+		Opts  []QueryOption
+	}{
+		Ctx:   ctx,
+		Email: email,
+		Opts:  opts,
+	})
+	mock.mu.Unlock()
+	return mock.GetByEmailFunc(ctx, email, opts...)
+}
+
+// GetByEmailCalls returns the calls made to GetByEmail so far.
+func (mock *OwnerRepositoryMock) GetByEmailCalls() []struct {
+	Ctx   context.Context
+	Email string
+	Opts  []QueryOption
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx   context.Context
+		Email string
+		Opts  []QueryOption
+	}(nil), mock.calls.GetByEmail...)
+}
+
+// Upsert calls UpsertFunc.
+func (mock *OwnerRepositoryMock) Upsert(ctx context.Context, m *Owner, opts ...UpsertOption) error {
+	if mock.UpsertFunc == nil {
+		panic("OwnerRepositoryMock.UpsertFunc: method is nil but OwnerRepository.Upsert was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Upsert = append(mock.calls.Upsert, struct {
+		Ctx  context.Context
+		M    *Owner
+		Opts []UpsertOption
+	}{
+		Ctx:  ctx,
+		M:    m,
+		Opts: opts,
+	})
+	mock.mu.Unlock()
+	return mock.UpsertFunc(ctx, m, opts...)
+}
+
+// UpsertCalls returns the calls made to Upsert so far.
+func (mock *OwnerRepositoryMock) UpsertCalls() []struct {
+	Ctx  context.Context
+	M    *Owner
+	Opts []UpsertOption
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx  context.Context
+		M    *Owner
+		Opts []UpsertOption
+	}(nil), mock.calls.Upsert...)
+}
+
+// UpsertBatch calls UpsertBatchFunc.
+func (mock *OwnerRepositoryMock) UpsertBatch(ctx context.Context, ms []*Owner, batchSize int, opts ...UpsertOption) error {
+	if mock.UpsertBatchFunc == nil {
+		panic("OwnerRepositoryMock.UpsertBatchFunc: method is nil but OwnerRepository.UpsertBatch was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.UpsertBatch = append(mock.calls.UpsertBatch, struct {
+		Ctx       context.Context
+		Ms        []*Owner
+		BatchSize int
+		Opts      []UpsertOption
+	}{
+		Ctx:       ctx,
+		Ms:        ms,
+		BatchSize: batchSize,
+		Opts:      opts,
+	})
+	mock.mu.Unlock()
+	return mock.UpsertBatchFunc(ctx, ms, batchSize, opts...)
+}
+
+// UpsertBatchCalls returns the calls made to UpsertBatch so far.
+func (mock *OwnerRepositoryMock) UpsertBatchCalls() []struct {
+	Ctx       context.Context
+	Ms        []*Owner
+	BatchSize int
+	Opts      []UpsertOption
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx       context.Context
+		Ms        []*Owner
+		BatchSize int
+		Opts      []UpsertOption
+	}(nil), mock.calls.UpsertBatch...)
+}
+
+// UpsertByEmail calls UpsertByEmailFunc.
+func (mock *OwnerRepositoryMock) UpsertByEmail(ctx context.Context, m *Owner, opts ...UpsertOption) error {
+	if mock.UpsertByEmailFunc == nil {
+		panic("OwnerRepositoryMock.UpsertByEmailFunc: method is nil but OwnerRepository.UpsertByEmail was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.UpsertByEmail = append(mock.calls.UpsertByEmail, struct {
+		Ctx  context.Context
+		M    *Owner
+		Opts []UpsertOption
+	}{
+		Ctx:  ctx,
+		M:    m,
+		Opts: opts,
+	})
+	mock.mu.Unlock()
+	return mock.UpsertByEmailFunc(ctx, m, opts...)
+}
+
+// UpsertByEmailCalls returns the calls made to UpsertByEmail so far.
+func (mock *OwnerRepositoryMock) UpsertByEmailCalls() []struct {
+	Ctx  context.Context
+	M    *Owner
+	Opts []UpsertOption
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx  context.Context
+		M    *Owner
+		Opts []UpsertOption
+	}(nil), mock.calls.UpsertByEmail...)
+}
+
+// UpsertBatchByEmail calls UpsertBatchByEmailFunc.
+func (mock *OwnerRepositoryMock) UpsertBatchByEmail(ctx context.Context, ms []*Owner, batchSize int, opts ...UpsertOption) error {
+	if mock.UpsertBatchByEmailFunc == nil {
+		panic("OwnerRepositoryMock.UpsertBatchByEmailFunc: method is nil but OwnerRepository.UpsertBatchByEmail was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.UpsertBatchByEmail = append(mock.calls.UpsertBatchByEmail, struct {
+		Ctx       context.Context
+		Ms        []*Owner
+		BatchSize int
+		Opts      []UpsertOption
+	}{
+		Ctx:       ctx,
+		Ms:        ms,
+		BatchSize: batchSize,
+		Opts:      opts,
+	})
+	mock.mu.Unlock()
+	return mock.UpsertBatchByEmailFunc(ctx, ms, batchSize, opts...)
+}
+
+// UpsertBatchByEmailCalls returns the calls made to UpsertBatchByEmail so far.
+func (mock *OwnerRepositoryMock) UpsertBatchByEmailCalls() []struct {
+	Ctx       context.Context
+	Ms        []*Owner
+	BatchSize int
+	Opts      []UpsertOption
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx       context.Context
+		Ms        []*Owner
+		BatchSize int
+		Opts      []UpsertOption
+	}(nil), mock.calls.UpsertBatchByEmail...)
+}
+
+// List calls ListFunc.
+func (mock *OwnerRepositoryMock) List(ctx context.Context, filter *OwnerFilter, opts ...QueryOption) ([]*Owner, error) {
+	if mock.ListFunc == nil {
+		panic("OwnerRepositoryMock.ListFunc: method is nil but OwnerRepository.List was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.List = append(mock.calls.List, struct {
+		Ctx    context.Context
+		Filter *OwnerFilter
+		Opts   []QueryOption
+	}{
+		Ctx:    ctx,
+		Filter: filter,
+		Opts:   opts,
+	})
+	mock.mu.Unlock()
+	return mock.ListFunc(ctx, filter, opts...)
+}
+
+// ListCalls returns the calls made to List so far.
+func (mock *OwnerRepositoryMock) ListCalls() []struct {
+	Ctx    context.Context
+	Filter *OwnerFilter
+	Opts   []QueryOption
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx    context.Context
+		Filter *OwnerFilter
+		Opts   []QueryOption
+	}(nil), mock.calls.List...)
+}
+
+// ListPage calls ListPageFunc.
+func (mock *OwnerRepositoryMock) ListPage(ctx context.Context, filter *OwnerFilter, sort OwnerSort, cursor string, limit int) ([]*Owner, string, error) {
+	if mock.ListPageFunc == nil {
+		panic("OwnerRepositoryMock.ListPageFunc: method is nil but OwnerRepository.ListPage was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.ListPage = append(mock.calls.ListPage, struct {
+		Ctx    context.Context
+		Filter *OwnerFilter
+		Sort   OwnerSort
+		Cursor string
+		Limit  int
+	}{
+		Ctx:    ctx,
+		Filter: filter,
+		Sort:   sort,
+		Cursor: cursor,
+		Limit:  limit,
+	})
+	mock.mu.Unlock()
+	return mock.ListPageFunc(ctx, filter, sort, cursor, limit)
+}
+
+// ListPageCalls returns the calls made to ListPage so far.
+func (mock *OwnerRepositoryMock) ListPageCalls() []struct {
+	Ctx    context.Context
+	Filter *OwnerFilter
+	Sort   OwnerSort
+	Cursor string
+	Limit  int
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx    context.Context
+		Filter *OwnerFilter
+		Sort   OwnerSort
+		Cursor string
+		Limit  int
+	}(nil), mock.calls.ListPage...)
+}
+
+// ForEachBatch calls ForEachBatchFunc.
+func (mock *OwnerRepositoryMock) ForEachBatch(ctx context.Context, filter *OwnerFilter, batchSize int, fn func([]*Owner) error) error {
+	if mock.ForEachBatchFunc == nil {
+		panic("OwnerRepositoryMock.ForEachBatchFunc: method is nil but OwnerRepository.ForEachBatch was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.ForEachBatch = append(mock.calls.ForEachBatch, struct {
+		Ctx       context.Context
+		Filter    *OwnerFilter
+		BatchSize int
+		Fn        func([]*Owner) error
+	}{
+		Ctx:       ctx,
+		Filter:    filter,
+		BatchSize: batchSize,
+		Fn:        fn,
+	})
+	mock.mu.Unlock()
+	return mock.ForEachBatchFunc(ctx, filter, batchSize, fn)
+}
+
+// ForEachBatchCalls returns the calls made to ForEachBatch so far.
+func (mock *OwnerRepositoryMock) ForEachBatchCalls() []struct {
+	Ctx       context.Context
+	Filter    *OwnerFilter
+	BatchSize int
+	Fn        func([]*Owner) error
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx       context.Context
+		Filter    *OwnerFilter
+		BatchSize int
+		Fn        func([]*Owner) error
+	}(nil), mock.calls.ForEachBatch...)
+}
+
+// Each calls EachFunc.
+func (mock *OwnerRepositoryMock) Each(ctx context.Context, filter *OwnerFilter, fn func(*Owner) error) error {
+	if mock.EachFunc == nil {
+		panic("OwnerRepositoryMock.EachFunc: method is nil but OwnerRepository.Each was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Each = append(mock.calls.Each, struct {
+		Ctx    context.Context
+		Filter *OwnerFilter
+		Fn     func(*Owner) error
+	}{
+		Ctx:    ctx,
+		Filter: filter,
+		Fn:     fn,
+	})
+	mock.mu.Unlock()
+	return mock.EachFunc(ctx, filter, fn)
+}
+
+// EachCalls returns the calls made to Each so far.
+func (mock *OwnerRepositoryMock) EachCalls() []struct {
+	Ctx    context.Context
+	Filter *OwnerFilter
+	Fn     func(*Owner) error
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx    context.Context
+		Filter *OwnerFilter
+		Fn     func(*Owner) error
+	}(nil), mock.calls.Each...)
+}
+
+// Stream calls StreamFunc.
+func (mock *OwnerRepositoryMock) Stream(ctx context.Context, filter *OwnerFilter) (<-chan *Owner, <-chan error) {
+	if mock.StreamFunc == nil {
+		panic("OwnerRepositoryMock.StreamFunc: method is nil but OwnerRepository.Stream was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Stream = append(mock.calls.Stream, struct {
+		Ctx    context.Context
+		Filter *OwnerFilter
+	}{
+		Ctx:    ctx,
+		Filter: filter,
+	})
+	mock.mu.Unlock()
+	return mock.StreamFunc(ctx, filter)
+}
+
+// StreamCalls returns the calls made to Stream so far.
+func (mock *OwnerRepositoryMock) StreamCalls() []struct {
+	Ctx    context.Context
+	Filter *OwnerFilter
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx    context.Context
+		Filter *OwnerFilter
+	}(nil), mock.calls.Stream...)
+}
+
+// GetForUpdate calls GetForUpdateFunc.
+func (mock *OwnerRepositoryMock) GetForUpdate(ctx context.Context, id uint, opts ...LockOption) (*Owner, error) {
+	if mock.GetForUpdateFunc == nil {
+		panic("OwnerRepositoryMock.GetForUpdateFunc: method is nil but OwnerRepository.GetForUpdate was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.GetForUpdate = append(mock.calls.GetForUpdate, struct {
+		Ctx  context.Context
+		Id   uint
+		Opts []LockOption
+	}{
+		Ctx:  ctx,
+		Id:   id,
+		Opts: opts,
+	})
+	mock.mu.Unlock()
+	return mock.GetForUpdateFunc(ctx, id, opts...)
+}
+
+// GetForUpdateCalls returns the calls made to GetForUpdate so far.
+func (mock *OwnerRepositoryMock) GetForUpdateCalls() []struct {
+	Ctx  context.Context
+	Id   uint
+	Opts []LockOption
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx  context.Context
+		Id   uint
+		Opts []LockOption
+	}(nil), mock.calls.GetForUpdate...)
+}
+
+// ListForUpdate calls ListForUpdateFunc.
+func (mock *OwnerRepositoryMock) ListForUpdate(ctx context.Context, filter *OwnerFilter, opts ...LockOption) ([]*Owner, error) {
+	if mock.ListForUpdateFunc == nil {
+		panic("OwnerRepositoryMock.ListForUpdateFunc: method is nil but OwnerRepository.ListForUpdate was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.ListForUpdate = append(mock.calls.ListForUpdate, struct {
+		Ctx    context.Context
+		Filter *OwnerFilter
+		Opts   []LockOption
+	}{
+		Ctx:    ctx,
+		Filter: filter,
+		Opts:   opts,
+	})
+	mock.mu.Unlock()
+	return mock.ListForUpdateFunc(ctx, filter, opts...)
+}
+
+// ListForUpdateCalls returns the calls made to ListForUpdate so far.
+func (mock *OwnerRepositoryMock) ListForUpdateCalls() []struct {
+	Ctx    context.Context
+	Filter *OwnerFilter
+	Opts   []LockOption
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx    context.Context
+		Filter *OwnerFilter
+		Opts   []LockOption
+	}(nil), mock.calls.ListForUpdate...)
+}
+
+// Count calls CountFunc.
+func (mock *OwnerRepositoryMock) Count(ctx context.Context, filter *OwnerFilter, opts ...QueryOption) (int64, error) {
+	if mock.CountFunc == nil {
+		panic("OwnerRepositoryMock.CountFunc: method is nil but OwnerRepository.Count was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Count = append(mock.calls.Count, struct {
+		Ctx    context.Context
+		Filter *OwnerFilter
+		Opts   []QueryOption
+	}{
+		Ctx:    ctx,
+		Filter: filter,
+		Opts:   opts,
+	})
+	mock.mu.Unlock()
+	return mock.CountFunc(ctx, filter, opts...)
+}
+
+// CountCalls returns the calls made to Count so far.
+func (mock *OwnerRepositoryMock) CountCalls() []struct {
+	Ctx    context.Context
+	Filter *OwnerFilter
+	Opts   []QueryOption
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx    context.Context
+		Filter *OwnerFilter
+		Opts   []QueryOption
+	}(nil), mock.calls.Count...)
+}
+
+// DeleteWhere calls DeleteWhereFunc.
+func (mock *OwnerRepositoryMock) DeleteWhere(ctx context.Context, filter *OwnerFilter) (int64, error) {
+	if mock.DeleteWhereFunc == nil {
+		panic("OwnerRepositoryMock.DeleteWhereFunc: method is nil but OwnerRepository.DeleteWhere was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.DeleteWhere = append(mock.calls.DeleteWhere, struct {
+		Ctx    context.Context
+		Filter *OwnerFilter
+	}{
+		Ctx:    ctx,
+		Filter: filter,
+	})
+	mock.mu.Unlock()
+	return mock.DeleteWhereFunc(ctx, filter)
+}
+
+// DeleteWhereCalls returns the calls made to DeleteWhere so far.
+func (mock *OwnerRepositoryMock) DeleteWhereCalls() []struct {
+	Ctx    context.Context
+	Filter *OwnerFilter
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx    context.Context
+		Filter *OwnerFilter
+	}(nil), mock.calls.DeleteWhere...)
+}
+
+// ListDeleted calls ListDeletedFunc.
+func (mock *OwnerRepositoryMock) ListDeleted(ctx context.Context, filter *OwnerFilter, opts ...QueryOption) ([]*Owner, error) {
+	if mock.ListDeletedFunc == nil {
+		panic("OwnerRepositoryMock.ListDeletedFunc: method is nil but OwnerRepository.ListDeleted was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.ListDeleted = append(mock.calls.ListDeleted, struct {
+		Ctx    context.Context
+		Filter *OwnerFilter
+		Opts   []QueryOption
+	}{
+		Ctx:    ctx,
+		Filter: filter,
+		Opts:   opts,
+	})
+	mock.mu.Unlock()
+	return mock.ListDeletedFunc(ctx, filter, opts...)
+}
+
+// ListDeletedCalls returns the calls made to ListDeleted so far.
+func (mock *OwnerRepositoryMock) ListDeletedCalls() []struct {
+	Ctx    context.Context
+	Filter *OwnerFilter
+	Opts   []QueryOption
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx    context.Context
+		Filter *OwnerFilter
+		Opts   []QueryOption
+	}(nil), mock.calls.ListDeleted...)
+}
+
+// PurgeWhere calls PurgeWhereFunc.
+func (mock *OwnerRepositoryMock) PurgeWhere(ctx context.Context, filter *OwnerFilter) (int64, error) {
+	if mock.PurgeWhereFunc == nil {
+		panic("OwnerRepositoryMock.PurgeWhereFunc: method is nil but OwnerRepository.PurgeWhere was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.PurgeWhere = append(mock.calls.PurgeWhere, struct {
+		Ctx    context.Context
+		Filter *OwnerFilter
+	}{
+		Ctx:    ctx,
+		Filter: filter,
+	})
+	mock.mu.Unlock()
+	return mock.PurgeWhereFunc(ctx, filter)
+}
+
+// PurgeWhereCalls returns the calls made to PurgeWhere so far.
+func (mock *OwnerRepositoryMock) PurgeWhereCalls() []struct {
+	Ctx    context.Context
+	Filter *OwnerFilter
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx    context.Context
+		Filter *OwnerFilter
+	}(nil), mock.calls.PurgeWhere...)
+}
+
+// OwnerRepositoryFake implements OwnerRepository in memory, for tests that
+// cannot reach a database. Like the database, it refuses rows with the
+// primary key or unique index values of another row, with an error
+// wrapping gorm.ErrDuplicatedKey as OwnerRepo does on a gorm.DB opened
+// with TranslateError, and returns a *NotFoundError where it does.
+// Unlike the database, it ignores preloads, row locks and column defaults
+// other than NULL, sorts NULL first and keeps associations apart from
+// the rows. Like matches case-sensitively, as on PostgreSQL, while
+// SQLite and MySQL ignore the case of letters. Set Now to control the
+// time rows are stamped with.
+// Deleted rows are soft deleted.
+type OwnerRepositoryFake struct {
+	fakeTable[Owner]
+}
+
+var _ OwnerRepository = (*OwnerRepositoryFake)(nil)
+
+// NewOwnerRepositoryFake returns a OwnerRepositoryFake without rows.
+func NewOwnerRepositoryFake() *OwnerRepositoryFake {
+	return &OwnerRepositoryFake{fakeTable[Owner]{schema: ownerFakeSchema}}
+}
+
+var ownerFakeSchema = &fakeSchema[Owner]{
+	model:         "Owner",
+	columns:       []string{"id", "email", "name", "nickname", "created_at", "deleted_at"},
+	readColumns:   ownerReadColumns,
+	createColumns: ownerCreateColumns,
+	updateColumns: ownerUpdateColumns,
+	keyColumns:    ownerKeyColumns,
+	autoUpdate:    []string{},
+	uniques: []fakeUnique{
+		{index: "idx_owners_email", columns: []string{"email"}},
+	},
+	softDelete: "deleted_at",
+	value: func(m *Owner, column string) any {
+		switch column {
+		case "id":
+			return m.ID
+		case "email":
+			return m.Email
+		case "name":
+			return m.Name
+		case "nickname":
+			return m.Nickname
+		case "created_at":
+			return m.CreatedAt
+		case "deleted_at":
+			return m.DeletedAt
+		}
+		return nil
+	},
+	copy: func(dst, src *Owner, column string) {
+		switch column {
+		case "id":
+			dst.ID = src.ID
+		case "email":
+			dst.Email = src.Email
+		case "name":
+			dst.Name = src.Name
+		case "nickname":
+			dst.Nickname = src.Nickname
+		case "created_at":
+			dst.CreatedAt = src.CreatedAt
+		case "deleted_at":
+			dst.DeletedAt = src.DeletedAt
+		}
+	},
+	stamp: func(m *Owner, now time.Time, create bool) {
+		if create && m.CreatedAt.IsZero() {
+			m.CreatedAt = now
+		}
+	},
+	increment: func(m *Owner, last *int64) {
+		if m.ID == 0 {
+			*last++
+			m.ID = uint(*last)
+		} else if int64(m.ID) > *last {
+			*last = int64(m.ID)
+		}
+	},
+	delete: func(m *Owner, at *time.Time) {
+		if at == nil {
+			m.DeletedAt = gorm.DeletedAt{}
+			return
+		}
+		m.DeletedAt = gorm.DeletedAt{Time: *at, Valid: true}
+	},
+}
+
+// match reports whether the row whose columns value returns satisfies
+// the predicates of f, every row when f is nil.
+func (f *OwnerFilter) match(value func(column string) any) bool {
+	if f == nil {
+		return true
+	}
+	if !f.ID.match(value("id")) {
+		return false
+	}
+	if !f.Email.match(value("email")) {
+		return false
+	}
+	if !f.Name.match(value("name")) {
+		return false
+	}
+	if !f.Nickname.match(value("nickname")) {
+		return false
+	}
+	if !f.CreatedAt.match(value("created_at")) {
+		return false
+	}
+	if !f.DeletedAt.match(value("deleted_at")) {
+		return false
+	}
+	return true
+}
+
+// empty reports whether f adds no condition, which the delete methods
+// refuse.
+func (f *OwnerFilter) empty() bool {
+	if f == nil {
+		return true
+	}
+	n := 0
+	n += len(f.ID.conditions("id"))
+	n += len(f.Email.conditions("email"))
+	n += len(f.Name.conditions("name"))
+	n += len(f.Nickname.conditions("nickname"))
+	n += len(f.CreatedAt.conditions("created_at"))
+	n += len(f.DeletedAt.conditions("deleted_at"))
+	return n == 0
+}
+
+// WithTx returns r: the fake has no transactions, but Store.RunInTx
+// restores its rows when the function it runs fails.
+func (r *OwnerRepositoryFake) WithTx(tx *gorm.DB) OwnerRepository {
+	return r
+}
+
+// Create inserts m.
+func (r *OwnerRepositoryFake) Create(ctx context.Context, m *Owner) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.insert(m)
+}
+
+// CreateInBatches inserts ms, none of them when one fails.
+func (r *OwnerRepositoryFake) CreateInBatches(ctx context.Context, ms []*Owner, batchSize int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.transaction(func() error {
+		for _, m := range ms {
+			if err := r.insert(m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Get returns the Owner with the given primary key, or a
+// *NotFoundError.
+func (r *OwnerRepositoryFake) Get(ctx context.Context, id uint, opts ...QueryOption) (*Owner, error) {
+	return r.GetByKey(ctx, OwnerKey{ID: id}, opts...)
+}
+
+// Update writes the updatable columns of m to the row with the primary
+// key of m, or returns a *NotFoundError.
+func (r *OwnerRepositoryFake) Update(ctx context.Context, m *Owner) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k := r.KeyOf(m)
+	row := r.find(ownerKeyColumns, k.values(), false)
+	if row == nil {
+		return &NotFoundError{Model: "Owner", Index: "primary key", Key: k.values()}
+	}
+	return r.update(row, m, ownerUpdateColumns, true)
+}
+
+// UpdateFields writes the fields set in p to the row with primary key k,
+// or returns a *NotFoundError. An empty p writes nothing.
+func (r *OwnerRepositoryFake) UpdateFields(ctx context.Context, k OwnerKey, p *OwnerPatch) error {
+	var m Owner
+	columns := p.apply(&m)
+	if len(columns) == 0 {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	row := r.find(ownerKeyColumns, k.values(), false)
+	if row == nil {
+		return &NotFoundError{Model: "Owner", Index: "primary key", Key: k.values()}
+	}
+	return r.update(row, &m, columns, true)
+}
+
+// Delete deletes the Owner with the given primary key, or returns a
+// *NotFoundError.
+func (r *OwnerRepositoryFake) Delete(ctx context.Context, id uint) error {
+	return r.DeleteByKey(ctx, OwnerKey{ID: id})
+}
+
+// KeyOf returns the primary key of m, as OwnerRepo.KeyOf does.
+func (r *OwnerRepositoryFake) KeyOf(m *Owner) OwnerKey {
+	return (*OwnerRepo)(nil).KeyOf(m)
+}
+
+// GetByKey returns the Owner with primary key k, or a *NotFoundError.
+func (r *OwnerRepositoryFake) GetByKey(ctx context.Context, k OwnerKey, opts ...QueryOption) (*Owner, error) {
+	o := newQueryOptions(opts)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	row := r.find(ownerKeyColumns, k.values(), o.deleted)
+	if row == nil {
+		return nil, &NotFoundError{Model: "Owner", Index: "primary key", Key: k.values()}
+	}
+	return r.read([]*Owner{row})[0], nil
+}
+
+// GetByKeys returns the Owners with the given primary keys.
+func (r *OwnerRepositoryFake) GetByKeys(ctx context.Context, keys []OwnerKey, opts ...QueryOption) ([]*Owner, error) {
+	o := newQueryOptions(opts)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rows := r.match(func(row *Owner) bool {
+		for _, k := range keys {
+			if compareRows(r.values(row, ownerKeyColumns), k.values()) == 0 {
+				return true
+			}
+		}
+		return false
+	}, o.deleted)
+	return r.read(rows), nil
+}
+
+// DeleteByKey deletes the Owner with primary key k, or returns a
+// *NotFoundError.
+func (r *OwnerRepositoryFake) DeleteByKey(ctx context.Context, k OwnerKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	row := r.find(ownerKeyColumns, k.values(), false)
+	if row == nil {
+		return &NotFoundError{Model: "Owner", Index: "primary key", Key: k.values()}
+	}
+	r.remove([]*Owner{row}, false)
+	return nil
+}
+
+// ExistsByKey reports whether a Owner has primary key k.
+func (r *OwnerRepositoryFake) ExistsByKey(ctx context.Context, k OwnerKey) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.find(ownerKeyColumns, k.values(), false) != nil, nil
+}
+
+// Restore brings back the soft deleted Owner with the given primary key,
+// or returns a *NotFoundError.
+func (r *OwnerRepositoryFake) Restore(ctx context.Context, id uint) error {
+	return r.RestoreByKey(ctx, OwnerKey{ID: id})
+}
+
+// RestoreByKey brings back the soft deleted Owner with primary key k, or
+// returns a *NotFoundError.
+func (r *OwnerRepositoryFake) RestoreByKey(ctx context.Context, k OwnerKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	row := r.find(ownerKeyColumns, k.values(), true)
+	if row == nil || r.visible(row, false) {
+		return &NotFoundError{Model: "Owner", Index: "primary key", Key: k.values()}
+	}
+	r.restore(row)
+	return nil
+}
+
+// Purge removes for good the Owner with the given primary key, deleted or
+// not, or returns a *NotFoundError.
+func (r *OwnerRepositoryFake) Purge(ctx context.Context, id uint) error {
+	return r.PurgeByKey(ctx, OwnerKey{ID: id})
+}
+
+// PurgeByKey removes for good the Owner with primary key k, deleted or
+// not, or returns a *NotFoundError.
+func (r *OwnerRepositoryFake) PurgeByKey(ctx context.Context, k OwnerKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	row := r.find(ownerKeyColumns, k.values(), true)
+	if row == nil {
+		return &NotFoundError{Model: "Owner", Index: "primary key", Key: k.values()}
+	}
+	r.remove([]*Owner{row}, true)
+	return nil
+}
+
+// GetByEmail returns the Owner with the given email, or a
+// *NotFoundError.
+func (r *OwnerRepositoryFake) GetByEmail(ctx context.Context, email string, opts ...QueryOption) (*Owner, error) {
+	o := newQueryOptions(opts)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	row := r.find([]string{"email"}, []any{email}, o.deleted)
+	if row == nil {
+		return nil, &NotFoundError{Model: "Owner", Index: "idx_owners_email", Key: []any{email}}
+	}
+	return r.read([]*Owner{row})[0], nil
+}
+
+// Upsert inserts m or, when a row has the same primary key,
+// updates that row as opts tell.
+func (r *OwnerRepositoryFake) Upsert(ctx context.Context, m *Owner, opts ...UpsertOption) error {
+	c, err := onConflict(ownerKeyColumns, "", ownerUpdateColumns, opts)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.upsert(m, c)
+}
+
+// UpsertBatch upserts ms like Upsert, none of them when one
+// fails.
+func (r *OwnerRepositoryFake) UpsertBatch(ctx context.Context, ms []*Owner, batchSize int, opts ...UpsertOption) error {
+	c, err := onConflict(ownerKeyColumns, "", ownerUpdateColumns, opts)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.transaction(func() error {
+		for _, m := range ms {
+			if err := r.upsert(m, c); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UpsertByEmail inserts m or, when a row has the same unique index idx_owners_email,
+// updates that row as opts tell.
+func (r *OwnerRepositoryFake) UpsertByEmail(ctx context.Context, m *Owner, opts ...UpsertOption) error {
+	c, err := onConflict([]string{"email"}, "", ownerUpdateColumns, opts)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.upsert(m, c)
+}
+
+// UpsertBatchByEmail upserts ms like UpsertByEmail, none of them when one
+// fails.
+func (r *OwnerRepositoryFake) UpsertBatchByEmail(ctx context.Context, ms []*Owner, batchSize int, opts ...UpsertOption) error {
+	c, err := onConflict([]string{"email"}, "", ownerUpdateColumns, opts)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.transaction(func() error {
+		for _, m := range ms {
+			if err := r.upsert(m, c); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// List returns the rows matching filter, sorted and paged by opts.
+func (r *OwnerRepositoryFake) List(ctx context.Context, filter *OwnerFilter, opts ...QueryOption) ([]*Owner, error) {
+	o := newQueryOptions(opts)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.list(r.where(filter.match), o)
+}
+
+// ListPage returns up to limit rows matching filter, sorted by sort then
+// primary key, after the page the cursor was returned with, and the
+// cursor of the next page, like OwnerRepo.ListPage. Rows whose sort
+// column is NULL are left out.
+func (r *OwnerRepositoryFake) ListPage(ctx context.Context, filter *OwnerFilter, sort OwnerSort, cursor string, limit int) ([]*Owner, string, error) {
+	if limit <= 0 {
+		return nil, "", fmt.Errorf("page limit %d is not positive", limit)
+	}
+	columns, err := sort.columns()
+	if err != nil {
+		return nil, "", err
+	}
+	var values []any
+	if cursor != "" {
+		var k OwnerKey
+		raw, err := decodeCursor(cursor, string(sort), &k)
+		if err != nil {
+			return nil, "", err
+		}
+		values = k.values()
+		if sort != OwnerSortByKey {
+			v, err := sort.decode(raw)
+			if err != nil {
+				return nil, "", ErrInvalidCursor
+			}
+			values = append([]any{v}, values...)
+		}
+	}
+	keep := r.where(filter.match)
+	if sort != OwnerSortByKey {
+		keep = func(m *Owner) bool {
+			return sqlValue(r.value(m, string(sort))) != nil && r.where(filter.match)(m)
+		}
+	}
+	ms, more := r.page(keep, columns, values, limit)
+	if !more {
+		return ms, "", nil
+	}
+	last := ms[limit-1]
+	next, err := encodeCursor(string(sort), sort.value(last), r.KeyOf(last))
+	if err != nil {
+		return nil, "", err
+	}
+	return ms, next, nil
+}
+
+// ForEachBatch calls fn with the rows matching filter, in primary key
+// order and batchSize rows at a time, like OwnerRepo.ForEachBatch.
+func (r *OwnerRepositoryFake) ForEachBatch(ctx context.Context, filter *OwnerFilter, batchSize int, fn func([]*Owner) error) error {
+	return r.forEachBatch(ctx, r.where(filter.match), batchSize, fn)
+}
+
+// Each calls fn with every row matching filter, in primary key order,
+// holding only a batch of rows in memory. It stops like ForEachBatch.
+func (r *OwnerRepositoryFake) Each(ctx context.Context, filter *OwnerFilter, fn func(*Owner) error) error {
+	return r.ForEachBatch(ctx, filter, eachBatchSize, func(ms []*Owner) error {
+		for _, m := range ms {
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Stream sends the rows matching filter on the first channel, in primary
+// key order, and closes it after the last row, at the first error or
+// when ctx is done. The second channel then receives the error, nil when
+// every row was sent. Cancel ctx to stop reading early.
+func (r *OwnerRepositoryFake) Stream(ctx context.Context, filter *OwnerFilter) (<-chan *Owner, <-chan error) {
+	ch := make(chan *Owner)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		err := r.Each(ctx, filter, func(m *Owner) error {
+			select {
+			case ch <- m:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		close(ch)
+		errc <- err
+	}()
+	return ch, errc
+}
+
+// GetForUpdate returns the Owner with the given primary key, or a
+// *NotFoundError. The fake has no row locks: opts are ignored.
+func (r *OwnerRepositoryFake) GetForUpdate(ctx context.Context, id uint, opts ...LockOption) (*Owner, error) {
+	return r.GetByKey(ctx, OwnerKey{ID: id})
+}
+
+// ListForUpdate returns the rows matching filter in primary key order.
+// The fake has no row locks: opts are ignored.
+func (r *OwnerRepositoryFake) ListForUpdate(ctx context.Context, filter *OwnerFilter, opts ...LockOption) ([]*Owner, error) {
+	return r.List(ctx, filter)
+}
+
+// Count returns the number of rows matching filter.
+func (r *OwnerRepositoryFake) Count(ctx context.Context, filter *OwnerFilter, opts ...QueryOption) (int64, error) {
+	o := newQueryOptions(opts)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return int64(len(r.match(r.where(filter.match), o.deleted))), nil
+}
+
+// DeleteWhere deletes the rows matching filter and returns how many
+// there were. Like gorm, it returns gorm.ErrMissingWhereClause when
+// filter sets no predicate.
+func (r *OwnerRepositoryFake) DeleteWhere(ctx context.Context, filter *OwnerFilter) (int64, error) {
+	if filter.empty() {
+		return 0, gorm.ErrMissingWhereClause
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rows := r.match(r.where(filter.match), false)
+	r.remove(rows, false)
+	return int64(len(rows)), nil
+}
+
+// ListDeleted returns the soft deleted rows matching filter, sorted and
+// paged by opts.
+func (r *OwnerRepositoryFake) ListDeleted(ctx context.Context, filter *OwnerFilter, opts ...QueryOption) ([]*Owner, error) {
+	o := newQueryOptions(opts)
+	o.deleted = true
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	keep := r.where(filter.match)
+	return r.list(func(m *Owner) bool { return !r.visible(m, false) && keep(m) }, o)
+}
+
+// PurgeWhere removes for good the rows matching filter, deleted or not,
+// and returns how many there were. Like DeleteWhere, it refuses to
+// remove every row.
+func (r *OwnerRepositoryFake) PurgeWhere(ctx context.Context, filter *OwnerFilter) (int64, error) {
+	if filter.empty() {
+		return 0, gorm.ErrMissingWhereClause
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rows := r.match(r.where(filter.match), true)
+	r.remove(rows, true)
+	return int64(len(rows)), nil
+}
+
+// Columns of table "pets" that generated code reads, creates and updates.
+var (
+	petReadColumns   = []string{"owner_id", "name", "kind", "age"}
+	petCreateColumns = []string{"owner_id", "name", "kind", "age"}
+	petUpdateColumns = []string{"kind", "age"}
+	petKeyColumns    = []string{"owner_id", "name"}
+)
+
+type petFields struct {
+	OwnerID Field
+	Name    Field
+	Kind    Field
+	Age     Field
+}
+
+// Pet_ describes the columns of table "pets".
+var Pet_ = petFields{
+	OwnerID: Field{Name: "OwnerID", Column: "owner_id", Table: "pets", Type: "uint"},
+	Name:    Field{Name: "Name", Column: "name", Table: "pets", Type: "string"},
+	Kind:    Field{Name: "Kind", Column: "kind", Table: "pets", Type: "string"},
+	Age:     Field{Name: "Age", Column: "age", Table: "pets", Type: "int"},
+}
+
+// PetFilter selects Pet rows: the predicates that are set must all hold.
+type PetFilter struct {
+	OwnerID OrderedPredicate[uint] // owner_id
+	Name    StringPredicate        // name
+	Kind    StringPredicate        // kind
+	Age     OrderedPredicate[int]  // age
+}
+
+// apply adds the conditions of f to db. A nil f adds none.
+func (f *PetFilter) apply(db *gorm.DB) *gorm.DB {
+	if f == nil {
+		return db
+	}
+	var conds []clause.Expression
+	conds = append(conds, f.OwnerID.conditions("owner_id")...)
+	conds = append(conds, f.Name.conditions("name")...)
+	conds = append(conds, f.Kind.conditions("kind")...)
+	conds = append(conds, f.Age.conditions("age")...)
+	if len(conds) == 0 {
+		return db
+	}
+	return db.Where(clause.And(conds...))
+}
+
+// PetRepo reads and writes the rows of table "pets".
+type PetRepo struct {
+	db *gorm.DB
+	tx bool // db is a transaction from WithTx
+}
+
+// NewPetRepo returns a PetRepo using db. Unless db is opened with
+// gorm.Config{TranslateError: true}, writes conflicting with the primary
+// key or a unique index return the error of the driver rather than one
+// matching gorm.ErrDuplicatedKey, as PetRepositoryFake returns.
+func NewPetRepo(db *gorm.DB) *PetRepo {
+	return &PetRepo{db: db}
+}
+
+// WithTx returns a PetRepo reading and writing through tx, a transaction
+// begun by the caller, e.g. in a gorm Transaction callback.
+func (r *PetRepo) WithTx(tx *gorm.DB) PetRepository {
+	return &PetRepo{db: tx, tx: true}
+}
+
+// Create inserts m.
+func (r *PetRepo) Create(ctx context.Context, m *Pet) error {
+	return r.db.WithContext(ctx).Select(petCreateColumns).Create(m).Error
+}
+
+// CreateInBatches inserts ms, batchSize rows per statement.
+func (r *PetRepo) CreateInBatches(ctx context.Context, ms []*Pet, batchSize int) error {
+	return r.db.WithContext(ctx).Select(petCreateColumns).CreateInBatches(ms, batchSize).Error
+}
+
+// PetKey is the primary key of a Pet.
+type PetKey struct {
+	OwnerID uint
+	Name    string
+}
+
+// condition matches the row with key k.
+func (k PetKey) condition() clause.Expression {
+	return clause.And(
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: "owner_id"}, Value: k.OwnerID},
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: "name"}, Value: k.Name},
+	)
+}
+
+// values returns the key columns of k in order.
+func (k PetKey) values() []any {
+	return []any{k.OwnerID, k.Name}
+}
+
+// PetPatch lists the columns of a Pet to update. Nil fields are left
+// untouched, the others are written even when they hold a zero value.
+type PetPatch struct {
+	Kind *string // kind
+	Age  *int    // age
+}
+
+// apply copies the fields set in p to m and returns their columns.
+func (p *PetPatch) apply(m *Pet) []string {
+	var columns []string
+	if p.Kind != nil {
+		m.Kind = *p.Kind
+		columns = append(columns, "kind")
+	}
+	if p.Age != nil {
+		m.Age = *p.Age
+		columns = append(columns, "age")
+	}
+	return columns
+}
+
+// Get returns the Pet with the given primary key, or a
+// *NotFoundError. Of opts, only WithDeleted and preloads apply.
+func (r *PetRepo) Get(ctx context.Context, ownerID uint, name string, opts ...QueryOption) (*Pet, error) {
+	return r.GetByKey(ctx, PetKey{OwnerID: ownerID, Name: name}, opts...)
+}
+
+// Update writes the updatable columns of m, zero values included, to
+// the row with the primary key of m, or returns a *NotFoundError.
+func (r *PetRepo) Update(ctx context.Context, m *Pet) error {
+	k := r.KeyOf(m)
+	res := r.db.WithContext(ctx).Model(m).Where(k.condition()).Select(petUpdateColumns).Updates(m)
+	return updated(res, func() (bool, error) { return r.ExistsByKey(ctx, k) }, "Pet", k.values())
+}
+
+// UpdateFields writes the fields set in p to the row with primary key k,
+// or returns a *NotFoundError. Columns gorm updates automatically, such
+// as UpdatedAt, are bumped. An empty p writes nothing.
+func (r *PetRepo) UpdateFields(ctx context.Context, k PetKey, p *PetPatch) error {
+	var m Pet
+	columns := p.apply(&m)
+	if len(columns) == 0 {
+		return nil
+	}
+	res := r.db.WithContext(ctx).Model(&m).Where(k.condition()).Select(columns).Updates(&m)
+	return updated(res, func() (bool, error) { return r.ExistsByKey(ctx, k) }, "Pet", k.values())
+}
+
+// Delete removes the Pet with the given primary key, or returns a
+// *NotFoundError.
+func (r *PetRepo) Delete(ctx context.Context, ownerID uint, name string) error {
+	return r.DeleteByKey(ctx, PetKey{OwnerID: ownerID, Name: name})
+}
+
+// KeyOf returns the primary key of m. Key fields of nil embedded structs
+// are zero, and the writes refuse such rows with ErrNilKey.
+func (r *PetRepo) KeyOf(m *Pet) PetKey {
+	k := PetKey{OwnerID: m.OwnerID, Name: m.Name}
+	return k
+}
+
+// GetByKey returns the Pet with primary key k, or a *NotFoundError.
+// Of opts, only WithDeleted and preloads apply.
+func (r *PetRepo) GetByKey(ctx context.Context, k PetKey, opts ...QueryOption) (*Pet, error) {
+	var m Pet
+	db := newQueryOptions(opts).read(r.db.WithContext(ctx))
+	if err := db.Where(k.condition()).Take(&m).Error; err != nil {
+		return nil, notFound(err, "Pet", "primary key", k.values()...)
+	}
+	return &m, nil
+}
+
+// GetByKeys returns the Pets with the given primary keys, in no
+// particular order. Keys without row are left out. Large sets of keys
+// are looked up in several queries. Of opts, only WithDeleted and
+// preloads apply.
+func (r *PetRepo) GetByKeys(ctx context.Context, keys []PetKey, opts ...QueryOption) ([]*Pet, error) {
+	o := newQueryOptions(opts)
+	var ms []*Pet
+	size := maxKeyParams / len(petKeyColumns)
+	for start := 0; start < len(keys); start += size {
+		end := start + size
+		if end > len(keys) {
+			end = len(keys)
+		}
+		values := make([][]any, 0, end-start)
+		for _, k := range keys[start:end] {
+			values = append(values, k.values())
+		}
+		var batch []*Pet
+		if err := o.read(r.db.WithContext(ctx)).Where(keysIn(r.db, petKeyColumns, values)).Find(&batch).Error; err != nil {
+			return nil, err
+		}
+		ms = append(ms, batch...)
+	}
+	return ms, nil
+}
+
+// DeleteByKey removes the Pet with primary key k, or returns a
+// *NotFoundError.
+func (r *PetRepo) DeleteByKey(ctx context.Context, k PetKey) error {
+	res := r.db.WithContext(ctx).Where(k.condition()).Delete(&Pet{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return &NotFoundError{Model: "Pet", Index: "primary key", Key: k.values()}
+	}
+	return nil
+}
+
+// ExistsByKey reports whether a Pet has primary key k.
+func (r *PetRepo) ExistsByKey(ctx context.Context, k PetKey) (bool, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&Pet{}).Where(k.condition()).Limit(1).Count(&n).Error
+	return n > 0, err
+}
+
+// Upsert inserts m or, when a row has the same primary key,
+// updates that row as opts tell. MySQL resolves conflicts on any unique
+// key of the table.
+func (r *PetRepo) Upsert(ctx context.Context, m *Pet, opts ...UpsertOption) error {
+	c, err := onConflict(petKeyColumns, "", petUpdateColumns, opts)
+	if err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Clauses(c).Select(petCreateColumns).Create(m).Error
+}
+
+// UpsertBatch upserts ms like Upsert, batchSize rows per
+// statement.
+func (r *PetRepo) UpsertBatch(ctx context.Context, ms []*Pet, batchSize int, opts ...UpsertOption) error {
+	c, err := onConflict(petKeyColumns, "", petUpdateColumns, opts)
+	if err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Clauses(c).Select(petCreateColumns).CreateInBatches(ms, batchSize).Error
+}
+
+// List returns the rows matching filter, all of them when filter is nil,
+// sorted and paged by opts. Soft deleted rows are left out unless opts
+// include WithDeleted.
+func (r *PetRepo) List(ctx context.Context, filter *PetFilter, opts ...QueryOption) ([]*Pet, error) {
+	var ms []*Pet
+	db := filter.apply(r.db.WithContext(ctx))
+	if err := newQueryOptions(opts).page(db, petKeyColumns).Find(&ms).Error; err != nil {
+		return nil, err
+	}
+	return ms, nil
+}
+
+// PetSort is the column ListPage sorts Pet rows on before their primary
+// key. Only indexed columns can be sorted on.
+type PetSort string
+
+// Columns ListPage can sort Pet rows on.
+const (
+	PetSortByKey PetSort = "" // the primary key alone
+)
+
+// columns returns the columns rows are sorted on.
+func (s PetSort) columns() ([]string, error) {
+	switch s {
+	case PetSortByKey:
+		return petKeyColumns, nil
+	}
+	return nil, fmt.Errorf("unknown PetSort %q", string(s))
+}
+
+// value returns the value of the sort column of m.
+func (s PetSort) value(m *Pet) any {
+	switch s {
+	}
+	return nil
+}
+
+// decode decodes a value of the sort column encoded by value.
+func (s PetSort) decode(raw json.RawMessage) (any, error) {
+	switch s {
+	}
+	return nil, nil
+}
+
+// ListPage returns up to limit rows matching filter, sorted by sort then
+// primary key, that come after the page the cursor was returned with,
+// from the first row when it is empty. It also returns the cursor of the
+// next page, empty after the last one. Unlike offsets, cursors do not skip
+// nor repeat rows when rows are inserted between pages. Rows whose sort
+// column is NULL are left out: databases disagree on where NULLs sort,
+// and a cursor cannot tell them from zero values.
+func (r *PetRepo) ListPage(ctx context.Context, filter *PetFilter, sort PetSort, cursor string, limit int) ([]*Pet, string, error) {
+	if limit <= 0 {
+		return nil, "", fmt.Errorf("page limit %d is not positive", limit)
+	}
+	columns, err := sort.columns()
+	if err != nil {
+		return nil, "", err
+	}
+	db := filter.apply(r.db.WithContext(ctx))
+	if sort != PetSortByKey {
+		db = db.Where(clause.Neq{Column: clause.Column{Table: clause.CurrentTable, Name: string(sort)}, Value: nil})
+	}
+	if cursor != "" {
+		var k PetKey
+		raw, err := decodeCursor(cursor, string(sort), &k)
+		if err != nil {
+			return nil, "", err
+		}
+		values := k.values()
+		if sort != PetSortByKey {
+			v, err := sort.decode(raw)
+			if err != nil {
+				return nil, "", ErrInvalidCursor
+			}
+			values = append([]any{v}, values...)
+		}
+		db = db.Where(keysetAfter(columns, values))
+	}
+	var ms []*Pet
+	if err := db.Clauses(keysetOrder(columns)).Limit(limit + 1).Find(&ms).Error; err != nil {
+		return nil, "", err
+	}
+	if len(ms) <= limit {
+		return ms, "", nil
+	}
+	ms = ms[:limit]
+	last := ms[limit-1]
+	next, err := encodeCursor(string(sort), sort.value(last), r.KeyOf(last))
+	if err != nil {
+		return nil, "", err
+	}
+	return ms, next, nil
+}
+
+// ForEachBatch calls fn with the rows matching filter, in primary key
+// order and batchSize rows at a time. It stops at the first error of fn,
+// returning it unless it is ErrStop, or when ctx is done.
+func (r *PetRepo) ForEachBatch(ctx context.Context, filter *PetFilter, batchSize int, fn func([]*Pet) error) error {
+	if batchSize <= 0 {
+		return fmt.Errorf("batch size %d is not positive", batchSize)
+	}
+	var after []any
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		db := filter.apply(r.db.WithContext(ctx))
+		if after != nil {
+			db = db.Where(keysetAfter(petKeyColumns, after))
+		}
+		var ms []*Pet
+		if err := db.Clauses(keysetOrder(petKeyColumns)).Limit(batchSize).Find(&ms).Error; err != nil {
+			return err
+		}
+		if len(ms) == 0 {
+			return nil
+		}
+		// Read the key before fn gets a chance to change it.
+		after = r.KeyOf(ms[len(ms)-1]).values()
+		if err := fn(ms); err != nil {
+			if errors.Is(err, ErrStop) {
+				return nil
+			}
+			return err
+		}
+		if len(ms) < batchSize {
+			return nil
+		}
+	}
+}
+
+// Each calls fn with every row matching filter, in primary key order,
+// holding only a batch of rows in memory. It stops like ForEachBatch.
+func (r *PetRepo) Each(ctx context.Context, filter *PetFilter, fn func(*Pet) error) error {
+	return r.ForEachBatch(ctx, filter, eachBatchSize, func(ms []*Pet) error {
+		for _, m := range ms {
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Stream sends the rows matching filter on the first channel, in primary
+// key order, and closes it after the last row, at the first error or
+// when ctx is done. The second channel then receives the error, nil when
+// every row was sent. Cancel ctx to stop reading early.
+func (r *PetRepo) Stream(ctx context.Context, filter *PetFilter) (<-chan *Pet, <-chan error) {
+	ch := make(chan *Pet)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		err := r.Each(ctx, filter, func(m *Pet) error {
+			select {
+			case ch <- m:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		close(ch)
+		errc <- err
+	}()
+	return ch, errc
+}
+
+// GetForUpdate returns the Pet with the given primary key, locked until
+// the end of the transaction of r, or a *NotFoundError.
+func (r *PetRepo) GetForUpdate(ctx context.Context, ownerID uint, name string, opts ...LockOption) (*Pet, error) {
+	db, err := lockRows(r.db.WithContext(ctx), r.tx, opts)
+	if err != nil {
+		return nil, err
+	}
+	k := PetKey{OwnerID: ownerID, Name: name}
+	var m Pet
+	if err := db.Where(k.condition()).Take(&m).Error; err != nil {
+		return nil, notFound(err, "Pet", "primary key", k.values()...)
+	}
+	return &m, nil
+}
+
+// ListForUpdate returns the rows matching filter, locked until the end of
+// the transaction of r. Rows are locked in primary key order, the same in
+// every transaction, to avoid deadlocks.
+func (r *PetRepo) ListForUpdate(ctx context.Context, filter *PetFilter, opts ...LockOption) ([]*Pet, error) {
+	db, err := lockRows(r.db.WithContext(ctx), r.tx, opts)
+	if err != nil {
+		return nil, err
+	}
+	var ms []*Pet
+	if err := filter.apply(db).Clauses(keysetOrder(petKeyColumns)).Find(&ms).Error; err != nil {
+		return nil, err
+	}
+	return ms, nil
+}
+
+// Count returns the number of rows matching filter, of all rows when
+// filter is nil. Of opts, only WithDeleted applies.
+func (r *PetRepo) Count(ctx context.Context, filter *PetFilter, opts ...QueryOption) (int64, error) {
+	var n int64
+	err := filter.apply(newQueryOptions(opts).scope(r.db.WithContext(ctx).Model(&Pet{}))).Count(&n).Error
+	return n, err
+}
+
+// DeleteWhere removes the rows matching filter and returns how many there
+// were. Like gorm, it refuses to delete every row: filter must set a
+// predicate.
+func (r *PetRepo) DeleteWhere(ctx context.Context, filter *PetFilter) (int64, error) {
+	res := filter.apply(r.db.WithContext(ctx)).Delete(&Pet{})
+	return res.RowsAffected, res.Error
+}
+
+// PetRepository is the interface of PetRepo, for code to be tested with a
+// PetRepositoryMock instead of a database.
+type PetRepository interface {
+	WithTx(tx *gorm.DB) PetRepository
+	Create(ctx context.Context, m *Pet) error
+	CreateInBatches(ctx context.Context, ms []*Pet, batchSize int) error
+	Get(ctx context.Context, ownerID uint, name string, opts ...QueryOption) (*Pet, error)
+	Update(ctx context.Context, m *Pet) error
+	UpdateFields(ctx context.Context, k PetKey, p *PetPatch) error
+	Delete(ctx context.Context, ownerID uint, name string) error
+	KeyOf(m *Pet) PetKey
+	GetByKey(ctx context.Context, k PetKey, opts ...QueryOption) (*Pet, error)
+	GetByKeys(ctx context.Context, keys []PetKey, opts ...QueryOption) ([]*Pet, error)
+	DeleteByKey(ctx context.Context, k PetKey) error
+	ExistsByKey(ctx context.Context, k PetKey) (bool, error)
+	Upsert(ctx context.Context, m *Pet, opts ...UpsertOption) error
+	UpsertBatch(ctx context.Context, ms []*Pet, batchSize int, opts ...UpsertOption) error
+	List(ctx context.Context, filter *PetFilter, opts ...QueryOption) ([]*Pet, error)
+	ListPage(ctx context.Context, filter *PetFilter, sort PetSort, cursor string, limit int) ([]*Pet, string, error)
+	ForEachBatch(ctx context.Context, filter *PetFilter, batchSize int, fn func([]*Pet) error) error
+	Each(ctx context.Context, filter *PetFilter, fn func(*Pet) error) error
+	Stream(ctx context.Context, filter *PetFilter) (<-chan *Pet, <-chan error)
+	GetForUpdate(ctx context.Context, ownerID uint, name string, opts ...LockOption) (*Pet, error)
+	ListForUpdate(ctx context.Context, filter *PetFilter, opts ...LockOption) ([]*Pet, error)
+	Count(ctx context.Context, filter *PetFilter, opts ...QueryOption) (int64, error)
+	DeleteWhere(ctx context.Context, filter *PetFilter) (int64, error)
+}
+
+var (
+	_ PetRepository = (*PetRepo)(nil)
+	_ PetRepository = (*PetRepositoryMock)(nil)
+)
+
+// PetRepositoryMock implements PetRepository by calling the func field named after
+// each method, e.g. GetFunc for Get, and records the calls, which the
+// <Method>Calls methods return. Calling a method whose func is nil
+// panics. A PetRepositoryMock is safe for concurrent use once its funcs are set.
+type PetRepositoryMock struct {
+	WithTxFunc          func(tx *gorm.DB) PetRepository
+	CreateFunc          func(ctx context.Context, m *Pet) error
+	CreateInBatchesFunc func(ctx context.Context, ms []*Pet, batchSize int) error
+	GetFunc             func(ctx context.Context, ownerID uint, name string, opts ...QueryOption) (*Pet, error)
+	UpdateFunc          func(ctx context.Context, m *Pet) error
+	UpdateFieldsFunc    func(ctx context.Context, k PetKey, p *PetPatch) error
+	DeleteFunc          func(ctx context.Context, ownerID uint, name string) error
+	KeyOfFunc           func(m *Pet) PetKey
+	GetByKeyFunc        func(ctx context.Context, k PetKey, opts ...QueryOption) (*Pet, error)
+	GetByKeysFunc       func(ctx context.Context, keys []PetKey, opts ...QueryOption) ([]*Pet, error)
+	DeleteByKeyFunc     func(ctx context.Context, k PetKey) error
+	ExistsByKeyFunc     func(ctx context.Context, k PetKey) (bool, error)
+	UpsertFunc          func(ctx context.Context, m *Pet, opts ...UpsertOption) error
+	UpsertBatchFunc     func(ctx context.Context, ms []*Pet, batchSize int, opts ...UpsertOption) error
+	ListFunc            func(ctx context.Context, filter *PetFilter, opts ...QueryOption) ([]*Pet, error)
+	ListPageFunc        func(ctx context.Context, filter *PetFilter, sort PetSort, cursor string, limit int) ([]*Pet, string, error)
+	ForEachBatchFunc    func(ctx context.Context, filter *PetFilter, batchSize int, fn func([]*Pet) error) error
+	EachFunc            func(ctx context.Context, filter *PetFilter, fn func(*Pet) error) error
+	StreamFunc          func(ctx context.Context, filter *PetFilter) (<-chan *Pet, <-chan error)
+	GetForUpdateFunc    func(ctx context.Context, ownerID uint, name string, opts ...LockOption) (*Pet, error)
+	ListForUpdateFunc   func(ctx context.Context, filter *PetFilter, opts ...LockOption) ([]*Pet, error)
+	CountFunc           func(ctx context.Context, filter *PetFilter, opts ...QueryOption) (int64, error)
+	DeleteWhereFunc     func(ctx context.Context, filter *PetFilter) (int64, error)
+
+	mu    sync.Mutex
+	calls struct {
+		WithTx []struct {
+			Tx *gorm.DB
+		}
+		Create []struct {
+			Ctx context.Context
+			M   *Pet
+		}
+		CreateInBatches []struct {
+			Ctx       context.Context
+			Ms        []*Pet
+			BatchSize int
+		}
+		Get []struct {
+			Ctx     context.Context
+			OwnerID uint
+			Name    string
+			Opts    []QueryOption
+		}
+		Update []struct {
+			Ctx context.Context
+			M   *Pet
+		}
+		UpdateFields []struct {
+			Ctx context.Context
+			K   PetKey
+			P   *PetPatch
+		}
+		Delete []struct {
+			Ctx     context.Context
+			OwnerID uint
+			Name    string
+		}
+		KeyOf []struct {
+			M *Pet
+		}
+		GetByKey []struct {
+			Ctx  context.Context
+			K    PetKey
+			Opts []QueryOption
+		}
+		GetByKeys []struct {
+			Ctx  context.Context
+			Keys []PetKey
+			Opts []QueryOption
+		}
+		DeleteByKey []struct {
+			Ctx context.Context
+			K   PetKey
+		}
+		ExistsByKey []struct {
+			Ctx context.Context
+			K   PetKey
+		}
+		Upsert []struct {
+			Ctx  context.Context
+			M    *Pet
+			Opts []UpsertOption
+		}
+		UpsertBatch []struct {
+			Ctx       context.Context
+			Ms        []*Pet
+			BatchSize int
+			Opts      []UpsertOption
+		}
+		List []struct {
+			Ctx    context.Context
+			Filter *PetFilter
+			Opts   []QueryOption
+		}
+		ListPage []struct {
+			Ctx    context.Context
+			Filter *PetFilter
+			Sort   PetSort
+			Cursor string
+			Limit  int
+		}
+		ForEachBatch []struct {
+			Ctx       context.Context
+			Filter    *PetFilter
+			BatchSize int
+			Fn        func([]*Pet) error
+		}
+		Each []struct {
+			Ctx    context.Context
+			Filter *PetFilter
+			Fn     func(*Pet) error
+		}
+		Stream []struct {
+			Ctx    context.Context
+			Filter *PetFilter
+		}
+		GetForUpdate []struct {
+			Ctx     context.Context
+			OwnerID uint
+			Name    string
+			Opts    []LockOption
+		}
+		ListForUpdate []struct {
+			Ctx    context.Context
+			Filter *PetFilter
+			Opts   []LockOption
+		}
+		Count []struct {
+			Ctx    context.Context
+			Filter *PetFilter
+			Opts   []QueryOption
+		}
+		DeleteWhere []struct {
+			Ctx    context.Context
+			Filter *PetFilter
+		}
+	}
+}
+
+// WithTx calls WithTxFunc.
+func (mock *PetRepositoryMock) WithTx(tx *gorm.DB) PetRepository {
+	if mock.WithTxFunc == nil {
+		panic("PetRepositoryMock.WithTxFunc: method is nil but PetRepository.WithTx was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.WithTx = append(mock.calls.WithTx, struct {
+		Tx *gorm.DB
+	}{
+		Tx: tx,
+	})
+	mock.mu.Unlock()
+	return mock.WithTxFunc(tx)
+}
+
+// WithTxCalls returns the calls made to WithTx so far.
+func (mock *PetRepositoryMock) WithTxCalls() []struct {
+	Tx *gorm.DB
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Tx *gorm.DB
+	}(nil), mock.calls.WithTx...)
+}
+
+// Create calls CreateFunc.
+func (mock *PetRepositoryMock) Create(ctx context.Context, m *Pet) error {
+	if mock.CreateFunc == nil {
+		panic("PetRepositoryMock.CreateFunc: method is nil but PetRepository.Create was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Create = append(mock.calls.Create, struct {
+		Ctx context.Context
+		M   *Pet
+	}{
+		Ctx: ctx,
+		M:   m,
+	})
+	mock.mu.Unlock()
+	return mock.CreateFunc(ctx, m)
+}
+
+// CreateCalls returns the calls made to Create so far.
+func (mock *PetRepositoryMock) CreateCalls() []struct {
+	Ctx context.Context
+	M   *Pet
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx context.Context
+		M   *Pet
+	}(nil), mock.calls.Create...)
+}
+
+// CreateInBatches calls CreateInBatchesFunc.
+func (mock *PetRepositoryMock) CreateInBatches(ctx context.Context, ms []*Pet, batchSize int) error {
+	if mock.CreateInBatchesFunc == nil {
+		panic("PetRepositoryMock.CreateInBatchesFunc: method is nil but PetRepository.CreateInBatches was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.CreateInBatches = append(mock.calls.CreateInBatches, struct {
+		Ctx       context.Context
+		Ms        []*Pet
+		BatchSize int
+	}{
+		Ctx:       ctx,
+		Ms:        ms,
+		BatchSize: batchSize,
+	})
+	mock.mu.Unlock()
+	return mock.CreateInBatchesFunc(ctx, ms, batchSize)
+}
+
+// CreateInBatchesCalls returns the calls made to CreateInBatches so far.
+func (mock *PetRepositoryMock) CreateInBatchesCalls() []struct {
+	Ctx       context.Context
+	Ms        []*Pet
+	BatchSize int
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx       context.Context
+		Ms        []*Pet
+		BatchSize int
+	}(nil), mock.calls.CreateInBatches...)
+}
+
+// Get calls GetFunc.
+func (mock *PetRepositoryMock) Get(ctx context.Context, ownerID uint, name string, opts ...QueryOption) (*Pet, error) {
+	if mock.GetFunc == nil {
+		panic("PetRepositoryMock.GetFunc: method is nil but PetRepository.Get was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Get = append(mock.calls.Get, struct {
+		Ctx     context.Context
+		OwnerID uint
+		Name    string
+		Opts    []QueryOption
+	}{
+		Ctx:     ctx,
+		OwnerID: ownerID,
+		Name:    name,
+		Opts:    opts,
+	})
+	mock.mu.Unlock()
+	return mock.GetFunc(ctx, ownerID, name, opts...)
+}
+
+// GetCalls returns the calls made to Get so far.
+func (mock *PetRepositoryMock) GetCalls() []struct {
+	Ctx     context.Context
+	OwnerID uint
+	Name    string
+	Opts    []QueryOption
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx     context.Context
+		OwnerID uint
+		Name    string
+		Opts    []QueryOption
+	}(nil), mock.calls.Get...)
+}
+
+// Update calls UpdateFunc.
+func (mock *PetRepositoryMock) Update(ctx context.Context, m *Pet) error {
+	if mock.UpdateFunc == nil {
+		panic("PetRepositoryMock.UpdateFunc: method is nil but PetRepository.Update was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Update = append(mock.calls.Update, struct {
+		Ctx context.Context
+		M   *Pet
+	}{
+		Ctx: ctx,
+		M:   m,
+	})
+	mock.mu.Unlock()
+	return mock.UpdateFunc(ctx, m)
+}
+
+// UpdateCalls returns the calls made to Update so far.
+func (mock *PetRepositoryMock) UpdateCalls() []struct {
+	Ctx context.Context
+	M   *Pet
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx context.Context
+		M   *Pet
+	}(nil), mock.calls.Update...)
+}
+
+// UpdateFields calls UpdateFieldsFunc.
+func (mock *PetRepositoryMock) UpdateFields(ctx context.Context, k PetKey, p *PetPatch) error {
+	if mock.UpdateFieldsFunc == nil {
+		panic("PetRepositoryMock.UpdateFieldsFunc: method is nil but PetRepository.UpdateFields was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.UpdateFields = append(mock.calls.UpdateFields, struct {
+		Ctx context.Context
+		K   PetKey
+		P   *PetPatch
+	}{
+		Ctx: ctx,
+		K:   k,
+		P:   p,
+	})
+	mock.mu.Unlock()
+	return mock.UpdateFieldsFunc(ctx, k, p)
+}
+
+// UpdateFieldsCalls returns the calls made to UpdateFields so far.
+func (mock *PetRepositoryMock) UpdateFieldsCalls() []struct {
+	Ctx context.Context
+	K   PetKey
+	P   *PetPatch
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx context.Context
+		K   PetKey
+		P   *PetPatch
+	}(nil), mock.calls.UpdateFields...)
+}
+
+// Delete calls DeleteFunc.
+func (mock *PetRepositoryMock) Delete(ctx context.Context, ownerID uint, name string) error {
+	if mock.DeleteFunc == nil {
+		panic("PetRepositoryMock.DeleteFunc: method is nil but PetRepository.Delete was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Delete = append(mock.calls.Delete, struct {
+		Ctx     context.Context
+		OwnerID uint
+		Name    string
+	}{
+		Ctx:     ctx,
+		OwnerID: ownerID,
+		Name:    name,
+	})
+	mock.mu.Unlock()
+	return mock.DeleteFunc(ctx, ownerID, name)
+}
+
+// DeleteCalls returns the calls made to Delete so far.
+func (mock *PetRepositoryMock) DeleteCalls() []struct {
+	Ctx     context.Context
+	OwnerID uint
+	Name    string
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx     context.Context
+		OwnerID uint
+		Name    string
+	}(nil), mock.calls.Delete...)
+}
+
+// KeyOf calls KeyOfFunc.
+func (mock *PetRepositoryMock) KeyOf(m *Pet) PetKey {
+	if mock.KeyOfFunc == nil {
+		panic("PetRepositoryMock.KeyOfFunc: method is nil but PetRepository.KeyOf was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.KeyOf = append(mock.calls.KeyOf, struct {
+		M *Pet
+	}{
+		M: m,
+	})
+	mock.mu.Unlock()
+	return mock.KeyOfFunc(m)
+}
+
+// KeyOfCalls returns the calls made to KeyOf so far.
+func (mock *PetRepositoryMock) KeyOfCalls() []struct {
+	M *Pet
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		M *Pet
+	}(nil), mock.calls.KeyOf...)
+}
+
+// GetByKey calls GetByKeyFunc.
+func (mock *PetRepositoryMock) GetByKey(ctx context.Context, k PetKey, opts ...QueryOption) (*Pet, error) {
+	if mock.GetByKeyFunc == nil {
+		panic("PetRepositoryMock.GetByKeyFunc: method is nil but PetRepository.GetByKey was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.GetByKey = append(mock.calls.GetByKey, struct {
+		Ctx  context.Context
+		K    PetKey
+		Opts []QueryOption
+	}{
+		Ctx:  ctx,
+		K:    k,
+		Opts: opts,
+	})
+	mock.mu.Unlock()
+	return mock.GetByKeyFunc(ctx, k, opts...)
+}
+
+// GetByKeyCalls returns the calls made to GetByKey so far.
+func (mock *PetRepositoryMock) GetByKeyCalls() []struct {
+	Ctx  context.Context
+	K    PetKey
+	Opts []QueryOption
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx  context.Context
+		K    PetKey
+		Opts []QueryOption
+	}(nil), mock.calls.GetByKey...)
+}
+
+// GetByKeys calls GetByKeysFunc.
+func (mock *PetRepositoryMock) GetByKeys(ctx context.Context, keys []PetKey, opts ...QueryOption) ([]*Pet, error) {
+	if mock.GetByKeysFunc == nil {
+		panic("PetRepositoryMock.GetByKeysFunc: method is nil but PetRepository.GetByKeys was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.GetByKeys = append(mock.calls.GetByKeys, struct {
+		Ctx  context.Context
+		Keys []PetKey
+		Opts []QueryOption
+	}{
+		Ctx:  ctx,
+		Keys: keys,
+		Opts: opts,
+	})
+	mock.mu.Unlock()
+	return mock.GetByKeysFunc(ctx, keys, opts...)
+}
+
+// GetByKeysCalls returns the calls made to GetByKeys so far.
+func (mock *PetRepositoryMock) GetByKeysCalls() []struct {
+	Ctx  context.Context
+	Keys []PetKey
+	Opts []QueryOption
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx  context.Context
+		Keys []PetKey
+		Opts []QueryOption
+	}(nil), mock.calls.GetByKeys...)
+}
+
+// DeleteByKey calls DeleteByKeyFunc.
+func (mock *PetRepositoryMock) DeleteByKey(ctx context.Context, k PetKey) error {
+	if mock.DeleteByKeyFunc == nil {
+		panic("PetRepositoryMock.DeleteByKeyFunc: method is nil but PetRepository.DeleteByKey was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.DeleteByKey = append(mock.calls.DeleteByKey, struct {
+		Ctx context.Context
+		K   PetKey
+	}{
+		Ctx: ctx,
+		K:   k,
+	})
+	mock.mu.Unlock()
+	return mock.DeleteByKeyFunc(ctx, k)
+}
+
+// DeleteByKeyCalls returns the calls made to DeleteByKey so far.
+func (mock *PetRepositoryMock) DeleteByKeyCalls() []struct {
+	Ctx context.Context
+	K   PetKey
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx context.Context
+		K   PetKey
+	}(nil), mock.calls.DeleteByKey...)
+}
+
+// ExistsByKey calls ExistsByKeyFunc.
+func (mock *PetRepositoryMock) ExistsByKey(ctx context.Context, k PetKey) (bool, error) {
+	if mock.ExistsByKeyFunc == nil {
+		panic("PetRepositoryMock.ExistsByKeyFunc: method is nil but PetRepository.ExistsByKey was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.ExistsByKey = append(mock.calls.ExistsByKey, struct {
+		Ctx context.Context
+		K   PetKey
+	}{
+		Ctx: ctx,
+		K:   k,
+	})
+	mock.mu.Unlock()
+	return mock.ExistsByKeyFunc(ctx, k)
+}
+
+// ExistsByKeyCalls returns the calls made to ExistsByKey so far.
+func (mock *PetRepositoryMock) ExistsByKeyCalls() []struct {
+	Ctx context.Context
+	K   PetKey
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx context.Context
+		K   PetKey
+	}(nil), mock.calls.ExistsByKey...)
+}
+
+// Upsert calls UpsertFunc.
+func (mock *PetRepositoryMock) Upsert(ctx context.Context, m *Pet, opts ...UpsertOption) error {
+	if mock.UpsertFunc == nil {
+		panic("PetRepositoryMock.UpsertFunc: method is nil but PetRepository.Upsert was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Upsert = append(mock.calls.Upsert, struct {
+		Ctx  context.Context
+		M    *Pet
+		Opts []UpsertOption
+	}{
+		Ctx:  ctx,
+		M:    m,
+		Opts: opts,
+	})
+	mock.mu.Unlock()
+	return mock.UpsertFunc(ctx, m, opts...)
+}
+
+// UpsertCalls returns the calls made to Upsert so far.
+func (mock *PetRepositoryMock) UpsertCalls() []struct {
+	Ctx  context.Context
+	M    *Pet
+	Opts []UpsertOption
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx  context.Context
+		M    *Pet
+		Opts []UpsertOption
+	}(nil), mock.calls.Upsert...)
+}
+
+// UpsertBatch calls UpsertBatchFunc.
+func (mock *PetRepositoryMock) UpsertBatch(ctx context.Context, ms []*Pet, batchSize int, opts ...UpsertOption) error {
+	if mock.UpsertBatchFunc == nil {
+		panic("PetRepositoryMock.UpsertBatchFunc: method is nil but PetRepository.UpsertBatch was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.UpsertBatch = append(mock.calls.UpsertBatch, struct {
+		Ctx       context.Context
+		Ms        []*Pet
+		BatchSize int
+		Opts      []UpsertOption
+	}{
+		Ctx:       ctx,
+		Ms:        ms,
+		BatchSize: batchSize,
+		Opts:      opts,
+	})
+	mock.mu.Unlock()
+	return mock.UpsertBatchFunc(ctx, ms, batchSize, opts...)
+}
+
+// UpsertBatchCalls returns the calls made to UpsertBatch so far.
+func (mock *PetRepositoryMock) UpsertBatchCalls() []struct {
+	Ctx       context.Context
+	Ms        []*Pet
+	BatchSize int
+	Opts      []UpsertOption
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx       context.Context
+		Ms        []*Pet
+		BatchSize int
+		Opts      []UpsertOption
+	}(nil), mock.calls.UpsertBatch...)
+}
+
+// List calls ListFunc.
+func (mock *PetRepositoryMock) List(ctx context.Context, filter *PetFilter, opts ...QueryOption) ([]*Pet, error) {
+	if mock.ListFunc == nil {
+		panic("PetRepositoryMock.ListFunc: method is nil but PetRepository.List was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.List = append(mock.calls.List, struct {
+		Ctx    context.Context
+		Filter *PetFilter
+		Opts   []QueryOption
+	}{
+		Ctx:    ctx,
+		Filter: filter,
+		Opts:   opts,
+	})
+	mock.mu.Unlock()
+	return mock.ListFunc(ctx, filter, opts...)
+}
+
+// ListCalls returns the calls made to List so far.
+func (mock *PetRepositoryMock) ListCalls() []struct {
+	Ctx    context.Context
+	Filter *PetFilter
+	Opts   []QueryOption
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx    context.Context
+		Filter *PetFilter
+		Opts   []QueryOption
+	}(nil), mock.calls.List...)
+}
+
+// ListPage calls ListPageFunc.
+func (mock *PetRepositoryMock) ListPage(ctx context.Context, filter *PetFilter, sort PetSort, cursor string, limit int) ([]*Pet, string, error) {
+	if mock.ListPageFunc == nil {
+		panic("PetRepositoryMock.ListPageFunc: method is nil but PetRepository.ListPage was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.ListPage = append(mock.calls.ListPage, struct {
+		Ctx    context.Context
+		Filter *PetFilter
+		Sort   PetSort
+		Cursor string
+		Limit  int
+	}{
+		Ctx:    ctx,
+		Filter: filter,
+		Sort:   sort,
+		Cursor: cursor,
+		Limit:  limit,
+	})
+	mock.mu.Unlock()
+	return mock.ListPageFunc(ctx, filter, sort, cursor, limit)
+}
+
+// ListPageCalls returns the calls made to ListPage so far.
+func (mock *PetRepositoryMock) ListPageCalls() []struct {
+	Ctx    context.Context
+	Filter *PetFilter
+	Sort   PetSort
+	Cursor string
+	Limit  int
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx    context.Context
+		Filter *PetFilter
+		Sort   PetSort
+		Cursor string
+		Limit  int
+	}(nil), mock.calls.ListPage...)
+}
+
+// ForEachBatch calls ForEachBatchFunc.
+func (mock *PetRepositoryMock) ForEachBatch(ctx context.Context, filter *PetFilter, batchSize int, fn func([]*Pet) error) error {
+	if mock.ForEachBatchFunc == nil {
+		panic("PetRepositoryMock.ForEachBatchFunc: method is nil but PetRepository.ForEachBatch was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.ForEachBatch = append(mock.calls.ForEachBatch, struct {
+		Ctx       context.Context
+		Filter    *PetFilter
+		BatchSize int
+		Fn        func([]*Pet) error
+	}{
+		Ctx:       ctx,
+		Filter:    filter,
+		BatchSize: batchSize,
+		Fn:        fn,
+	})
+	mock.mu.Unlock()
+	return mock.ForEachBatchFunc(ctx, filter, batchSize, fn)
+}
+
+// ForEachBatchCalls returns the calls made to ForEachBatch so far.
+func (mock *PetRepositoryMock) ForEachBatchCalls() []struct {
+	Ctx       context.Context
+	Filter    *PetFilter
+	BatchSize int
+	Fn        func([]*Pet) error
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx       context.Context
+		Filter    *PetFilter
+		BatchSize int
+		Fn        func([]*Pet) error
+	}(nil), mock.calls.ForEachBatch...)
+}
+
+// Each calls EachFunc.
+func (mock *PetRepositoryMock) Each(ctx context.Context, filter *PetFilter, fn func(*Pet) error) error {
+	if mock.EachFunc == nil {
+		panic("PetRepositoryMock.EachFunc: method is nil but PetRepository.Each was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Each = append(mock.calls.Each, struct {
+		Ctx    context.Context
+		Filter *PetFilter
+		Fn     func(*Pet) error
+	}{
+		Ctx:    ctx,
+		Filter: filter,
+		Fn:     fn,
+	})
+	mock.mu.Unlock()
+	return mock.EachFunc(ctx, filter, fn)
+}
+
+// EachCalls returns the calls made to Each so far.
+func (mock *PetRepositoryMock) EachCalls() []struct {
+	Ctx    context.Context
+	Filter *PetFilter
+	Fn     func(*Pet) error
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx    context.Context
+		Filter *PetFilter
+		Fn     func(*Pet) error
+	}(nil), mock.calls.Each...)
+}
+
+// Stream calls StreamFunc.
+func (mock *PetRepositoryMock) Stream(ctx context.Context, filter *PetFilter) (<-chan *Pet, <-chan error) {
+	if mock.StreamFunc == nil {
+		panic("PetRepositoryMock.StreamFunc: method is nil but PetRepository.Stream was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Stream = append(mock.calls.Stream, struct {
+		Ctx    context.Context
+		Filter *PetFilter
+	}{
+		Ctx:    ctx,
+		Filter: filter,
+	})
+	mock.mu.Unlock()
+	return mock.StreamFunc(ctx, filter)
+}
+
+// StreamCalls returns the calls made to Stream so far.
+func (mock *PetRepositoryMock) StreamCalls() []struct {
+	Ctx    context.Context
+	Filter *PetFilter
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx    context.Context
+		Filter *PetFilter
+	}(nil), mock.calls.Stream...)
+}
+
+// GetForUpdate calls GetForUpdateFunc.
+func (mock *PetRepositoryMock) GetForUpdate(ctx context.Context, ownerID uint, name string, opts ...LockOption) (*Pet, error) {
+	if mock.GetForUpdateFunc == nil {
+		panic("PetRepositoryMock.GetForUpdateFunc: method is nil but PetRepository.GetForUpdate was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.GetForUpdate = append(mock.calls.GetForUpdate, struct {
+		Ctx     context.Context
+		OwnerID uint
+		Name    string
+		Opts    []LockOption
+	}{
+		Ctx:     ctx,
+		OwnerID: ownerID,
+		Name:    name,
+		Opts:    opts,
+	})
+	mock.mu.Unlock()
+	return mock.GetForUpdateFunc(ctx, ownerID, name, opts...)
+}
+
+// GetForUpdateCalls returns the calls made to GetForUpdate so far.
+func (mock *PetRepositoryMock) GetForUpdateCalls() []struct {
+	Ctx     context.Context
+	OwnerID uint
+	Name    string
+	Opts    []LockOption
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx     context.Context
+		OwnerID uint
+		Name    string
+		Opts    []LockOption
+	}(nil), mock.calls.GetForUpdate...)
+}
+
+// ListForUpdate calls ListForUpdateFunc.
+func (mock *PetRepositoryMock) ListForUpdate(ctx context.Context, filter *PetFilter, opts ...LockOption) ([]*Pet, error) {
+	if mock.ListForUpdateFunc == nil {
+		panic("PetRepositoryMock.ListForUpdateFunc: method is nil but PetRepository.ListForUpdate was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.ListForUpdate = append(mock.calls.ListForUpdate, struct {
+		Ctx    context.Context
+		Filter *PetFilter
+		Opts   []LockOption
+	}{
+		Ctx:    ctx,
+		Filter: filter,
+		Opts:   opts,
+	})
+	mock.mu.Unlock()
+	return mock.ListForUpdateFunc(ctx, filter, opts...)
+}
+
+// ListForUpdateCalls returns the calls made to ListForUpdate so far.
+func (mock *PetRepositoryMock) ListForUpdateCalls() []struct {
+	Ctx    context.Context
+	Filter *PetFilter
+	Opts   []LockOption
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx    context.Context
+		Filter *PetFilter
+		Opts   []LockOption
+	}(nil), mock.calls.ListForUpdate...)
+}
+
+// Count calls CountFunc.
+func (mock *PetRepositoryMock) Count(ctx context.Context, filter *PetFilter, opts ...QueryOption) (int64, error) {
+	if mock.CountFunc == nil {
+		panic("PetRepositoryMock.CountFunc: method is nil but PetRepository.Count was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Count = append(mock.calls.Count, struct {
+		Ctx    context.Context
+		Filter *PetFilter
+		Opts   []QueryOption
+	}{
+		Ctx:    ctx,
+		Filter: filter,
+		Opts:   opts,
+	})
+	mock.mu.Unlock()
+	return mock.CountFunc(ctx, filter, opts...)
+}
+
+// CountCalls returns the calls made to Count so far.
+func (mock *PetRepositoryMock) CountCalls() []struct {
+	Ctx    context.Context
+	Filter *PetFilter
+	Opts   []QueryOption
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx    context.Context
+		Filter *PetFilter
+		Opts   []QueryOption
+	}(nil), mock.calls.Count...)
+}
+
+// DeleteWhere calls DeleteWhereFunc.
+func (mock *PetRepositoryMock) DeleteWhere(ctx context.Context, filter *PetFilter) (int64, error) {
+	if mock.DeleteWhereFunc == nil {
+		panic("PetRepositoryMock.DeleteWhereFunc: method is nil but PetRepository.DeleteWhere was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.DeleteWhere = append(mock.calls.DeleteWhere, struct {
+		Ctx    context.Context
+		Filter *PetFilter
+	}{
+		Ctx:    ctx,
+		Filter: filter,
+	})
+	mock.mu.Unlock()
+	return mock.DeleteWhereFunc(ctx, filter)
+}
+
+// DeleteWhereCalls returns the calls made to DeleteWhere so far.
+func (mock *PetRepositoryMock) DeleteWhereCalls() []struct {
+	Ctx    context.Context
+	Filter *PetFilter
+} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]struct {
+		Ctx    context.Context
+		Filter *PetFilter
+	}(nil), mock.calls.DeleteWhere...)
+}
+
+// PetRepositoryFake implements PetRepository in memory, for tests that
+// cannot reach a database. Like the database, it refuses rows with the
+// primary key or unique index values of another row, with an error
+// wrapping gorm.ErrDuplicatedKey as PetRepo does on a gorm.DB opened
+// with TranslateError, and returns a *NotFoundError where it does.
+// Unlike the database, it ignores preloads, row locks and column defaults
+// other than NULL, sorts NULL first and keeps associations apart from
+// the rows. Like matches case-sensitively, as on PostgreSQL, while
+// SQLite and MySQL ignore the case of letters. Set Now to control the
+// time rows are stamped with.
+type PetRepositoryFake struct {
+	fakeTable[Pet]
+}
+
+var _ PetRepository = (*PetRepositoryFake)(nil)
+
+// NewPetRepositoryFake returns a PetRepositoryFake without rows.
+func NewPetRepositoryFake() *PetRepositoryFake {
+	return &PetRepositoryFake{fakeTable[Pet]{schema: petFakeSchema}}
+}
+
+var petFakeSchema = &fakeSchema[Pet]{
+	model:         "Pet",
+	columns:       []string{"owner_id", "name", "kind", "age"},
+	readColumns:   petReadColumns,
+	createColumns: petCreateColumns,
+	updateColumns: petUpdateColumns,
+	keyColumns:    petKeyColumns,
+	autoUpdate:    []string{},
+	softDelete:    "",
+	value: func(m *Pet, column string) any {
+		switch column {
+		case "owner_id":
+			return m.OwnerID
+		case "name":
+			return m.Name
+		case "kind":
+			return m.Kind
+		case "age":
+			return m.Age
+		}
+		return nil
+	},
+	copy: func(dst, src *Pet, column string) {
+		switch column {
+		case "owner_id":
+			dst.OwnerID = src.OwnerID
+		case "name":
+			dst.Name = src.Name
+		case "kind":
+			dst.Kind = src.Kind
+		case "age":
+			dst.Age = src.Age
+		}
+	},
+	stamp: func(m *Pet, now time.Time, create bool) {
+	},
+}
+
+// match reports whether the row whose columns value returns satisfies
+// the predicates of f, every row when f is nil.
+func (f *PetFilter) match(value func(column string) any) bool {
+	if f == nil {
+		return true
+	}
+	if !f.OwnerID.match(value("owner_id")) {
+		return false
+	}
+	if !f.Name.match(value("name")) {
+		return false
+	}
+	if !f.Kind.match(value("kind")) {
+		return false
+	}
+	if !f.Age.match(value("age")) {
+		return false
+	}
+	return true
+}
+
+// empty reports whether f adds no condition, which the delete methods
+// refuse.
+func (f *PetFilter) empty() bool {
+	if f == nil {
+		return true
+	}
+	n := 0
+	n += len(f.OwnerID.conditions("owner_id"))
+	n += len(f.Name.conditions("name"))
+	n += len(f.Kind.conditions("kind"))
+	n += len(f.Age.conditions("age"))
+	return n == 0
+}
+
+// WithTx returns r: the fake has no transactions, but Store.RunInTx
+// restores its rows when the function it runs fails.
+func (r *PetRepositoryFake) WithTx(tx *gorm.DB) PetRepository {
+	return r
+}
+
+// Create inserts m.
+func (r *PetRepositoryFake) Create(ctx context.Context, m *Pet) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.insert(m)
+}
+
+// CreateInBatches inserts ms, none of them when one fails.
+func (r *PetRepositoryFake) CreateInBatches(ctx context.Context, ms []*Pet, batchSize int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.transaction(func() error {
+		for _, m := range ms {
+			if err := r.insert(m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Get returns the Pet with the given primary key, or a
+// *NotFoundError.
+func (r *PetRepositoryFake) Get(ctx context.Context, ownerID uint, name string, opts ...QueryOption) (*Pet, error) {
+	return r.GetByKey(ctx, PetKey{OwnerID: ownerID, Name: name}, opts...)
+}
+
+// Update writes the updatable columns of m to the row with the primary
+// key of m, or returns a *NotFoundError.
+func (r *PetRepositoryFake) Update(ctx context.Context, m *Pet) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k := r.KeyOf(m)
+	row := r.find(petKeyColumns, k.values(), false)
+	if row == nil {
+		return &NotFoundError{Model: "Pet", Index: "primary key", Key: k.values()}
+	}
+	return r.update(row, m, petUpdateColumns, true)
+}
+
+// UpdateFields writes the fields set in p to the row with primary key k,
+// or returns a *NotFoundError. An empty p writes nothing.
+func (r *PetRepositoryFake) UpdateFields(ctx context.Context, k PetKey, p *PetPatch) error {
+	var m Pet
+	columns := p.apply(&m)
+	if len(columns) == 0 {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	row := r.find(petKeyColumns, k.values(), false)
+	if row == nil {
+		return &NotFoundError{Model: "Pet", Index: "primary key", Key: k.values()}
+	}
+	return r.update(row, &m, columns, true)
+}
+
+// Delete deletes the Pet with the given primary key, or returns a
+// *NotFoundError.
+func (r *PetRepositoryFake) Delete(ctx context.Context, ownerID uint, name string) error {
+	return r.DeleteByKey(ctx, PetKey{OwnerID: ownerID, Name: name})
+}
+
+// KeyOf returns the primary key of m, as PetRepo.KeyOf does.
+func (r *PetRepositoryFake) KeyOf(m *Pet) PetKey {
+	return (*PetRepo)(nil).KeyOf(m)
+}
+
+// GetByKey returns the Pet with primary key k, or a *NotFoundError.
+func (r *PetRepositoryFake) GetByKey(ctx context.Context, k PetKey, opts ...QueryOption) (*Pet, error) {
+	o := newQueryOptions(opts)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	row := r.find(petKeyColumns, k.values(), o.deleted)
+	if row == nil {
+		return nil, &NotFoundError{Model: "Pet", Index: "primary key", Key: k.values()}
+	}
+	return r.read([]*Pet{row})[0], nil
+}
+
+// GetByKeys returns the Pets with the given primary keys.
+func (r *PetRepositoryFake) GetByKeys(ctx context.Context, keys []PetKey, opts ...QueryOption) ([]*Pet, error) {
+	o := newQueryOptions(opts)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rows := r.match(func(row *Pet) bool {
+		for _, k := range keys {
+			if compareRows(r.values(row, petKeyColumns), k.values()) == 0 {
+				return true
+			}
+		}
+		return false
+	}, o.deleted)
+	return r.read(rows), nil
+}
+
+// DeleteByKey deletes the Pet with primary key k, or returns a
+// *NotFoundError.
+func (r *PetRepositoryFake) DeleteByKey(ctx context.Context, k PetKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	row := r.find(petKeyColumns, k.values(), false)
+	if row == nil {
+		return &NotFoundError{Model: "Pet", Index: "primary key", Key: k.values()}
+	}
+	r.remove([]*Pet{row}, false)
+	return nil
+}
+
+// ExistsByKey reports whether a Pet has primary key k.
+func (r *PetRepositoryFake) ExistsByKey(ctx context.Context, k PetKey) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.find(petKeyColumns, k.values(), false) != nil, nil
+}
+
+// Upsert inserts m or, when a row has the same primary key,
+// updates that row as opts tell.
+func (r *PetRepositoryFake) Upsert(ctx context.Context, m *Pet, opts ...UpsertOption) error {
+	c, err := onConflict(petKeyColumns, "", petUpdateColumns, opts)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.upsert(m, c)
+}
+
+// UpsertBatch upserts ms like Upsert, none of them when one
+// fails.
+func (r *PetRepositoryFake) UpsertBatch(ctx context.Context, ms []*Pet, batchSize int, opts ...UpsertOption) error {
+	c, err := onConflict(petKeyColumns, "", petUpdateColumns, opts)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.transaction(func() error {
+		for _, m := range ms {
+			if err := r.upsert(m, c); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// List returns the rows matching filter, sorted and paged by opts.
+func (r *PetRepositoryFake) List(ctx context.Context, filter *PetFilter, opts ...QueryOption) ([]*Pet, error) {
+	o := newQueryOptions(opts)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.list(r.where(filter.match), o)
+}
+
+// ListPage returns up to limit rows matching filter, sorted by sort then
+// primary key, after the page the cursor was returned with, and the
+// cursor of the next page, like PetRepo.ListPage. Rows whose sort
+// column is NULL are left out.
+func (r *PetRepositoryFake) ListPage(ctx context.Context, filter *PetFilter, sort PetSort, cursor string, limit int) ([]*Pet, string, error) {
+	if limit <= 0 {
+		return nil, "", fmt.Errorf("page limit %d is not positive", limit)
+	}
+	columns, err := sort.columns()
+	if err != nil {
+		return nil, "", err
+	}
+	var values []any
+	if cursor != "" {
+		var k PetKey
+		raw, err := decodeCursor(cursor, string(sort), &k)
+		if err != nil {
+			return nil, "", err
+		}
+		values = k.values()
+		if sort != PetSortByKey {
+			v, err := sort.decode(raw)
+			if err != nil {
+				return nil, "", ErrInvalidCursor
+			}
+			values = append([]any{v}, values...)
+		}
+	}
+	keep := r.where(filter.match)
+	if sort != PetSortByKey {
+		keep = func(m *Pet) bool {
+			return sqlValue(r.value(m, string(sort))) != nil && r.where(filter.match)(m)
+		}
+	}
+	ms, more := r.page(keep, columns, values, limit)
+	if !more {
+		return ms, "", nil
+	}
+	last := ms[limit-1]
+	next, err := encodeCursor(string(sort), sort.value(last), r.KeyOf(last))
+	if err != nil {
+		return nil, "", err
+	}
+	return ms, next, nil
+}
+
+// ForEachBatch calls fn with the rows matching filter, in primary key
+// order and batchSize rows at a time, like PetRepo.ForEachBatch.
+func (r *PetRepositoryFake) ForEachBatch(ctx context.Context, filter *PetFilter, batchSize int, fn func([]*Pet) error) error {
+	return r.forEachBatch(ctx, r.where(filter.match), batchSize, fn)
+}
+
+// Each calls fn with every row matching filter, in primary key order,
+// holding only a batch of rows in memory. It stops like ForEachBatch.
+func (r *PetRepositoryFake) Each(ctx context.Context, filter *PetFilter, fn func(*Pet) error) error {
+	return r.ForEachBatch(ctx, filter, eachBatchSize, func(ms []*Pet) error {
+		for _, m := range ms {
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Stream sends the rows matching filter on the first channel, in primary
+// key order, and closes it after the last row, at the first error or
+// when ctx is done. The second channel then receives the error, nil when
+// every row was sent. Cancel ctx to stop reading early.
+func (r *PetRepositoryFake) Stream(ctx context.Context, filter *PetFilter) (<-chan *Pet, <-chan error) {
+	ch := make(chan *Pet)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		err := r.Each(ctx, filter, func(m *Pet) error {
+			select {
+			case ch <- m:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		close(ch)
+		errc <- err
+	}()
+	return ch, errc
+}
+
+// GetForUpdate returns the Pet with the given primary key, or a
+// *NotFoundError. The fake has no row locks: opts are ignored.
+func (r *PetRepositoryFake) GetForUpdate(ctx context.Context, ownerID uint, name string, opts ...LockOption) (*Pet, error) {
+	return r.GetByKey(ctx, PetKey{OwnerID: ownerID, Name: name})
+}
+
+// ListForUpdate returns the rows matching filter in primary key order.
+// The fake has no row locks: opts are ignored.
+func (r *PetRepositoryFake) ListForUpdate(ctx context.Context, filter *PetFilter, opts ...LockOption) ([]*Pet, error) {
+	return r.List(ctx, filter)
+}
+
+// Count returns the number of rows matching filter.
+func (r *PetRepositoryFake) Count(ctx context.Context, filter *PetFilter, opts ...QueryOption) (int64, error) {
+	o := newQueryOptions(opts)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return int64(len(r.match(r.where(filter.match), o.deleted))), nil
+}
+
+// DeleteWhere deletes the rows matching filter and returns how many
+// there were. Like gorm, it returns gorm.ErrMissingWhereClause when
+// filter sets no predicate.
+func (r *PetRepositoryFake) DeleteWhere(ctx context.Context, filter *PetFilter) (int64, error) {
+	if filter.empty() {
+		return 0, gorm.ErrMissingWhereClause
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rows := r.match(r.where(filter.match), false)
+	r.remove(rows, false)
+	return int64(len(rows)), nil
+}
@@ -0,0 +1,31 @@
+// Package example holds models gormaid generates code for. The generated
+// files are checked in: the tests of gormaid regenerate them and compare,
+// and the tests of example run them on SQLite and against the fakes.
+package example
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:generate go run github.com/nathanusask/gormaid -struct Owner,Pet -o crud.go
+
+// Owner has an auto-increment key, a unique index, a nullable column to
+// sort on and is soft deleted.
+type Owner struct {
+	ID        uint
+	Email     string `gorm:"uniqueIndex"`
+	Name      string
+	Nickname  *string `gorm:"index"`
+	CreatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+// Pet has a composite key.
+type Pet struct {
+	OwnerID uint   `gorm:"primaryKey;autoIncrement:false"`
+	Name    string `gorm:"primaryKey"`
+	Kind    string
+	Age     int
+}
@@ -0,0 +1,266 @@
+package example
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// backends open the Stores every parity test runs on: the repositories on
+// an empty SQLite database and the fakes.
+var backends = []struct {
+	name string
+	open func(t *testing.T) *Store
+}{
+	{"sqlite", func(t *testing.T) *Store {
+		db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true, Logger: logger.Discard})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Every connection to :memory: opens a database of its own.
+		sqlDB, err := db.DB()
+		if err != nil {
+			t.Fatal(err)
+		}
+		sqlDB.SetMaxOpenConns(1)
+		t.Cleanup(func() { sqlDB.Close() })
+		if err := db.AutoMigrate(&Owner{}, &Pet{}); err != nil {
+			t.Fatal(err)
+		}
+		return NewStore(db)
+	}},
+	{"fake", func(t *testing.T) *Store {
+		return &Store{Owner: NewOwnerRepositoryFake(), Pet: NewPetRepositoryFake()}
+	}},
+}
+
+var errRollback = errors.New("roll back")
+
+func TestParity(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, ctx context.Context, s *Store) []string
+		want []string
+	}{
+		{
+			name: "create and get",
+			run: func(t *testing.T, ctx context.Context, s *Store) []string {
+				a := &Owner{Email: "a@example.com", Name: "A"}
+				b := &Owner{Email: "b@example.com", Name: "B", Nickname: ptr("bee")}
+				errA, errB := s.Owner.Create(ctx, a), s.Owner.Create(ctx, b)
+				got, err := s.Owner.Get(ctx, b.ID)
+				byEmail, errByEmail := s.Owner.GetByEmail(ctx, "a@example.com")
+				return []string{outcome(errA), outcome(errB), owner(got), outcome(err), owner(byEmail), outcome(errByEmail)}
+			},
+			want: []string{"ok", "ok", "2 b@example.com B bee", "ok", "1 a@example.com A -", "ok"},
+		},
+		{
+			name: "duplicate keys",
+			run: func(t *testing.T, ctx context.Context, s *Store) []string {
+				seed(t, ctx, s)
+				email := s.Owner.Create(ctx, &Owner{Email: "a@example.com"})
+				id := s.Owner.Create(ctx, &Owner{ID: 2, Email: "new@example.com"})
+				pet := s.Pet.Create(ctx, &Pet{OwnerID: 1, Name: "Rex"})
+				again := s.Pet.Create(ctx, &Pet{OwnerID: 1, Name: "Rex", Kind: "cat"})
+				n, err := s.Owner.Count(ctx, nil)
+				return []string{outcome(email), outcome(id), outcome(pet), outcome(again), fmt.Sprint(n), outcome(err)}
+			},
+			want: []string{"duplicate key", "duplicate key", "ok", "duplicate key", "5", "ok"},
+		},
+		{
+			name: "update and delete",
+			run: func(t *testing.T, ctx context.Context, s *Store) []string {
+				seed(t, ctx, s)
+				update := s.Owner.Update(ctx, &Owner{ID: 3, Email: "c@example.com", Name: "Cecil"})
+				got, err := s.Owner.Get(ctx, 3)
+				pet := &Pet{OwnerID: 3, Name: "Tom", Kind: "cat"}
+				create := s.Pet.Create(ctx, pet)
+				pet.Age = 4
+				updatePet := s.Pet.Update(ctx, pet)
+				gotPet, errPet := s.Pet.Get(ctx, 3, "Tom")
+				deletePet := s.Pet.Delete(ctx, 3, "Tom")
+				return []string{outcome(update), owner(got), outcome(err), outcome(create), outcome(updatePet), fmt.Sprintf("%+v", gotPet), outcome(errPet), outcome(deletePet)}
+			},
+			want: []string{"ok", "3 c@example.com Cecil -", "ok", "ok", "ok", "&{OwnerID:3 Name:Tom Kind:cat Age:4}", "ok", "ok"},
+		},
+		{
+			name: "not found",
+			run: func(t *testing.T, ctx context.Context, s *Store) []string {
+				seed(t, ctx, s)
+				_, get := s.Owner.Get(ctx, 9)
+				_, byEmail := s.Owner.GetByEmail(ctx, "z@example.com")
+				update := s.Owner.Update(ctx, &Owner{ID: 9, Email: "z@example.com"})
+				del := s.Owner.Delete(ctx, 9)
+				_, pet := s.Pet.Get(ctx, 1, "Rex")
+				updatePet := s.Pet.Update(ctx, &Pet{OwnerID: 1, Name: "Rex"})
+				return []string{outcome(get), outcome(byEmail), outcome(update), outcome(del), outcome(pet), outcome(updatePet)}
+			},
+			want: []string{
+				"Owner not found by primary key [9]",
+				"Owner not found by idx_owners_email [z@example.com]",
+				"Owner not found by primary key [9]",
+				"Owner not found by primary key [9]",
+				"Pet not found by primary key [1 Rex]",
+				"Pet not found by primary key [1 Rex]",
+			},
+		},
+		{
+			name: "pages leave out NULL sort columns",
+			run: func(t *testing.T, ctx context.Context, s *Store) []string {
+				seed(t, ctx, s)
+				var pages []string
+				cursor := ""
+				for {
+					ms, next, err := s.Owner.ListPage(ctx, nil, OwnerSortByNickname, cursor, 2)
+					if err != nil {
+						return append(pages, outcome(err))
+					}
+					pages = append(pages, ids(ms))
+					if next == "" {
+						return pages
+					}
+					cursor = next
+				}
+			},
+			want: []string{"[4 2]", "[5]"},
+		},
+		{
+			name: "soft delete and restore",
+			run: func(t *testing.T, ctx context.Context, s *Store) []string {
+				seed(t, ctx, s)
+				del := s.Owner.Delete(ctx, 2)
+				_, get := s.Owner.Get(ctx, 2)
+				list, errList := s.Owner.List(ctx, nil)
+				deleted, errDeleted := s.Owner.ListDeleted(ctx, nil)
+				restore := s.Owner.Restore(ctx, 2)
+				got, errGot := s.Owner.Get(ctx, 2)
+				purge := s.Owner.Purge(ctx, 3)
+				restorePurged := s.Owner.Restore(ctx, 3)
+				return []string{outcome(del), outcome(get), ids(list), outcome(errList), ids(deleted), outcome(errDeleted), outcome(restore), owner(got), outcome(errGot), outcome(purge), outcome(restorePurged)}
+			},
+			want: []string{
+				"ok", "Owner not found by primary key [2]",
+				"[1 3 4 5]", "ok", "[2]", "ok",
+				"ok", "2 b@example.com B bee", "ok",
+				"ok", "Owner not found by primary key [3]",
+			},
+		},
+		{
+			name: "upsert",
+			run: func(t *testing.T, ctx context.Context, s *Store) []string {
+				seed(t, ctx, s)
+				kept := &Owner{Email: "a@example.com", Name: "kept", Nickname: ptr("x")}
+				doNothing := s.Owner.UpsertByEmail(ctx, kept, DoNothing())
+				a, errA := s.Owner.Get(ctx, 1)
+				renamed := &Owner{Email: "b@example.com", Name: "renamed", Nickname: ptr("x")}
+				updateOnly := s.Owner.UpsertByEmail(ctx, renamed, UpdateOnly("name"))
+				b, errB := s.Owner.Get(ctx, 2)
+				inserted := &Owner{Email: "f@example.com", Name: "F"}
+				insert := s.Owner.UpsertByEmail(ctx, inserted, DoNothing())
+				return []string{outcome(doNothing), owner(a), outcome(errA), outcome(updateOnly), fmt.Sprint(renamed.ID), owner(b), outcome(errB), outcome(insert), fmt.Sprint(inserted.ID)}
+			},
+			want: []string{"ok", "1 a@example.com A -", "ok", "ok", "2", "2 b@example.com renamed bee", "ok", "ok", "6"},
+		},
+		{
+			name: "transactions roll back to their savepoint",
+			run: func(t *testing.T, ctx context.Context, s *Store) []string {
+				outer := s.RunInTx(ctx, func(s *Store) error {
+					if err := s.Owner.Create(ctx, &Owner{Email: "a@example.com"}); err != nil {
+						return err
+					}
+					inner := s.RunInTx(ctx, func(s *Store) error {
+						if err := s.Owner.Create(ctx, &Owner{Email: "b@example.com"}); err != nil {
+							return err
+						}
+						return errRollback
+					})
+					if !errors.Is(inner, errRollback) {
+						return fmt.Errorf("inner transaction: %v", inner)
+					}
+					return s.Pet.Create(ctx, &Pet{OwnerID: 1, Name: "Rex"})
+				})
+				failed := s.RunInTx(ctx, func(s *Store) error {
+					if err := s.Owner.Create(ctx, &Owner{Email: "c@example.com"}); err != nil {
+						return err
+					}
+					return errRollback
+				})
+				owners, errOwners := s.Owner.List(ctx, nil)
+				pets, errPets := s.Pet.Count(ctx, nil)
+				var emails []string
+				for _, o := range owners {
+					emails = append(emails, o.Email)
+				}
+				return []string{outcome(outer), outcome(failed), strings.Join(emails, " "), outcome(errOwners), fmt.Sprint(pets), outcome(errPets)}
+			},
+			want: []string{"ok", "roll back", "a@example.com", "ok", "1", "ok"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, b := range backends {
+				got := tt.run(t, context.Background(), b.open(t))
+				if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+					t.Errorf("%s:\ngot  %q\nwant %q", b.name, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// seed creates the owners 1 to 5, with the nicknames -, bee, -, ant and
+// bee.
+func seed(t *testing.T, ctx context.Context, s *Store) {
+	t.Helper()
+	for i, nickname := range []*string{nil, ptr("bee"), nil, ptr("ant"), ptr("bee")} {
+		c := string(rune('a' + i))
+		if err := s.Owner.Create(ctx, &Owner{Email: c + "@example.com", Name: strings.ToUpper(c), Nickname: nickname}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
+
+func owner(o *Owner) string {
+	if o == nil {
+		return "<nil>"
+	}
+	nickname := "-"
+	if o.Nickname != nil {
+		nickname = *o.Nickname
+	}
+	return fmt.Sprintf("%d %s %s %s", o.ID, o.Email, o.Name, nickname)
+}
+
+func ids(ms []*Owner) string {
+	ids := make([]uint, len(ms))
+	for i, m := range ms {
+		ids[i] = m.ID
+	}
+	return fmt.Sprint(ids)
+}
+
+// outcome describes err in terms both backends share. The SQLite driver
+// only translates errors of type *sqlite3.Error, which go-sqlite3 does not
+// return, so constraint violations are matched here.
+func outcome(err error) string {
+	var sqliteErr sqlite3.Error
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, gorm.ErrDuplicatedKey),
+		errors.As(err, &sqliteErr) && (sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey || sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique):
+		return "duplicate key"
+	}
+	return err.Error()
+}
@@ -0,0 +1,76 @@
+// Code generated by gormaid. DO NOT EDIT.
+
+package example
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Store groups the repositories of the package, sharing one connection
+// or transaction. Its fields are interfaces, so that tests can build a
+// Store of mocks or fakes.
+type Store struct {
+	db *gorm.DB
+
+	Owner OwnerRepository
+	Pet   PetRepository
+}
+
+// NewStore returns a Store whose repositories use db.
+func NewStore(db *gorm.DB) *Store {
+	return &Store{
+		db:    db,
+		Owner: NewOwnerRepo(db),
+		Pet:   NewPetRepo(db),
+	}
+}
+
+// RunInTx calls fn with a Store whose repositories run in a transaction,
+// committed when fn returns nil and rolled back when it returns an error
+// or panics. Called on the Store of a transaction, RunInTx nests a
+// savepoint, so that a failing fn only rolls back its own writes. Each
+// repository joins the transaction through its WithTx method, so mocks
+// and fakes set in the fields are kept. A Store not returned by
+// NewStore, e.g. one of fakes, has no transaction to begin and calls fn
+// with itself. Either way, the rows of fakes are restored when fn fails.
+func (s *Store) RunInTx(ctx context.Context, fn func(*Store) error) error {
+	restore := s.snapshot()
+	committed := false
+	defer func() {
+		if !committed {
+			restore()
+		}
+	}()
+	var err error
+	if s.db == nil {
+		err = fn(s)
+	} else {
+		err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return fn(&Store{
+				db:    tx,
+				Owner: s.Owner.WithTx(tx),
+				Pet:   s.Pet.WithTx(tx),
+			})
+		})
+	}
+	committed = err == nil
+	return err
+}
+
+// snapshot saves the rows of the repositories of s held in memory, such
+// as fakes, and returns the func restoring them.
+func (s *Store) snapshot() func() {
+	var restores []func()
+	for _, repo := range []any{s.Owner, s.Pet} {
+		if r, ok := repo.(interface{ snapshot() func() }); ok {
+			restores = append(restores, r.snapshot())
+		}
+	}
+	return func() {
+		for _, restore := range restores {
+			restore()
+		}
+	}
+}
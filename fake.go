@@ -0,0 +1,1306 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// generateFakeTable writes the fakeTable type the fake repositories of the
+// output package keep their rows in, and the matching of predicates
+// against the values of a row.
+func (g *Generator) generateFakeTable() {
+	context, errors, fmt, reflect := g.use("context"), g.use("errors"), g.use("fmt"), g.use("reflect")
+	regexp, sort, strings, sync, time := g.use("regexp"), g.use("sort"), g.use("strings"), g.use("sync"), g.use("time")
+	driver, gorm, clause := g.use("database/sql/driver"), g.use("gorm.io/gorm"), g.use("gorm.io/gorm/clause")
+	g.Printf("// fakeUnique is a unique index the fake repositories enforce.\n")
+	g.Printf("type fakeUnique struct {\n")
+	g.Printf("index   string\n")
+	g.Printf("columns []string\n")
+	g.Printf("key     bool // the primary key\n")
+	g.Printf("}\n\n")
+	g.Printf("// fakeSchema describes a model to the fakeTable holding its rows.\n")
+	g.Printf("type fakeSchema[M any] struct {\n")
+	g.Printf("model         string\n")
+	g.Printf("columns       []string // every column\n")
+	g.Printf("readColumns   []string\n")
+	g.Printf("createColumns []string\n")
+	g.Printf("updateColumns []string\n")
+	g.Printf("keyColumns    []string\n")
+	g.Printf("autoUpdate    []string     // the columns gorm sets to the update time\n")
+	g.Printf("uniques       []fakeUnique // the unique indexes besides the primary key\n")
+	g.Printf("softDelete    string       // the soft delete column, \"\" when deletes are for good\n")
+	g.Printf("nullDefaults  []string     // the creatable columns defaulting to NULL\n")
+	g.Printf("// value returns the value of column in m, nil when m holds NULL.\n")
+	g.Printf("value func(m *M, column string) any\n")
+	g.Printf("// copy copies column from src to dst.\n")
+	g.Printf("copy func(dst, src *M, column string)\n")
+	g.Printf("// stamp sets the columns gorm sets to the creation time, when create is\n")
+	g.Printf("// set, and to the update time.\n")
+	g.Printf("stamp func(m *M, now %s.Time, create bool)\n", time)
+	g.Printf("// increment gives m the auto-increment key following *last when its key\n")
+	g.Printf("// is zero, and keeps *last at the greatest key. It is nil when the key\n")
+	g.Printf("// is not auto-incremented.\n")
+	g.Printf("increment func(m *M, last *int64)\n")
+	g.Printf("// delete sets the soft delete column of m to at, NULL when at is nil.\n")
+	g.Printf("delete func(m *M, at *%s.Time)\n", time)
+	g.Printf("}\n\n")
+	g.Printf("// fakeTable holds the rows of a fake repository and does the work of its\n")
+	g.Printf("// methods, which hold mu while they call it, except for page and\n")
+	g.Printf("// forEachBatch that lock mu themselves.\n")
+	g.Printf("type fakeTable[M any] struct {\n")
+	g.Printf("// Now returns the time rows are stamped with on create, update and\n")
+	g.Printf("// soft delete, %s.Now when nil.\n", time)
+	g.Printf("Now func() %s.Time\n\n", time)
+	g.Printf("schema  *fakeSchema[M]\n")
+	g.Printf("mu      %s.Mutex\n", sync)
+	g.Printf("rows    []*M\n")
+	g.Printf("lastID  int64\n")
+	g.Printf("related map[string][]any // associated values by association and key\n")
+	g.Printf("// nulls holds the columns of rows that are NULL although their field\n")
+	g.Printf("// is not nil: gorm leaves zero values out of inserts when the column\n")
+	g.Printf("// has a default, here NULL.\n")
+	g.Printf("nulls map[*M]map[string]bool\n")
+	g.Printf("}\n\n")
+	g.Printf("// clock returns the time gorm would stamp rows with.\n")
+	g.Printf("func (t *fakeTable[M]) clock() %s.Time {\n", time)
+	g.Printf("if t.Now != nil {\n")
+	g.Printf("return t.Now()\n")
+	g.Printf("}\n")
+	g.Printf("return %s.Now()\n", time)
+	g.Printf("}\n\n")
+	g.Printf("// value returns the value of column in m, nil when it is NULL.\n")
+	g.Printf("func (t *fakeTable[M]) value(m *M, column string) any {\n")
+	g.Printf("if t.nulls[m][column] {\n")
+	g.Printf("return nil\n")
+	g.Printf("}\n")
+	g.Printf("return t.schema.value(m, column)\n")
+	g.Printf("}\n\n")
+	g.Printf("// setNulls records the columns of row that are NULL.\n")
+	g.Printf("func (t *fakeTable[M]) setNulls(row *M, nulls map[string]bool) {\n")
+	g.Printf("if len(nulls) == 0 {\n")
+	g.Printf("delete(t.nulls, row)\n")
+	g.Printf("return\n")
+	g.Printf("}\n")
+	g.Printf("if t.nulls == nil {\n")
+	g.Printf("t.nulls = make(map[*M]map[string]bool)\n")
+	g.Printf("}\n")
+	g.Printf("t.nulls[row] = nulls\n")
+	g.Printf("}\n\n")
+	g.Printf("// values returns the values of columns in m.\n")
+	g.Printf("func (t *fakeTable[M]) values(m *M, columns []string) []any {\n")
+	g.Printf("values := make([]any, len(columns))\n")
+	g.Printf("for i, column := range columns {\n")
+	g.Printf("values[i] = t.value(m, column)\n")
+	g.Printf("}\n")
+	g.Printf("return values\n")
+	g.Printf("}\n\n")
+	g.Printf("// visible reports whether row is read, soft deleted rows only when deleted\n")
+	g.Printf("// is set.\n")
+	g.Printf("func (t *fakeTable[M]) visible(row *M, deleted bool) bool {\n")
+	g.Printf("return deleted || t.schema.softDelete == \"\" || sqlValue(t.value(row, t.schema.softDelete)) == nil\n")
+	g.Printf("}\n\n")
+	g.Printf("// find returns the row holding values in columns, nil when there is none.\n")
+	g.Printf("func (t *fakeTable[M]) find(columns []string, values []any, deleted bool) *M {\n")
+	g.Printf("for _, row := range t.rows {\n")
+	g.Printf("if t.visible(row, deleted) && compareRows(t.values(row, columns), values) == 0 {\n")
+	g.Printf("return row\n")
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("return nil\n")
+	g.Printf("}\n\n")
+	g.Printf("// where returns the keep function of the match method of a filter.\n")
+	g.Printf("func (t *fakeTable[M]) where(match func(value func(column string) any) bool) func(*M) bool {\n")
+	g.Printf("return func(m *M) bool {\n")
+	g.Printf("return match(func(column string) any { return t.value(m, column) })\n")
+	g.Printf("}\n")
+	g.Printf("}\n\n")
+	g.Printf("// match returns the rows keep accepts, soft deleted ones only when deleted\n")
+	g.Printf("// is set.\n")
+	g.Printf("func (t *fakeTable[M]) match(keep func(*M) bool, deleted bool) []*M {\n")
+	g.Printf("var rows []*M\n")
+	g.Printf("for _, row := range t.rows {\n")
+	g.Printf("if t.visible(row, deleted) && keep(row) {\n")
+	g.Printf("rows = append(rows, row)\n")
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("return rows\n")
+	g.Printf("}\n\n")
+	g.Printf("// clone returns a copy of the columns of row.\n")
+	g.Printf("func (t *fakeTable[M]) clone(row *M, columns []string) *M {\n")
+	g.Printf("m := new(M)\n")
+	g.Printf("for _, column := range columns {\n")
+	g.Printf("t.schema.copy(m, row, column)\n")
+	g.Printf("}\n")
+	g.Printf("return m\n")
+	g.Printf("}\n\n")
+	g.Printf("// read returns copies of the readable columns of rows, as gorm reads them.\n")
+	g.Printf("func (t *fakeTable[M]) read(rows []*M) []*M {\n")
+	g.Printf("ms := make([]*M, len(rows))\n")
+	g.Printf("for i, row := range rows {\n")
+	g.Printf("ms[i] = t.clone(row, t.schema.readColumns)\n")
+	g.Printf("}\n")
+	g.Printf("return ms\n")
+	g.Printf("}\n\n")
+	g.Printf("// conflicting returns the row other than self holding the values of row in\n")
+	g.Printf("// the columns of u, deleted or not. As in SQL, NULLs only conflict in\n")
+	g.Printf("// the primary key, which the database does not let be NULL.\n")
+	g.Printf("func (t *fakeTable[M]) conflicting(row, self *M, u fakeUnique) *M {\n")
+	g.Printf("values := t.values(row, u.columns)\n")
+	g.Printf("for _, v := range values {\n")
+	g.Printf("if !u.key && sqlValue(v) == nil {\n")
+	g.Printf("return nil\n")
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("for _, other := range t.rows {\n")
+	g.Printf("if other != self && compareRows(t.values(other, u.columns), values) == 0 {\n")
+	g.Printf("return other\n")
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("return nil\n")
+	g.Printf("}\n\n")
+	g.Printf("// conflict returns the error of the database storing row, in place of self\n")
+	g.Printf("// when it is not nil, as another row has its primary key or the values of\n")
+	g.Printf("// one of its unique indexes.\n")
+	g.Printf("func (t *fakeTable[M]) conflict(row, self *M) error {\n")
+	g.Printf("indexes := t.schema.uniques\n")
+	g.Printf("if len(t.schema.keyColumns) > 0 {\n")
+	g.Printf("indexes = append([]fakeUnique{{index: \"primary key\", columns: t.schema.keyColumns, key: true}}, indexes...)\n")
+	g.Printf("}\n")
+	g.Printf("for _, u := range indexes {\n")
+	g.Printf("if t.conflicting(row, self, u) != nil {\n")
+	g.Printf("return %s.Errorf(\"%%w: %%s %%s %%v\", %s.ErrDuplicatedKey, t.schema.model, u.index, t.values(row, u.columns))\n", fmt, gorm)
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("return nil\n")
+	g.Printf("}\n\n")
+	g.Printf("// insert stamps m as gorm does on create and stores its creatable columns,\n")
+	g.Printf("// unless another row has the same key.\n")
+	g.Printf("func (t *fakeTable[M]) insert(m *M) error {\n")
+	g.Printf("t.schema.stamp(m, t.clock(), true)\n")
+	g.Printf("if t.schema.increment != nil {\n")
+	g.Printf("t.schema.increment(m, &t.lastID)\n")
+	g.Printf("}\n")
+	g.Printf("row := t.clone(m, t.schema.createColumns)\n")
+	g.Printf("nulls := make(map[string]bool)\n")
+	g.Printf("for _, column := range t.schema.nullDefaults {\n")
+	g.Printf("if v := t.schema.value(row, column); v != nil && %s.ValueOf(v).IsZero() {\n", reflect)
+	g.Printf("nulls[column] = true\n")
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("t.setNulls(row, nulls)\n")
+	g.Printf("if err := t.conflict(row, nil); err != nil {\n")
+	g.Printf("delete(t.nulls, row)\n")
+	g.Printf("return err\n")
+	g.Printf("}\n")
+	g.Printf("t.rows = append(t.rows, row)\n")
+	g.Printf("return nil\n")
+	g.Printf("}\n\n")
+	g.Printf("// update writes columns of m to row, after stamping the update time on m\n")
+	g.Printf("// when stamp is set, unless another row then has the same key.\n")
+	g.Printf("func (t *fakeTable[M]) update(row, m *M, columns []string, stamp bool) error {\n")
+	g.Printf("if stamp {\n")
+	g.Printf("t.schema.stamp(m, t.clock(), false)\n")
+	g.Printf("columns = append(columns[:len(columns):len(columns)], t.schema.autoUpdate...)\n")
+	g.Printf("}\n")
+	g.Printf("next := t.clone(row, t.schema.columns)\n")
+	g.Printf("for _, column := range columns {\n")
+	g.Printf("t.schema.copy(next, m, column)\n")
+	g.Printf("}\n")
+	g.Printf("nulls := make(map[string]bool)\n")
+	g.Printf("for column := range t.nulls[row] {\n")
+	g.Printf("nulls[column] = true\n")
+	g.Printf("}\n")
+	g.Printf("for _, column := range columns {\n")
+	g.Printf("delete(nulls, column)\n")
+	g.Printf("}\n")
+	g.Printf("t.setNulls(next, nulls)\n")
+	g.Printf("err := t.conflict(next, row)\n")
+	g.Printf("delete(t.nulls, next)\n")
+	g.Printf("if err != nil {\n")
+	g.Printf("return err\n")
+	g.Printf("}\n")
+	g.Printf("*row = *next\n")
+	g.Printf("t.setNulls(row, nulls)\n")
+	g.Printf("return nil\n")
+	g.Printf("}\n\n")
+	g.Printf("// upsert inserts m or, when a row holds its values in the conflict target\n")
+	g.Printf("// of c, updates that row as c tells and gives m its auto-increment key,\n")
+	g.Printf("// which the database returns for the updated row.\n")
+	g.Printf("func (t *fakeTable[M]) upsert(m *M, c %s.OnConflict) error {\n", clause)
+	g.Printf("target := make([]string, len(c.Columns))\n")
+	g.Printf("for i, column := range c.Columns {\n")
+	g.Printf("target[i] = column.Name\n")
+	g.Printf("}\n")
+	g.Printf("u := fakeUnique{columns: target, key: %s.Join(target, \",\") == %[1]s.Join(t.schema.keyColumns, \",\")}\n", strings)
+	g.Printf("row := t.conflicting(t.clone(m, t.schema.createColumns), nil, u)\n")
+	g.Printf("if row == nil {\n")
+	g.Printf("return t.insert(m)\n")
+	g.Printf("}\n")
+	g.Printf("if c.DoNothing {\n")
+	g.Printf("return nil\n")
+	g.Printf("}\n")
+	g.Printf("t.schema.stamp(m, t.clock(), true)\n")
+	g.Printf("columns := make([]string, len(c.DoUpdates))\n")
+	g.Printf("for i, assignment := range c.DoUpdates {\n")
+	g.Printf("columns[i] = assignment.Column.Name\n")
+	g.Printf("}\n")
+	g.Printf("if err := t.update(row, m, columns, false); err != nil {\n")
+	g.Printf("return err\n")
+	g.Printf("}\n")
+	g.Printf("if t.schema.increment != nil {\n")
+	g.Printf("for _, column := range t.schema.keyColumns {\n")
+	g.Printf("t.schema.copy(m, row, column)\n")
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("return nil\n")
+	g.Printf("}\n\n")
+	g.Printf("// remove soft deletes rows or, for good when purge is set or the model has\n")
+	g.Printf("// no soft delete column, removes them.\n")
+	g.Printf("func (t *fakeTable[M]) remove(rows []*M, purge bool) {\n")
+	g.Printf("if t.schema.softDelete != \"\" && !purge {\n")
+	g.Printf("now := t.clock()\n")
+	g.Printf("for _, row := range rows {\n")
+	g.Printf("t.schema.delete(row, &now)\n")
+	g.Printf("}\n")
+	g.Printf("return\n")
+	g.Printf("}\n")
+	g.Printf("removed := make(map[*M]bool, len(rows))\n")
+	g.Printf("for _, row := range rows {\n")
+	g.Printf("removed[row] = true\n")
+	g.Printf("delete(t.nulls, row)\n")
+	g.Printf("}\n")
+	g.Printf("kept := make([]*M, 0, len(t.rows))\n")
+	g.Printf("for _, row := range t.rows {\n")
+	g.Printf("if !removed[row] {\n")
+	g.Printf("kept = append(kept, row)\n")
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("t.rows = kept\n")
+	g.Printf("}\n\n")
+	g.Printf("// restore brings back the soft deleted row, bumping its update time as\n")
+	g.Printf("// gorm does.\n")
+	g.Printf("func (t *fakeTable[M]) restore(row *M) {\n")
+	g.Printf("t.schema.delete(row, nil)\n")
+	g.Printf("t.schema.stamp(row, t.clock(), false)\n")
+	g.Printf("}\n\n")
+	g.Printf("// save copies the rows and returns the func putting the copy back.\n")
+	g.Printf("func (t *fakeTable[M]) save() func() {\n")
+	g.Printf("rows := make([]*M, len(t.rows))\n")
+	g.Printf("nulls := make(map[*M]map[string]bool, len(t.nulls))\n")
+	g.Printf("for i, row := range t.rows {\n")
+	g.Printf("saved := *row\n")
+	g.Printf("rows[i] = &saved\n")
+	g.Printf("if columns, ok := t.nulls[row]; ok {\n")
+	g.Printf("nulls[&saved] = make(map[string]bool, len(columns))\n")
+	g.Printf("for column := range columns {\n")
+	g.Printf("nulls[&saved][column] = true\n")
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("related := make(map[string][]any, len(t.related))\n")
+	g.Printf("for key, values := range t.related {\n")
+	g.Printf("related[key] = append([]any(nil), values...)\n")
+	g.Printf("}\n")
+	g.Printf("lastID := t.lastID\n")
+	g.Printf("return func() {\n")
+	g.Printf("t.rows, t.nulls, t.related, t.lastID = rows, nulls, related, lastID\n")
+	g.Printf("}\n")
+	g.Printf("}\n\n")
+	g.Printf("// transaction calls fn and restores the rows when it fails, as a database\n")
+	g.Printf("// rolls back a statement writing several rows.\n")
+	g.Printf("func (t *fakeTable[M]) transaction(fn func() error) error {\n")
+	g.Printf("restore := t.save()\n")
+	g.Printf("if err := fn(); err != nil {\n")
+	g.Printf("restore()\n")
+	g.Printf("return err\n")
+	g.Printf("}\n")
+	g.Printf("return nil\n")
+	g.Printf("}\n\n")
+	g.Printf("// snapshot saves the rows like save, for Store.RunInTx to restore them\n")
+	g.Printf("// when its function fails.\n")
+	g.Printf("func (t *fakeTable[M]) snapshot() func() {\n")
+	g.Printf("t.mu.Lock()\n")
+	g.Printf("defer t.mu.Unlock()\n")
+	g.Printf("restore := t.save()\n")
+	g.Printf("return func() {\n")
+	g.Printf("t.mu.Lock()\n")
+	g.Printf("defer t.mu.Unlock()\n")
+	g.Printf("restore()\n")
+	g.Printf("}\n")
+	g.Printf("}\n\n")
+	g.Printf("// sort sorts rows on orders, then by primary key as the repositories do.\n")
+	g.Printf("// Like in SQL, unknown columns are an error.\n")
+	g.Printf("func (t *fakeTable[M]) sort(rows []*M, orders []%s.OrderByColumn) error {\n", clause)
+	g.Printf("orders = append([]%s.OrderByColumn{}, orders...)\n", clause)
+	g.Printf("for _, column := range t.schema.keyColumns {\n")
+	g.Printf("orders = append(orders, %s.OrderByColumn{Column: %[1]s.Column{Name: column}})\n", clause)
+	g.Printf("}\n")
+	g.Printf("for _, order := range orders {\n")
+	g.Printf("known := false\n")
+	g.Printf("for _, column := range t.schema.columns {\n")
+	g.Printf("known = known || column == order.Column.Name\n")
+	g.Printf("}\n")
+	g.Printf("if !known {\n")
+	g.Printf("return %s.Errorf(\"%%s has no column %%q\", t.schema.model, order.Column.Name)\n", fmt)
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("%s.SliceStable(rows, func(i, j int) bool {\n", sort)
+	g.Printf("for _, order := range orders {\n")
+	g.Printf("c := compareValues(t.value(rows[i], order.Column.Name), t.value(rows[j], order.Column.Name))\n")
+	g.Printf("if order.Desc {\n")
+	g.Printf("c = -c\n")
+	g.Printf("}\n")
+	g.Printf("if c != 0 {\n")
+	g.Printf("return c < 0\n")
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("return false\n")
+	g.Printf("})\n")
+	g.Printf("return nil\n")
+	g.Printf("}\n\n")
+	g.Printf("// after returns the rows sorting after values on columns.\n")
+	g.Printf("func (t *fakeTable[M]) after(rows []*M, columns []string, values []any) []*M {\n")
+	g.Printf("var kept []*M\n")
+	g.Printf("for _, row := range rows {\n")
+	g.Printf("if compareRows(t.values(row, columns), values) > 0 {\n")
+	g.Printf("kept = append(kept, row)\n")
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("return kept\n")
+	g.Printf("}\n\n")
+	g.Printf("// list returns the rows keep accepts, sorted and paged by o.\n")
+	g.Printf("func (t *fakeTable[M]) list(keep func(*M) bool, o *queryOptions) ([]*M, error) {\n")
+	g.Printf("rows := t.match(keep, o.deleted)\n")
+	g.Printf("if err := t.sort(rows, o.orders); err != nil {\n")
+	g.Printf("return nil, err\n")
+	g.Printf("}\n")
+	g.Printf("if o.offset > len(rows) {\n")
+	g.Printf("rows = nil\n")
+	g.Printf("} else if o.offset > 0 {\n")
+	g.Printf("rows = rows[o.offset:]\n")
+	g.Printf("}\n")
+	g.Printf("if o.limit >= 0 && o.limit < len(rows) {\n")
+	g.Printf("rows = rows[:o.limit]\n")
+	g.Printf("}\n")
+	g.Printf("return t.read(rows), nil\n")
+	g.Printf("}\n\n")
+	g.Printf("// page returns up to limit rows keep accepts, sorted on columns, after the\n")
+	g.Printf("// values on columns when they are not nil. It also reports whether more\n")
+	g.Printf("// rows follow.\n")
+	g.Printf("func (t *fakeTable[M]) page(keep func(*M) bool, columns []string, values []any, limit int) ([]*M, bool) {\n")
+	g.Printf("t.mu.Lock()\n")
+	g.Printf("defer t.mu.Unlock()\n")
+	g.Printf("rows := t.match(keep, false)\n")
+	g.Printf("if values != nil {\n")
+	g.Printf("rows = t.after(rows, columns, values)\n")
+	g.Printf("}\n")
+	g.Printf("orders := make([]%s.OrderByColumn, len(columns))\n", clause)
+	g.Printf("for i, column := range columns {\n")
+	g.Printf("orders[i] = %s.OrderByColumn{Column: %[1]s.Column{Name: column}}\n", clause)
+	g.Printf("}\n")
+	g.Printf("t.sort(rows, orders)\n")
+	g.Printf("if len(rows) <= limit {\n")
+	g.Printf("return t.read(rows), false\n")
+	g.Printf("}\n")
+	g.Printf("return t.read(rows[:limit]), true\n")
+	g.Printf("}\n\n")
+	g.Printf("// forEachBatch calls fn with the rows keep accepts like the ForEachBatch\n")
+	g.Printf("// method of a repository, reading a batch at a time so that fn can write\n")
+	g.Printf("// the rows.\n")
+	g.Printf("func (t *fakeTable[M]) forEachBatch(ctx %s.Context, keep func(*M) bool, batchSize int, fn func([]*M) error) error {\n", context)
+	g.Printf("if batchSize <= 0 {\n")
+	g.Printf("return %s.Errorf(\"batch size %%d is not positive\", batchSize)\n", fmt)
+	g.Printf("}\n")
+	g.Printf("var after []any\n")
+	g.Printf("for {\n")
+	g.Printf("if err := ctx.Err(); err != nil {\n")
+	g.Printf("return err\n")
+	g.Printf("}\n")
+	g.Printf("ms, more := t.page(keep, t.schema.keyColumns, after, batchSize)\n")
+	g.Printf("if len(ms) == 0 {\n")
+	g.Printf("return nil\n")
+	g.Printf("}\n")
+	g.Printf("after = t.values(ms[len(ms)-1], t.schema.keyColumns)\n")
+	g.Printf("if err := fn(ms); err != nil {\n")
+	g.Printf("if %s.Is(err, ErrStop) {\n", errors)
+	g.Printf("return nil\n")
+	g.Printf("}\n")
+	g.Printf("return err\n")
+	g.Printf("}\n")
+	g.Printf("if !more {\n")
+	g.Printf("return nil\n")
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("}\n\n")
+	g.Printf("// associationKey returns the key of the values associated with m through\n")
+	g.Printf("// association.\n")
+	g.Printf("func (t *fakeTable[M]) associationKey(association string, m *M) string {\n")
+	g.Printf("return %s.Sprint(association, t.values(m, t.schema.keyColumns))\n", fmt)
+	g.Printf("}\n\n")
+	g.Printf("// associate associates values with m through association, skipping those\n")
+	g.Printf("// already associated.\n")
+	g.Printf("func (t *fakeTable[M]) associate(association string, m *M, values []any) {\n")
+	g.Printf("if t.related == nil {\n")
+	g.Printf("t.related = make(map[string][]any)\n")
+	g.Printf("}\n")
+	g.Printf("key := t.associationKey(association, m)\n")
+	g.Printf("for _, v := range values {\n")
+	g.Printf("if !containsDeep(t.related[key], v) {\n")
+	g.Printf("t.related[key] = append(t.related[key], v)\n")
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("}\n\n")
+	g.Printf("// dissociate removes values from those associated with m through\n")
+	g.Printf("// association.\n")
+	g.Printf("func (t *fakeTable[M]) dissociate(association string, m *M, values []any) {\n")
+	g.Printf("key := t.associationKey(association, m)\n")
+	g.Printf("var kept []any\n")
+	g.Printf("for _, v := range t.related[key] {\n")
+	g.Printf("if !containsDeep(values, v) {\n")
+	g.Printf("kept = append(kept, v)\n")
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("t.related[key] = kept\n")
+	g.Printf("}\n\n")
+	g.Printf("// associated returns the values associated with m through association.\n")
+	g.Printf("func (t *fakeTable[M]) associated(association string, m *M) []any {\n")
+	g.Printf("return t.related[t.associationKey(association, m)]\n")
+	g.Printf("}\n\n")
+	g.Printf("// containsDeep reports whether values holds a value deeply equal to v.\n")
+	g.Printf("func containsDeep(values []any, v any) bool {\n")
+	g.Printf("for _, value := range values {\n")
+	g.Printf("if %s.DeepEqual(value, v) {\n", reflect)
+	g.Printf("return true\n")
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("return false\n")
+	g.Printf("}\n\n")
+	g.Printf("// sqlValue returns the value a database holds for v: nil for a nil pointer,\n")
+	g.Printf("// the value of a %s.Valuer, v otherwise.\n", driver)
+	g.Printf("func sqlValue(v any) any {\n")
+	g.Printf("for {\n")
+	g.Printf("rv := %s.ValueOf(v)\n", reflect)
+	g.Printf("if !rv.IsValid() || rv.Kind() == %s.Pointer && rv.IsNil() {\n", reflect)
+	g.Printf("return nil\n")
+	g.Printf("}\n")
+	g.Printf("if valuer, ok := v.(%s.Valuer); ok {\n", driver)
+	g.Printf("if value, err := valuer.Value(); err == nil {\n")
+	g.Printf("return value\n")
+	g.Printf("}\n")
+	g.Printf("return v\n")
+	g.Printf("}\n")
+	g.Printf("if rv.Kind() != %s.Pointer {\n", reflect)
+	g.Printf("return v\n")
+	g.Printf("}\n")
+	g.Printf("v = rv.Elem().Interface()\n")
+	g.Printf("}\n")
+	g.Printf("}\n\n")
+	g.Printf("// compareValues compares the database values of a and b, NULL sorting\n")
+	g.Printf("// first.\n")
+	g.Printf("func compareValues(a, b any) int {\n")
+	g.Printf("a, b = sqlValue(a), sqlValue(b)\n")
+	g.Printf("switch {\n")
+	g.Printf("case a == nil && b == nil:\n")
+	g.Printf("return 0\n")
+	g.Printf("case a == nil:\n")
+	g.Printf("return -1\n")
+	g.Printf("case b == nil:\n")
+	g.Printf("return 1\n")
+	g.Printf("}\n")
+	g.Printf("if ta, ok := a.(%s.Time); ok {\n", time)
+	g.Printf("if tb, ok := b.(%s.Time); ok {\n", time)
+	g.Printf("switch {\n")
+	g.Printf("case ta.Before(tb):\n")
+	g.Printf("return -1\n")
+	g.Printf("case ta.After(tb):\n")
+	g.Printf("return 1\n")
+	g.Printf("}\n")
+	g.Printf("return 0\n")
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("va, vb := %s.ValueOf(a), %[1]s.ValueOf(b)\n", reflect)
+	g.Printf("switch {\n")
+	g.Printf("case va.Kind() == %s.String && vb.Kind() == %[1]s.String:\n", reflect)
+	g.Printf("return compareOrdered(va.String(), vb.String())\n")
+	g.Printf("case va.Kind() == %s.Bool && vb.Kind() == %[1]s.Bool:\n", reflect)
+	g.Printf("switch {\n")
+	g.Printf("case va.Bool() == vb.Bool():\n")
+	g.Printf("return 0\n")
+	g.Printf("case vb.Bool():\n")
+	g.Printf("return -1\n")
+	g.Printf("}\n")
+	g.Printf("return 1\n")
+	g.Printf("case va.CanInt() && vb.CanInt():\n")
+	g.Printf("return compareOrdered(va.Int(), vb.Int())\n")
+	g.Printf("case va.CanUint() && vb.CanUint():\n")
+	g.Printf("return compareOrdered(va.Uint(), vb.Uint())\n")
+	g.Printf("case (va.CanInt() || va.CanUint() || va.CanFloat()) && (vb.CanInt() || vb.CanUint() || vb.CanFloat()):\n")
+	g.Printf("return compareOrdered(toFloat(va), toFloat(vb))\n")
+	g.Printf("}\n")
+	g.Printf("return compareOrdered(%s.Sprint(a), %[1]s.Sprint(b))\n", fmt)
+	g.Printf("}\n\n")
+	g.Printf("// compareRows compares rows of values column by column.\n")
+	g.Printf("func compareRows(a, b []any) int {\n")
+	g.Printf("for i := range a {\n")
+	g.Printf("if c := compareValues(a[i], b[i]); c != 0 {\n")
+	g.Printf("return c\n")
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("return 0\n")
+	g.Printf("}\n\n")
+	g.Printf("func compareOrdered[T int64 | uint64 | float64 | string](a, b T) int {\n")
+	g.Printf("switch {\n")
+	g.Printf("case a < b:\n")
+	g.Printf("return -1\n")
+	g.Printf("case a > b:\n")
+	g.Printf("return 1\n")
+	g.Printf("}\n")
+	g.Printf("return 0\n")
+	g.Printf("}\n\n")
+	g.Printf("func toFloat(v %s.Value) float64 {\n", reflect)
+	g.Printf("switch {\n")
+	g.Printf("case v.CanInt():\n")
+	g.Printf("return float64(v.Int())\n")
+	g.Printf("case v.CanUint():\n")
+	g.Printf("return float64(v.Uint())\n")
+	g.Printf("}\n")
+	g.Printf("return v.Float()\n")
+	g.Printf("}\n\n")
+	g.Printf("// likeMatch reports whether s matches the LIKE pattern, where %% stands for\n")
+	g.Printf("// any text and _ for any character. It is case sensitive, as in PostgreSQL.\n")
+	g.Printf("func likeMatch(s, pattern string) bool {\n")
+	g.Printf("var expr %s.Builder\n", strings)
+	g.Printf("expr.WriteString(\"^(?s:\")\n")
+	g.Printf("for _, r := range pattern {\n")
+	g.Printf("switch r {\n")
+	g.Printf("case '%%':\n")
+	g.Printf("expr.WriteString(\".*\")\n")
+	g.Printf("case '_':\n")
+	g.Printf("expr.WriteString(\".\")\n")
+	g.Printf("default:\n")
+	g.Printf("expr.WriteString(%s.QuoteMeta(string(r)))\n", regexp)
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("expr.WriteString(\")$\")\n")
+	g.Printf("ok, _ := %s.MatchString(expr.String(), s)\n", regexp)
+	g.Printf("return ok\n")
+	g.Printf("}\n\n")
+	g.Printf("// match reports whether v, the value of a column, satisfies p as the\n")
+	g.Printf("// conditions of p do in SQL, where NULL satisfies no comparison.\n")
+	g.Printf("func (p Predicate[T]) match(v any) bool {\n")
+	g.Printf("v = sqlValue(v)\n")
+	g.Printf("if p.IsNull != nil && *p.IsNull != (v == nil) {\n")
+	g.Printf("return false\n")
+	g.Printf("}\n")
+	g.Printf("if v == nil {\n")
+	g.Printf("return p.Eq == nil && p.NotEq == nil && p.In == nil && len(p.NotIn) == 0\n")
+	g.Printf("}\n")
+	g.Printf("return (p.Eq == nil || compareValues(v, *p.Eq) == 0) &&\n")
+	g.Printf("(p.NotEq == nil || compareValues(v, *p.NotEq) != 0) &&\n")
+	g.Printf("(p.In == nil || containsValue(p.In, v)) &&\n")
+	g.Printf("!containsValue(p.NotIn, v)\n")
+	g.Printf("}\n\n")
+	g.Printf("func (p OrderedPredicate[T]) match(v any) bool {\n")
+	g.Printf("if !(Predicate[T]{Eq: p.Eq, NotEq: p.NotEq, In: p.In, NotIn: p.NotIn, IsNull: p.IsNull}).match(v) {\n")
+	g.Printf("return false\n")
+	g.Printf("}\n")
+	g.Printf("if sqlValue(v) == nil {\n")
+	g.Printf("return p.Gt == nil && p.Gte == nil && p.Lt == nil && p.Lte == nil\n")
+	g.Printf("}\n")
+	g.Printf("return (p.Gt == nil || compareValues(v, *p.Gt) > 0) &&\n")
+	g.Printf("(p.Gte == nil || compareValues(v, *p.Gte) >= 0) &&\n")
+	g.Printf("(p.Lt == nil || compareValues(v, *p.Lt) < 0) &&\n")
+	g.Printf("(p.Lte == nil || compareValues(v, *p.Lte) <= 0)\n")
+	g.Printf("}\n\n")
+	g.Printf("func (p StringPredicate) match(v any) bool {\n")
+	g.Printf("if !(OrderedPredicate[string]{Eq: p.Eq, NotEq: p.NotEq, In: p.In, NotIn: p.NotIn, IsNull: p.IsNull, Gt: p.Gt, Gte: p.Gte, Lt: p.Lt, Lte: p.Lte}).match(v) {\n")
+	g.Printf("return false\n")
+	g.Printf("}\n")
+	g.Printf("if p.Like == nil {\n")
+	g.Printf("return true\n")
+	g.Printf("}\n")
+	g.Printf("s, ok := sqlValue(v).(string)\n")
+	g.Printf("return ok && likeMatch(s, *p.Like)\n")
+	g.Printf("}\n\n")
+	g.Printf("// containsValue reports whether values holds v.\n")
+	g.Printf("func containsValue[T any](values []T, v any) bool {\n")
+	g.Printf("for _, value := range values {\n")
+	g.Printf("if compareValues(v, value) == 0 {\n")
+	g.Printf("return true\n")
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("return false\n")
+	g.Printf("}\n\n")
+}
+
+// generateFake writes the <Model>RepositoryFake implementing the repository
+// interface of si in memory, on a fakeTable described by the
+// <model>FakeSchema variable, and the matching of its filter against rows.
+// Its methods mirror those of the repository, generated under the same
+// conditions.
+func (g *Generator) generateFake(si *StructInfo) {
+	name := si.StructName
+	model := g.model(name)
+	lower := lowerFirst(name)
+	fake := name + "RepositoryFake"
+	if g.existing[fake] {
+		log.Printf("warning: %s: %s is declared in another file, no fake is generated", name, fake)
+		return
+	}
+	ctx, gorm := g.use("context"), g.use("gorm.io/gorm")
+	keys := g.primaryKeys(si)
+	key := name + "Key"
+	notFound := func(index, values string) string {
+		return fmt.Sprintf("&NotFoundError{Model: %q, Index: %q, Key: %s}", name, index, values)
+	}
+	lock := func() {
+		g.Printf("r.mu.Lock()\n")
+		g.Printf("defer r.mu.Unlock()\n")
+	}
+
+	g.Printf("// %s implements %sRepository in memory, for tests that\n", fake, name)
+	g.Printf("// cannot reach a database. Like the database, it refuses rows with the\n")
+	g.Printf("// primary key or unique index values of another row, with an error\n")
+	g.Printf("// wrapping gorm.ErrDuplicatedKey as %sRepo does on a gorm.DB opened\n", name)
+	g.Printf("// with TranslateError, and returns a *NotFoundError where it does.\n")
+	g.Printf("// Unlike the database, it ignores preloads, row locks and column defaults\n")
+	g.Printf("// other than NULL, sorts NULL first and keeps associations apart from\n")
+	g.Printf("// the rows. Like matches case-sensitively, as on PostgreSQL, while\n")
+	g.Printf("// SQLite and MySQL ignore the case of letters. Set Now to control the\n")
+	g.Printf("// time rows are stamped with.\n")
+	if si.SoftDelete {
+		g.Printf("// Deleted rows are soft deleted.\n")
+	}
+	g.Printf("type %s struct {\n", fake)
+	g.Printf("fakeTable[%s]\n", model)
+	g.Printf("}\n\n")
+	g.Printf("var _ %sRepository = (*%s)(nil)\n\n", name, fake)
+	g.Printf("// New%s returns a %s without rows.\n", fake, fake)
+	g.Printf("func New%s() *%s {\n", fake, fake)
+	g.Printf("return &%s{fakeTable[%s]{schema: %sFakeSchema}}\n", fake, model, lower)
+	g.Printf("}\n\n")
+	g.generateFakeSchema(si, keys)
+	g.generateFilterMatch(si)
+
+	g.Printf("// WithTx returns r: the fake has no transactions, but Store.RunInTx\n")
+	g.Printf("// restores its rows when the function it runs fails.\n")
+	g.Printf("func (r *%s) WithTx(tx *%s.DB) %sRepository {\n", fake, gorm, name)
+	g.Printf("return r\n")
+	g.Printf("}\n\n")
+
+	g.Printf("// Create inserts m.\n")
+	g.Printf("func (r *%s) Create(ctx %s.Context, m *%s) error {\n", fake, ctx, model)
+	g.checkKeys(si, false)
+	lock()
+	g.Printf("return r.insert(m)\n")
+	g.Printf("}\n\n")
+
+	g.Printf("// CreateInBatches inserts ms, none of them when one fails.\n")
+	g.Printf("func (r *%s) CreateInBatches(ctx %s.Context, ms []*%s, batchSize int) error {\n", fake, ctx, model)
+	g.checkKeys(si, true)
+	lock()
+	g.Printf("return r.transaction(func() error {\n")
+	g.Printf("for _, m := range ms {\n")
+	g.Printf("if err := r.insert(m); err != nil {\n")
+	g.Printf("return err\n")
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("return nil\n")
+	g.Printf("})\n")
+	g.Printf("}\n\n")
+
+	if len(keys) > 0 {
+		g.Printf("// Get returns the %s with the given primary key, or a\n", name)
+		g.Printf("// *NotFoundError.\n")
+		g.Printf("func (r *%s) Get(ctx %s.Context, %s, opts ...%s) (*%s, error) {\n", fake, ctx, g.keyParams(keys), queryOption(si), model)
+		g.Printf("return r.GetByKey(ctx, %s, opts...)\n", keyLiteral(key, keys))
+		g.Printf("}\n\n")
+
+		g.Printf("// Update writes the updatable columns of m to the row with the primary\n")
+		g.Printf("// key of m, or returns a *NotFoundError.\n")
+		g.Printf("func (r *%s) Update(ctx %s.Context, m *%s) error {\n", fake, ctx, model)
+		g.checkKeys(si, false)
+		lock()
+		g.Printf("k := r.KeyOf(m)\n")
+		g.Printf("row := r.find(%sKeyColumns, k.values(), false)\n", lower)
+		g.Printf("if row == nil {\n")
+		g.Printf("return %s\n", notFound("primary key", "k.values()"))
+		g.Printf("}\n")
+		g.Printf("return r.update(row, m, %sUpdateColumns, true)\n", lower)
+		g.Printf("}\n\n")
+
+		g.Printf("// UpdateFields writes the fields set in p to the row with primary key k,\n")
+		g.Printf("// or returns a *NotFoundError. An empty p writes nothing.\n")
+		g.Printf("func (r *%s) UpdateFields(ctx %s.Context, k %s, p *%sPatch) error {\n", fake, ctx, key, name)
+		g.Printf("var m %s\n", model)
+		g.Printf("columns := p.apply(&m)\n")
+		g.Printf("if len(columns) == 0 {\n")
+		g.Printf("return nil\n")
+		g.Printf("}\n")
+		lock()
+		g.Printf("row := r.find(%sKeyColumns, k.values(), false)\n", lower)
+		g.Printf("if row == nil {\n")
+		g.Printf("return %s\n", notFound("primary key", "k.values()"))
+		g.Printf("}\n")
+		g.Printf("return r.update(row, &m, columns, true)\n")
+		g.Printf("}\n\n")
+
+		g.Printf("// Delete deletes the %s with the given primary key, or returns a\n", name)
+		g.Printf("// *NotFoundError.\n")
+		g.Printf("func (r *%s) Delete(ctx %s.Context, %s) error {\n", fake, ctx, g.keyParams(keys))
+		g.Printf("return r.DeleteByKey(ctx, %s)\n", keyLiteral(key, keys))
+		g.Printf("}\n\n")
+
+		g.Printf("// KeyOf returns the primary key of m, as %sRepo.KeyOf does.\n", name)
+		g.Printf("func (r *%s) KeyOf(m *%s) %s {\n", fake, model, key)
+		g.Printf("return (*%sRepo)(nil).KeyOf(m)\n", name)
+		g.Printf("}\n\n")
+
+		g.Printf("// GetByKey returns the %s with primary key k, or a *NotFoundError.\n", name)
+		g.Printf("func (r *%s) GetByKey(ctx %s.Context, k %s, opts ...%s) (*%s, error) {\n", fake, ctx, key, queryOption(si), model)
+		g.Printf("o := %s(opts)\n", optionsFunc(si))
+		lock()
+		g.Printf("row := r.find(%sKeyColumns, k.values(), o.deleted)\n", lower)
+		g.Printf("if row == nil {\n")
+		g.Printf("return nil, %s\n", notFound("primary key", "k.values()"))
+		g.Printf("}\n")
+		g.Printf("return r.read([]*%s{row})[0], nil\n", model)
+		g.Printf("}\n\n")
+
+		g.Printf("// GetByKeys returns the %ss with the given primary keys.\n", name)
+		g.Printf("func (r *%s) GetByKeys(ctx %s.Context, keys []%s, opts ...%s) ([]*%s, error) {\n", fake, ctx, key, queryOption(si), model)
+		g.Printf("o := %s(opts)\n", optionsFunc(si))
+		lock()
+		g.Printf("rows := r.match(func(row *%s) bool {\n", model)
+		g.Printf("for _, k := range keys {\n")
+		g.Printf("if compareRows(r.values(row, %sKeyColumns), k.values()) == 0 {\n", lower)
+		g.Printf("return true\n")
+		g.Printf("}\n")
+		g.Printf("}\n")
+		g.Printf("return false\n")
+		g.Printf("}, o.deleted)\n")
+		g.Printf("return r.read(rows), nil\n")
+		g.Printf("}\n\n")
+
+		g.Printf("// DeleteByKey deletes the %s with primary key k, or returns a\n", name)
+		g.Printf("// *NotFoundError.\n")
+		g.Printf("func (r *%s) DeleteByKey(ctx %s.Context, k %s) error {\n", fake, ctx, key)
+		lock()
+		g.Printf("row := r.find(%sKeyColumns, k.values(), false)\n", lower)
+		g.Printf("if row == nil {\n")
+		g.Printf("return %s\n", notFound("primary key", "k.values()"))
+		g.Printf("}\n")
+		g.Printf("r.remove([]*%s{row}, false)\n", model)
+		g.Printf("return nil\n")
+		g.Printf("}\n\n")
+
+		g.Printf("// ExistsByKey reports whether a %s has primary key k.\n", name)
+		g.Printf("func (r *%s) ExistsByKey(ctx %s.Context, k %s) (bool, error) {\n", fake, ctx, key)
+		lock()
+		g.Printf("return r.find(%sKeyColumns, k.values(), false) != nil, nil\n", lower)
+		g.Printf("}\n\n")
+
+		if si.SoftDelete {
+			g.Printf("// Restore brings back the soft deleted %s with the given primary key,\n", name)
+			g.Printf("// or returns a *NotFoundError.\n")
+			g.Printf("func (r *%s) Restore(ctx %s.Context, %s) error {\n", fake, ctx, g.keyParams(keys))
+			g.Printf("return r.RestoreByKey(ctx, %s)\n", keyLiteral(key, keys))
+			g.Printf("}\n\n")
+
+			g.Printf("// RestoreByKey brings back the soft deleted %s with primary key k, or\n", name)
+			g.Printf("// returns a *NotFoundError.\n")
+			g.Printf("func (r *%s) RestoreByKey(ctx %s.Context, k %s) error {\n", fake, ctx, key)
+			lock()
+			g.Printf("row := r.find(%sKeyColumns, k.values(), true)\n", lower)
+			g.Printf("if row == nil || r.visible(row, false) {\n")
+			g.Printf("return %s\n", notFound("primary key", "k.values()"))
+			g.Printf("}\n")
+			g.Printf("r.restore(row)\n")
+			g.Printf("return nil\n")
+			g.Printf("}\n\n")
+
+			g.Printf("// Purge removes for good the %s with the given primary key, deleted or\n", name)
+			g.Printf("// not, or returns a *NotFoundError.\n")
+			g.Printf("func (r *%s) Purge(ctx %s.Context, %s) error {\n", fake, ctx, g.keyParams(keys))
+			g.Printf("return r.PurgeByKey(ctx, %s)\n", keyLiteral(key, keys))
+			g.Printf("}\n\n")
+
+			g.Printf("// PurgeByKey removes for good the %s with primary key k, deleted or\n", name)
+			g.Printf("// not, or returns a *NotFoundError.\n")
+			g.Printf("func (r *%s) PurgeByKey(ctx %s.Context, k %s) error {\n", fake, ctx, key)
+			lock()
+			g.Printf("row := r.find(%sKeyColumns, k.values(), true)\n", lower)
+			g.Printf("if row == nil {\n")
+			g.Printf("return %s\n", notFound("primary key", "k.values()"))
+			g.Printf("}\n")
+			g.Printf("r.remove([]*%s{row}, true)\n", model)
+			g.Printf("return nil\n")
+			g.Printf("}\n\n")
+		}
+	}
+
+	for _, uk := range uniqueKeys(si) {
+		method := "GetBy" + uk.Name
+		columns := columnNames(si, uk.Fields)
+		for i, column := range columns {
+			columns[i] = strconv.Quote(column)
+		}
+		g.Printf("// %s returns the %s with the given %s, or a\n", method, name, strings.Join(columnNames(si, uk.Fields), " and "))
+		g.Printf("// *NotFoundError.\n")
+		g.Printf("func (r *%s) %s(ctx %s.Context, %s, opts ...%s) (*%s, error) {\n", fake, method, ctx, g.keyParams(uk.Fields), queryOption(si), model)
+		g.Printf("o := %s(opts)\n", optionsFunc(si))
+		lock()
+		g.Printf("row := r.find([]string{%s}, []any{%s}, o.deleted)\n", strings.Join(columns, ", "), keyArgs(uk.Fields))
+		g.Printf("if row == nil {\n")
+		g.Printf("return nil, %s\n", notFound(uk.Index.Name, "[]any{"+keyArgs(uk.Fields)+"}"))
+		g.Printf("}\n")
+		g.Printf("return r.read([]*%s{row})[0], nil\n", model)
+		g.Printf("}\n\n")
+	}
+
+	if len(si.PrimaryKeys) > 0 {
+		g.generateFakeUpsert(si, "", "primary key", lower+"KeyColumns", "")
+	}
+	for _, uk := range uniqueKeys(si) {
+		columns := columnNames(si, uk.Fields)
+		for i, column := range columns {
+			columns[i] = strconv.Quote(column)
+		}
+		target := "[]string{" + strings.Join(columns, ", ") + "}"
+		g.generateFakeUpsert(si, "By"+uk.Name, "unique index "+uk.Index.Name, target, uk.Index.Where)
+	}
+
+	for _, rel := range si.Relationships {
+		if rel.Kind != Many2Many {
+			continue
+		}
+		field := keyField(&FieldInfo{FieldName: rel.FieldName})
+		related := g.relatedType(rel)
+		g.Printf("// Add%s associates related with m.\n", field)
+		g.Printf("func (r *%s) Add%s(ctx %s.Context, m *%s, related ...*%s) error {\n", fake, field, ctx, model, related)
+		lock()
+		g.Printf("r.associate(%q, m, anySlice(related))\n", rel.FieldName)
+		g.Printf("return nil\n")
+		g.Printf("}\n\n")
+
+		g.Printf("// Remove%s dissociates related from m.\n", field)
+		g.Printf("func (r *%s) Remove%s(ctx %s.Context, m *%s, related ...*%s) error {\n", fake, field, ctx, model, related)
+		lock()
+		g.Printf("r.dissociate(%q, m, anySlice(related))\n", rel.FieldName)
+		g.Printf("return nil\n")
+		g.Printf("}\n\n")
+
+		g.Printf("// Replace%s makes related the only %ss associated with m.\n", field, rel.Related)
+		g.Printf("func (r *%s) Replace%s(ctx %s.Context, m *%s, related ...*%s) error {\n", fake, field, ctx, model, related)
+		lock()
+		g.Printf("r.dissociate(%q, m, r.associated(%[1]q, m))\n", rel.FieldName)
+		g.Printf("r.associate(%q, m, anySlice(related))\n", rel.FieldName)
+		g.Printf("return nil\n")
+		g.Printf("}\n\n")
+
+		g.Printf("// Count%s returns the number of %ss associated with m.\n", field, rel.Related)
+		g.Printf("func (r *%s) Count%s(ctx %s.Context, m *%s) (int64, error) {\n", fake, field, ctx, model)
+		lock()
+		g.Printf("return int64(len(r.associated(%q, m))), nil\n", rel.FieldName)
+		g.Printf("}\n\n")
+	}
+
+	g.Printf("// List returns the rows matching filter, sorted and paged by opts.\n")
+	g.Printf("func (r *%s) List(ctx %s.Context, filter *%sFilter, opts ...%s) ([]*%s, error) {\n", fake, ctx, name, queryOption(si), model)
+	g.Printf("o := %s(opts)\n", optionsFunc(si))
+	lock()
+	g.Printf("return r.list(r.where(filter.match), o)\n")
+	g.Printf("}\n\n")
+
+	if len(keys) > 0 {
+		g.generateFakeListPage(si)
+
+		g.Printf("// ForEachBatch calls fn with the rows matching filter, in primary key\n")
+		g.Printf("// order and batchSize rows at a time, like %sRepo.ForEachBatch.\n", name)
+		g.Printf("func (r *%s) ForEachBatch(ctx %s.Context, filter *%sFilter, batchSize int, fn func([]*%s) error) error {\n", fake, ctx, name, model)
+		g.Printf("return r.forEachBatch(ctx, r.where(filter.match), batchSize, fn)\n")
+		g.Printf("}\n\n")
+		g.generateEach(si, fake)
+
+		g.Printf("// GetForUpdate returns the %s with the given primary key, or a\n", name)
+		g.Printf("// *NotFoundError. The fake has no row locks: opts are ignored.\n")
+		g.Printf("func (r *%s) GetForUpdate(ctx %s.Context, %s, opts ...LockOption) (*%s, error) {\n", fake, ctx, g.keyParams(keys), model)
+		g.Printf("return r.GetByKey(ctx, %s)\n", keyLiteral(key, keys))
+		g.Printf("}\n\n")
+
+		g.Printf("// ListForUpdate returns the rows matching filter in primary key order.\n")
+		g.Printf("// The fake has no row locks: opts are ignored.\n")
+		g.Printf("func (r *%s) ListForUpdate(ctx %s.Context, filter *%sFilter, opts ...LockOption) ([]*%s, error) {\n", fake, ctx, name, model)
+		g.Printf("return r.List(ctx, filter)\n")
+		g.Printf("}\n\n")
+	}
+
+	g.Printf("// Count returns the number of rows matching filter.\n")
+	g.Printf("func (r *%s) Count(ctx %s.Context, filter *%sFilter, opts ...QueryOption) (int64, error) {\n", fake, ctx, name)
+	g.Printf("o := newQueryOptions(opts)\n")
+	lock()
+	g.Printf("return int64(len(r.match(r.where(filter.match), o.deleted))), nil\n")
+	g.Printf("}\n\n")
+
+	g.Printf("// DeleteWhere deletes the rows matching filter and returns how many\n")
+	g.Printf("// there were. Like gorm, it returns gorm.ErrMissingWhereClause when\n")
+	g.Printf("// filter sets no predicate.\n")
+	g.Printf("func (r *%s) DeleteWhere(ctx %s.Context, filter *%sFilter) (int64, error) {\n", fake, ctx, name)
+	g.Printf("if filter.empty() {\n")
+	g.Printf("return 0, %s.ErrMissingWhereClause\n", gorm)
+	g.Printf("}\n")
+	lock()
+	g.Printf("rows := r.match(r.where(filter.match), false)\n")
+	g.Printf("r.remove(rows, false)\n")
+	g.Printf("return int64(len(rows)), nil\n")
+	g.Printf("}\n\n")
+
+	if si.SoftDelete {
+		g.Printf("// ListDeleted returns the soft deleted rows matching filter, sorted and\n")
+		g.Printf("// paged by opts.\n")
+		g.Printf("func (r *%s) ListDeleted(ctx %s.Context, filter *%sFilter, opts ...%s) ([]*%s, error) {\n", fake, ctx, name, queryOption(si), model)
+		g.Printf("o := %s(opts)\n", optionsFunc(si))
+		g.Printf("o.deleted = true\n")
+		lock()
+		g.Printf("keep := r.where(filter.match)\n")
+		g.Printf("return r.list(func(m *%s) bool { return !r.visible(m, false) && keep(m) }, o)\n", model)
+		g.Printf("}\n\n")
+
+		g.Printf("// PurgeWhere removes for good the rows matching filter, deleted or not,\n")
+		g.Printf("// and returns how many there were. Like DeleteWhere, it refuses to\n")
+		g.Printf("// remove every row.\n")
+		g.Printf("func (r *%s) PurgeWhere(ctx %s.Context, filter *%sFilter) (int64, error) {\n", fake, ctx, name)
+		g.Printf("if filter.empty() {\n")
+		g.Printf("return 0, %s.ErrMissingWhereClause\n", gorm)
+		g.Printf("}\n")
+		lock()
+		g.Printf("rows := r.match(r.where(filter.match), true)\n")
+		g.Printf("r.remove(rows, true)\n")
+		g.Printf("return int64(len(rows)), nil\n")
+		g.Printf("}\n\n")
+	}
+}
+
+// generateFakeSchema writes the <model>FakeSchema variable describing si to
+// the fakeTable of its fake repository.
+func (g *Generator) generateFakeSchema(si *StructInfo, keys []*FieldInfo) {
+	name := si.StructName
+	model := g.model(name)
+	lower := lowerFirst(name)
+	time := g.use("time")
+	quote := func(columns []string) string {
+		quoted := make([]string, len(columns))
+		for i, column := range columns {
+			quoted[i] = strconv.Quote(column)
+		}
+		return "[]string{" + strings.Join(quoted, ", ") + "}"
+	}
+	fields := si.Columns(nil)
+
+	g.Printf("var %sFakeSchema = &fakeSchema[%s]{\n", lower, model)
+	g.Printf("model: %q,\n", name)
+	g.Printf("columns: %s,\n", quote(columnNames(si, fields)))
+	g.Printf("readColumns: %sReadColumns,\n", lower)
+	g.Printf("createColumns: %sCreateColumns,\n", lower)
+	g.Printf("updateColumns: %sUpdateColumns,\n", lower)
+	g.Printf("keyColumns: %sKeyColumns,\n", lower)
+	g.Printf("autoUpdate: %s,\n", quote(columnNames(si, si.Columns(isAutoUpdateTime))))
+	var uniques []string
+	for _, idx := range si.Indexes {
+		if !idx.Unique {
+			continue
+		}
+		if idx.Where != "" {
+			log.Printf("warning: %s: the fake repository does not enforce partial unique index %s", name, idx.Name)
+			continue
+		}
+		columns := make([]string, len(idx.Fields))
+		for i, f := range idx.Fields {
+			columns[i] = f.Column
+		}
+		uniques = append(uniques, fmt.Sprintf("{index: %q, columns: %s},\n", idx.Name, quote(columns)))
+	}
+	for _, fi := range si.Columns(func(fi *FieldInfo) bool { return fi.Tag.Unique && !isPrimaryKey(si, fi) }) {
+		column := si.ColumnMap[fi.FieldName]
+		uniques = append(uniques, fmt.Sprintf("{index: %q, columns: []string{%q}},\n", "uni_"+si.TableName+"_"+column, column))
+	}
+	if len(uniques) > 0 {
+		g.Printf("uniques: []fakeUnique{\n")
+		for _, u := range uniques {
+			g.Printf("%s", u)
+		}
+		g.Printf("},\n")
+	}
+	g.Printf("softDelete: %q,\n", softDeleteColumn(si))
+	nullDefaults := si.Columns(func(fi *FieldInfo) bool {
+		return fi.Permission.Create && fi.Tag.HasDefault && strings.EqualFold(fi.Tag.Default, "NULL")
+	})
+	if len(nullDefaults) > 0 {
+		g.Printf("nullDefaults: %s,\n", quote(columnNames(si, nullDefaults)))
+	}
+
+	g.Printf("value: func(m *%s, column string) any {\n", model)
+	g.Printf("switch column {\n")
+	for _, fi := range fields {
+		g.Printf("case %q:\n", si.ColumnMap[fi.FieldName])
+		if guard := nilGuard(fi, "m"); guard != "" {
+			g.Printf("if !(%s) {\n", guard)
+			g.Printf("return nil\n")
+			g.Printf("}\n")
+		}
+		g.Printf("return m.%s\n", fi.FieldName)
+	}
+	g.Printf("}\n")
+	g.Printf("return nil\n")
+	g.Printf("},\n")
+
+	g.Printf("copy: func(dst, src *%s, column string) {\n", model)
+	g.Printf("switch column {\n")
+	for _, fi := range fields {
+		g.Printf("case %q:\n", si.ColumnMap[fi.FieldName])
+		guard := nilGuard(fi, "src")
+		if guard != "" {
+			g.Printf("if %s {\n", guard)
+		}
+		g.allocParents(fi, "dst")
+		g.Printf("dst.%s = src.%[1]s\n", fi.FieldName)
+		if guard != "" {
+			g.Printf("}\n")
+		}
+	}
+	g.Printf("}\n")
+	g.Printf("},\n")
+
+	g.Printf("stamp: func(m *%s, now %s.Time, create bool) {\n", model, time)
+	for _, fi := range si.Columns(func(fi *FieldInfo) bool { return isAutoCreateTime(fi) || isAutoUpdateTime(fi) }) {
+		zero, value := g.fakeTime(fi)
+		if zero == "" {
+			log.Printf("warning: %s.%s: the fake repository cannot stamp a %s", name, fi.FieldName, g.typeOf(fi))
+			continue
+		}
+		cond := "create && " + zero
+		if isAutoUpdateTime(fi) {
+			cond = "!create || " + zero
+		}
+		g.allocParents(fi, "m")
+		g.Printf("if %s {\n", cond)
+		g.Printf("m.%s = %s\n", fi.FieldName, value)
+		g.Printf("}\n")
+	}
+	g.Printf("},\n")
+
+	if len(keys) == 1 && isAutoIncrement(keys[0]) && nilGuard(keys[0], "m") == "" {
+		fi := keys[0]
+		g.Printf("increment: func(m *%s, last *int64) {\n", model)
+		g.Printf("if m.%s == 0 {\n", fi.FieldName)
+		g.Printf("*last++\n")
+		g.Printf("m.%s = %s(*last)\n", fi.FieldName, g.typeOf(fi))
+		g.Printf("} else if int64(m.%s) > *last {\n", fi.FieldName)
+		g.Printf("*last = int64(m.%s)\n", fi.FieldName)
+		g.Printf("}\n")
+		g.Printf("},\n")
+	}
+
+	for _, fi := range si.Columns(isSoftDelete) {
+		typ := strings.TrimPrefix(g.typeOf(fi), "*")
+		null, value := typ+"{}", typ+"{Time: *at, Valid: true}"
+		if fi.Type != nil && fi.Type.Pointer || strings.HasPrefix(fi.FieldType, "*") {
+			null, value = "nil", "&"+value
+		}
+		g.Printf("delete: func(m *%s, at *%s.Time) {\n", model, time)
+		g.allocParents(fi, "m")
+		g.Printf("if at == nil {\n")
+		g.Printf("m.%s = %s\n", fi.FieldName, null)
+		g.Printf("return\n")
+		g.Printf("}\n")
+		g.Printf("m.%s = %s\n", fi.FieldName, value)
+		g.Printf("},\n")
+		break
+	}
+	g.Printf("}\n\n")
+}
+
+// fakeTime returns the condition under which the auto time field fi of m is
+// zero, and the value gorm stamps it with at now, or "" for types gorm does
+// not stamp.
+func (g *Generator) fakeTime(fi *FieldInfo) (zero, value string) {
+	if fi.Type == nil {
+		return "", ""
+	}
+	field := "m." + fi.FieldName
+	if fi.Type.qualifiedName() == "time.Time" {
+		if fi.Type.Pointer {
+			return field + " == nil", "&now"
+		}
+		return field + ".IsZero()", "now"
+	}
+	if fi.Type.Pointer {
+		return "", ""
+	}
+	switch fi.Type.Kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		at := fi.Tag.AutoUpdateTime
+		if isAutoCreateTime(fi) {
+			at = fi.Tag.AutoCreateTime
+		}
+		unix := "now.Unix()"
+		switch at {
+		case AutoTimeMilli:
+			unix = "now.UnixMilli()"
+		case AutoTimeNano:
+			unix = "now.UnixNano()"
+		}
+		return field + " == 0", g.typeOf(fi) + "(" + unix + ")"
+	}
+	return "", ""
+}
+
+// isAutoIncrement reports whether the database assigns the primary key fi,
+// as it does for integer keys unless told otherwise.
+func isAutoIncrement(fi *FieldInfo) bool {
+	if fi.Type == nil || fi.Type.Pointer || strings.EqualFold(fi.Tag.Settings["AUTOINCREMENT"], "false") {
+		return false
+	}
+	switch fi.Type.Kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// generateFilterMatch writes the match and empty methods of the filter of
+// si, which test rows in memory.
+func (g *Generator) generateFilterMatch(si *StructInfo) {
+	name := si.StructName + "Filter"
+	fields := g.filterFields(si)
+
+	g.Printf("// match reports whether the row whose columns value returns satisfies\n")
+	g.Printf("// the predicates of f, every row when f is nil.\n")
+	g.Printf("func (f *%s) match(value func(column string) any) bool {\n", name)
+	g.Printf("if f == nil {\n")
+	g.Printf("return true\n")
+	g.Printf("}\n")
+	for _, fi := range fields {
+		g.Printf("if !f.%s.match(value(%q)) {\n", keyField(fi), si.ColumnMap[fi.FieldName])
+		g.Printf("return false\n")
+		g.Printf("}\n")
+	}
+	g.Printf("return true\n")
+	g.Printf("}\n\n")
+
+	g.Printf("// empty reports whether f adds no condition, which the delete methods\n")
+	g.Printf("// refuse.\n")
+	g.Printf("func (f *%s) empty() bool {\n", name)
+	g.Printf("if f == nil {\n")
+	g.Printf("return true\n")
+	g.Printf("}\n")
+	g.Printf("n := 0\n")
+	for _, fi := range fields {
+		g.Printf("n += len(f.%s.conditions(%q))\n", keyField(fi), si.ColumnMap[fi.FieldName])
+	}
+	g.Printf("return n == 0\n")
+	g.Printf("}\n\n")
+}
+
+// generateFakeUpsert writes the fake counterparts of the upsert methods
+// generateUpsert writes.
+func (g *Generator) generateFakeUpsert(si *StructInfo, suffix, key, target, where string) {
+	name := si.StructName
+	model := g.model(name)
+	lower := lowerFirst(name)
+	fake := name + "RepositoryFake"
+	ctx := g.use("context")
+
+	g.Printf("// Upsert%s inserts m or, when a row has the same %s,\n", suffix, key)
+	g.Printf("// updates that row as opts tell.\n")
+	g.Printf("func (r *%s) Upsert%s(ctx %s.Context, m *%s, opts ...UpsertOption) error {\n", fake, suffix, ctx, model)
+	g.checkKeys(si, false)
+	g.Printf("c, err := onConflict(%s, %q, %sUpdateColumns, opts)\n", target, where, lower)
+	g.Printf("if err != nil {\n")
+	g.Printf("return err\n")
+	g.Printf("}\n")
+	g.Printf("r.mu.Lock()\n")
+	g.Printf("defer r.mu.Unlock()\n")
+	g.Printf("return r.upsert(m, c)\n")
+	g.Printf("}\n\n")
+
+	g.Printf("// UpsertBatch%s upserts ms like Upsert%s, none of them when one\n", suffix, suffix)
+	g.Printf("// fails.\n")
+	g.Printf("func (r *%s) UpsertBatch%s(ctx %s.Context, ms []*%s, batchSize int, opts ...UpsertOption) error {\n", fake, suffix, ctx, model)
+	g.checkKeys(si, true)
+	g.Printf("c, err := onConflict(%s, %q, %sUpdateColumns, opts)\n", target, where, lower)
+	g.Printf("if err != nil {\n")
+	g.Printf("return err\n")
+	g.Printf("}\n")
+	g.Printf("r.mu.Lock()\n")
+	g.Printf("defer r.mu.Unlock()\n")
+	g.Printf("return r.transaction(func() error {\n")
+	g.Printf("for _, m := range ms {\n")
+	g.Printf("if err := r.upsert(m, c); err != nil {\n")
+	g.Printf("return err\n")
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("return nil\n")
+	g.Printf("})\n")
+	g.Printf("}\n\n")
+}
+
+// generateFakeListPage writes the ListPage method of the fake repository of
+// si, reading the cursors ListPage of the repository issues.
+func (g *Generator) generateFakeListPage(si *StructInfo) {
+	name := si.StructName
+	model := g.model(name)
+	fake, sort := name+"RepositoryFake", name+"Sort"
+	ctx, fmt := g.use("context"), g.use("fmt")
+
+	g.Printf("// ListPage returns up to limit rows matching filter, sorted by sort then\n")
+	g.Printf("// primary key, after the page the cursor was returned with, and the\n")
+	g.Printf("// cursor of the next page, like %sRepo.ListPage. Rows whose sort\n", name)
+	g.Printf("// column is NULL are left out.\n")
+	g.Printf("func (r *%s) ListPage(ctx %s.Context, filter *%sFilter, sort %s, cursor string, limit int) ([]*%s, string, error) {\n", fake, ctx, name, sort, model)
+	g.Printf("if limit <= 0 {\n")
+	g.Printf("return nil, \"\", %s.Errorf(\"page limit %%d is not positive\", limit)\n", fmt)
+	g.Printf("}\n")
+	g.Printf("columns, err := sort.columns()\n")
+	g.Printf("if err != nil {\n")
+	g.Printf("return nil, \"\", err\n")
+	g.Printf("}\n")
+	g.Printf("var values []any\n")
+	g.Printf("if cursor != \"\" {\n")
+	g.Printf("var k %sKey\n", name)
+	g.Printf("raw, err := decodeCursor(cursor, string(sort), &k)\n")
+	g.Printf("if err != nil {\n")
+	g.Printf("return nil, \"\", err\n")
+	g.Printf("}\n")
+	g.Printf("values = k.values()\n")
+	g.Printf("if sort != %sByKey {\n", sort)
+	g.Printf("v, err := sort.decode(raw)\n")
+	g.Printf("if err != nil {\n")
+	g.Printf("return nil, \"\", ErrInvalidCursor\n")
+	g.Printf("}\n")
+	g.Printf("values = append([]any{v}, values...)\n")
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("keep := r.where(filter.match)\n")
+	g.Printf("if sort != %sByKey {\n", sort)
+	g.Printf("keep = func(m *%s) bool {\n", model)
+	g.Printf("return sqlValue(r.value(m, string(sort))) != nil && r.where(filter.match)(m)\n")
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("ms, more := r.page(keep, columns, values, limit)\n")
+	g.Printf("if !more {\n")
+	g.Printf("return ms, \"\", nil\n")
+	g.Printf("}\n")
+	g.Printf("last := ms[limit-1]\n")
+	g.Printf("next, err := encodeCursor(string(sort), sort.value(last), r.KeyOf(last))\n")
+	g.Printf("if err != nil {\n")
+	g.Printf("return nil, \"\", err\n")
+	g.Printf("}\n")
+	g.Printf("return ms, next, nil\n")
+	g.Printf("}\n\n")
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"go/types"
+	"reflect"
+)
+
+// generatePredicates writes the predicate types the filters of every model
+// share, one per family of column types.
+func (g *Generator) generatePredicates() {
+	clause := g.use("gorm.io/gorm/clause")
+	g.Printf("// Ptr returns a pointer to v, to fill the Eq and range fields of predicates.\n")
+	g.Printf("func Ptr[T any](v T) *T {\n")
+	g.Printf("return &v\n")
+	g.Printf("}\n\n")
+
+	g.Printf("// Predicate tests the value of a column. Every field that is set must\n")
+	g.Printf("// hold; the zero Predicate matches every row. A nil In matches every row\n")
+	g.Printf("// while an empty one matches none.\n")
+	g.Printf("type Predicate[T any] struct {\n")
+	g.Printf("Eq     *T\n")
+	g.Printf("NotEq  *T\n")
+	g.Printf("In     []T\n")
+	g.Printf("NotIn  []T\n")
+	g.Printf("IsNull *bool // false for IS NOT NULL\n")
+	g.Printf("}\n\n")
+
+	g.Printf("func (p Predicate[T]) conditions(column string) []%s.Expression {\n", clause)
+	g.Printf("col := %s.Column{Table: %[1]s.CurrentTable, Name: column}\n", clause)
+	g.Printf("var conds []%s.Expression\n", clause)
+	g.Printf("if p.Eq != nil {\n")
+	g.Printf("conds = append(conds, %s.Eq{Column: col, Value: *p.Eq})\n", clause)
+	g.Printf("}\n")
+	g.Printf("if p.NotEq != nil {\n")
+	g.Printf("conds = append(conds, %s.Neq{Column: col, Value: *p.NotEq})\n", clause)
+	g.Printf("}\n")
+	g.Printf("if p.In != nil {\n")
+	g.Printf("conds = append(conds, %s.IN{Column: col, Values: anySlice(p.In)})\n", clause)
+	g.Printf("}\n")
+	g.Printf("if len(p.NotIn) > 0 {\n")
+	g.Printf("conds = append(conds, %s.Not(%[1]s.IN{Column: col, Values: anySlice(p.NotIn)}))\n", clause)
+	g.Printf("}\n")
+	g.Printf("if p.IsNull != nil && *p.IsNull {\n")
+	g.Printf("conds = append(conds, %s.Eq{Column: col, Value: nil})\n", clause)
+	g.Printf("} else if p.IsNull != nil {\n")
+	g.Printf("conds = append(conds, %s.Neq{Column: col, Value: nil})\n", clause)
+	g.Printf("}\n")
+	g.Printf("return conds\n")
+	g.Printf("}\n\n")
+
+	g.Printf("// OrderedPredicate is a Predicate on a column whose values are ordered,\n")
+	g.Printf("// such as numbers and times.\n")
+	g.Printf("type OrderedPredicate[T any] struct {\n")
+	g.Printf("Eq     *T\n")
+	g.Printf("NotEq  *T\n")
+	g.Printf("In     []T\n")
+	g.Printf("NotIn  []T\n")
+	g.Printf("IsNull *bool // false for IS NOT NULL\n")
+	g.Printf("Gt     *T\n")
+	g.Printf("Gte    *T\n")
+	g.Printf("Lt     *T\n")
+	g.Printf("Lte    *T\n")
+	g.Printf("}\n\n")
+
+	g.Printf("func (p OrderedPredicate[T]) conditions(column string) []%s.Expression {\n", clause)
+	g.Printf("conds := Predicate[T]{Eq: p.Eq, NotEq: p.NotEq, In: p.In, NotIn: p.NotIn, IsNull: p.IsNull}.conditions(column)\n")
+	g.Printf("col := %s.Column{Table: %[1]s.CurrentTable, Name: column}\n", clause)
+	for _, op := range []string{"Gt", "Gte", "Lt", "Lte"} {
+		g.Printf("if p.%s != nil {\n", op)
+		g.Printf("conds = append(conds, %s.%s{Column: col, Value: *p.%[2]s})\n", clause, op)
+		g.Printf("}\n")
+	}
+	g.Printf("return conds\n")
+	g.Printf("}\n\n")
+
+	g.Printf("// StringPredicate is an OrderedPredicate on a text column that can also\n")
+	g.Printf("// be matched against a LIKE pattern.\n")
+	g.Printf("type StringPredicate struct {\n")
+	g.Printf("Eq     *string\n")
+	g.Printf("NotEq  *string\n")
+	g.Printf("In     []string\n")
+	g.Printf("NotIn  []string\n")
+	g.Printf("IsNull *bool // false for IS NOT NULL\n")
+	g.Printf("Gt     *string\n")
+	g.Printf("Gte    *string\n")
+	g.Printf("Lt     *string\n")
+	g.Printf("Lte    *string\n")
+	g.Printf("Like   *string\n")
+	g.Printf("}\n\n")
+
+	g.Printf("func (p StringPredicate) conditions(column string) []%s.Expression {\n", clause)
+	g.Printf("conds := OrderedPredicate[string]{Eq: p.Eq, NotEq: p.NotEq, In: p.In, NotIn: p.NotIn, IsNull: p.IsNull, Gt: p.Gt, Gte: p.Gte, Lt: p.Lt, Lte: p.Lte}.conditions(column)\n")
+	g.Printf("if p.Like != nil {\n")
+	g.Printf("conds = append(conds, %s.Like{Column: %[1]s.Column{Table: %[1]s.CurrentTable, Name: column}, Value: *p.Like})\n", clause)
+	g.Printf("}\n")
+	g.Printf("return conds\n")
+	g.Printf("}\n\n")
+
+	g.Printf("func anySlice[T any](values []T) []any {\n")
+	g.Printf("s := make([]any, len(values))\n")
+	g.Printf("for i, v := range values {\n")
+	g.Printf("s[i] = v\n")
+	g.Printf("}\n")
+	g.Printf("return s\n")
+	g.Printf("}\n\n")
+}
+
+// generateFilter writes the <Model>Filter struct with a predicate for every
+// column that can be compared, named after the Go field and testing its
+// column, e.g. SourcePositionHouseID for src_house_id.
+func (g *Generator) generateFilter(si *StructInfo) {
+	name := si.StructName + "Filter"
+	gorm, clause := g.use("gorm.io/gorm"), g.use("gorm.io/gorm/clause")
+	fields := g.filterFields(si)
+
+	g.Printf("// %s selects %s rows: the predicates that are set must all hold.\n", name, si.StructName)
+	g.Printf("type %s struct {\n", name)
+	for _, fi := range fields {
+		g.Printf("%s %s // %s\n", keyField(fi), g.predicate(fi), si.ColumnMap[fi.FieldName])
+	}
+	g.Printf("}\n\n")
+
+	g.Printf("// apply adds the conditions of f to db. A nil f adds none.\n")
+	g.Printf("func (f *%s) apply(db *%s.DB) *%[2]s.DB {\n", name, gorm)
+	g.Printf("if f == nil {\n")
+	g.Printf("return db\n")
+	g.Printf("}\n")
+	g.Printf("var conds []%s.Expression\n", clause)
+	for _, fi := range fields {
+		g.Printf("conds = append(conds, f.%s.conditions(%q)...)\n", keyField(fi), si.ColumnMap[fi.FieldName])
+	}
+	g.Printf("if len(conds) == 0 {\n")
+	g.Printf("return db\n")
+	g.Printf("}\n")
+	g.Printf("return db.Where(%s.And(conds...))\n", clause)
+	g.Printf("}\n\n")
+}
+
+// filterFields returns the fields of si the filter of si has a predicate
+// for.
+func (g *Generator) filterFields(si *StructInfo) []*FieldInfo {
+	return si.Columns(func(fi *FieldInfo) bool { return fi.Permission.Read && g.predicate(fi) != "" })
+}
+
+// predicate returns the predicate type testing the column of fi, or "" when
+// the column holds composite values, such as serialized slices, that SQL
+// cannot compare.
+func (g *Generator) predicate(fi *FieldInfo) string {
+	if fi.Tag.Serializer != "" || fi.Type == nil {
+		return ""
+	}
+	ti := fi.Type
+	t := ti.typ
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	typ := g.typeString(t)
+	switch {
+	case ti.qualifiedName() == "time.Time":
+		return "OrderedPredicate[" + typ + "]"
+	case ti.Valuer || ti.Scanner:
+		return "Predicate[" + typ + "]"
+	}
+	switch ti.Kind {
+	case reflect.String:
+		if typ == "string" {
+			return "StringPredicate"
+		}
+		return "OrderedPredicate[" + typ + "]"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "OrderedPredicate[" + typ + "]"
+	case reflect.Bool:
+		return "Predicate[" + typ + "]"
+	}
+	return ""
+}
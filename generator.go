@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Generator holds the state of one output file.
+type Generator struct {
+	pkg     *Package // the package declaring the models
+	outPkg  string   // name of the package the file is generated into
+	outFile string   // absolute path of the output file
+	samePkg bool     // whether the output file lives in pkg itself
+	imports map[string]string
+	enums   map[string]bool // enums whose methods are generated
+	// enumText makes enums implement encoding.TextMarshaler and
+	// TextUnmarshaler with their text form.
+	enumText bool
+	// existing holds the package level names the other files of the output
+	// package declare.
+	existing map[string]bool
+	buf      bytes.Buffer
+}
+
+// NewGenerator returns a generator writing package outPkg to outFile. When
+// outFile is part of pkg model types are not qualified.
+func NewGenerator(pkg *Package, outPkg, outFile string) *Generator {
+	return &Generator{
+		pkg:      pkg,
+		outPkg:   outPkg,
+		outFile:  outFile,
+		samePkg:  filepath.Dir(outFile) == pkg.Dir,
+		imports:  make(map[string]string),
+		enums:    make(map[string]bool),
+		existing: declaredNames(filepath.Dir(outFile), outFile),
+	}
+}
+
+// declaredNames returns the package level names declared by the Go files of
+// dir other than skip. Unreadable files are ignored: the names only serve to
+// avoid redeclaring the shared helpers of another generated file.
+func declaredNames(dir, skip string) map[string]bool {
+	names := make(map[string]bool)
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	fset := token.NewFileSet()
+	for _, path := range paths {
+		if path == skip || strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Recv == nil {
+					names[decl.Name.Name] = true
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						names[spec.Name.Name] = true
+					case *ast.ValueSpec:
+						for _, name := range spec.Names {
+							names[name.Name] = true
+						}
+					}
+				}
+			}
+		}
+	}
+	return names
+}
+
+// Printf writes to the body of the generated file.
+func (g *Generator) Printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// use records that the generated code refers to the package at path and
+// returns the name to qualify it with.
+func (g *Generator) use(path string) string {
+	if name, ok := g.imports[path]; ok {
+		return name
+	}
+	name := path[strings.LastIndex(path, "/")+1:]
+	g.imports[path] = name
+	return name
+}
+
+// typeOf returns the expression naming the type of fi in the output file.
+func (g *Generator) typeOf(fi *FieldInfo) string {
+	if fi.Type == nil || fi.Type.typ == nil {
+		return fi.FieldType
+	}
+	return g.typeString(fi.Type.typ)
+}
+
+// typeString returns the expression naming t in the output file.
+func (g *Generator) typeString(t types.Type) string {
+	return types.TypeString(t, func(other *types.Package) string {
+		if other == g.pkg.Types && g.samePkg {
+			return ""
+		}
+		if _, ok := g.imports[other.Path()]; !ok {
+			g.imports[other.Path()] = other.Name()
+		}
+		return g.imports[other.Path()]
+	})
+}
+
+// model returns the expression naming the model type structName.
+func (g *Generator) model(structName string) string {
+	if g.samePkg {
+		return structName
+	}
+	g.imports[g.pkg.Path] = g.pkg.Name
+	return g.pkg.Name + "." + structName
+}
+
+// Generate writes the code for every model, after the helpers they share
+// unless another file of the output package already declares them.
+func (g *Generator) Generate(models []*StructInfo) {
+	if !g.existing["QueryOption"] {
+		g.generateQueryOptions()
+	}
+	if !g.existing["keysIn"] {
+		g.generateKeysIn()
+	}
+	if !g.existing["ErrNilKey"] {
+		g.generateNilKey()
+	}
+	if !g.existing["NotFoundError"] {
+		g.generateNotFoundError()
+	}
+	if !g.existing["Predicate"] {
+		g.generatePredicates()
+	}
+	if !g.existing["UpsertOption"] {
+		g.generateUpsertOptions()
+	}
+	if !g.existing["ErrInvalidCursor"] {
+		g.generateCursors()
+	}
+	if !g.existing["ErrStop"] {
+		g.generateStop()
+	}
+	if !g.existing["LockOption"] {
+		g.generateLockOptions()
+	}
+	if !g.existing["Field"] {
+		g.generateField()
+	}
+	if !g.existing["fakeTable"] {
+		g.generateFakeTable()
+	}
+	g.generatePreloadOptions(models)
+	for _, si := range models {
+		g.generateModel(si)
+	}
+}
+
+func (g *Generator) generateModel(si *StructInfo) {
+	g.generateColumns(si)
+	g.generateDescriptor(si)
+	g.generateFilter(si)
+	g.generatePreloads(si)
+	start := g.buf.Len()
+	g.generateRepository(si)
+	g.generateMock(si, g.buf.Bytes()[start:])
+	g.generateFake(si)
+	for _, fi := range si.Columns(func(fi *FieldInfo) bool { return fi.Enum != nil }) {
+		for _, problem := range fi.Enum.Problems {
+			log.Printf("warning: %s.%s: %s", si.StructName, fi.FieldName, problem)
+		}
+		g.generateEnum(fi.Enum)
+	}
+}
+
+// generateColumns writes the columns the generated code may read, create
+// and update, leaving out those the permission tags protect. Updates never
+// touch the primary key, the creation time nor the soft delete column.
+// Reads select every column as gorm does, which only scans the readable
+// ones, since columns left out of migrations may be missing from the table.
+func (g *Generator) generateColumns(si *StructInfo) {
+	name := lowerFirst(si.StructName)
+	list := func(fields []*FieldInfo) string {
+		columns := make([]string, len(fields))
+		for i, fi := range fields {
+			columns[i] = strconv.Quote(si.ColumnMap[fi.FieldName])
+		}
+		return strings.Join(columns, ", ")
+	}
+	g.Printf("// Columns of table %q that generated code reads, creates and updates.\n", si.TableName)
+	g.Printf("var (\n")
+	g.Printf("%sReadColumns = []string{%s}\n", name, list(si.Columns(func(fi *FieldInfo) bool { return fi.Permission.Read })))
+	g.Printf("%sCreateColumns = []string{%s}\n", name, list(si.Columns(func(fi *FieldInfo) bool { return fi.Permission.Create })))
+	g.Printf("%sUpdateColumns = []string{%s}\n", name, list(updateFields(si)))
+	g.Printf("%sKeyColumns = []string{%s}\n", name, list(si.Columns(func(fi *FieldInfo) bool { return isPrimaryKey(si, fi) })))
+	g.Printf(")\n\n")
+}
+
+// updateFields returns the fields of si Update writes.
+func updateFields(si *StructInfo) []*FieldInfo {
+	return si.Columns(func(fi *FieldInfo) bool {
+		return fi.Permission.Update && !isPrimaryKey(si, fi) && !isAutoCreateTime(fi) && !isSoftDelete(fi)
+	})
+}
+
+func isPrimaryKey(si *StructInfo, fi *FieldInfo) bool {
+	for _, name := range si.PrimaryKeys {
+		if name == fi.FieldName {
+			return true
+		}
+	}
+	return false
+}
+
+// isAutoCreateTime reports whether gorm sets fi to the creation time, as it
+// does for CreatedAt unless told otherwise.
+func isAutoCreateTime(fi *FieldInfo) bool {
+	at := fi.Tag.AutoCreateTime
+	return at > AutoTimeOff || at == AutoTimeUnset && fi.Name() == "CreatedAt"
+}
+
+// isAutoUpdateTime reports whether gorm sets fi to the update time, as it
+// does for UpdatedAt unless told otherwise.
+func isAutoUpdateTime(fi *FieldInfo) bool {
+	at := fi.Tag.AutoUpdateTime
+	return at > AutoTimeOff || at == AutoTimeUnset && fi.Name() == "UpdatedAt"
+}
+
+// paramName returns the name of the parameter or variable holding the value
+// of fi, e.g. sourcePositionHouseID. It never clashes with Go keywords nor
+// with the names the generated method bodies use.
+func paramName(fi *FieldInfo) string {
+	name := lowerFirst(strings.ReplaceAll(fi.FieldName, ".", ""))
+	if token.IsKeyword(name) || reservedNames[name] {
+		name += "_"
+	}
+	return name
+}
+
+// reservedNames are the identifiers of generated method bodies.
+var reservedNames = map[string]bool{"ctx": true, "r": true, "m": true, "ms": true, "db": true, "res": true, "err": true, "opts": true, "o": true, "k": true}
+
+// lowerFirst turns an exported identifier into an unexported one, keeping
+// initialisms together: IdentifiedGenotypes becomes identifiedGenotypes and
+// HTTPServer httpServer.
+func lowerFirst(name string) string {
+	runes := []rune(name)
+	for i := range runes {
+		if !unicode.IsUpper(runes[i]) {
+			break
+		}
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// Source returns the gofmt-ed content of the generated file. When the code
+// is not valid Go syntax, the unformatted source is returned alongside the
+// error to ease debugging. Type errors are left to the compiler.
+func (g *Generator) Source() ([]byte, error) {
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by gormaid. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", g.outPkg)
+	if len(g.imports) > 0 {
+		paths := make([]string, 0, len(g.imports))
+		for path := range g.imports {
+			paths = append(paths, path)
+		}
+		// standard packages first, as goimports groups them
+		std := func(path string) bool { return !strings.Contains(strings.Split(path, "/")[0], ".") }
+		sort.Slice(paths, func(i, j int) bool {
+			if std(paths[i]) != std(paths[j]) {
+				return std(paths[i])
+			}
+			return paths[i] < paths[j]
+		})
+		fmt.Fprintf(&out, "import (\n")
+		for i, path := range paths {
+			if i > 0 && std(paths[i-1]) != std(path) {
+				fmt.Fprintf(&out, "\n")
+			}
+			if name := g.imports[path]; name != path[strings.LastIndex(path, "/")+1:] {
+				fmt.Fprintf(&out, "\t%s %s\n", name, strconv.Quote(path))
+			} else {
+				fmt.Fprintf(&out, "\t%s\n", strconv.Quote(path))
+			}
+		}
+		fmt.Fprintf(&out, ")\n\n")
+	}
+	out.Write(g.buf.Bytes())
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		log.Printf("warning: internal error: invalid Go generated: %s", err)
+		return out.Bytes(), err
+	}
+	return src, nil
+}
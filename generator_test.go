@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestGenerateExample regenerates the files of the example package and
+// compares them with those checked in, which the tests of example compile
+// and run on SQLite. Run go generate ./example after changing the output.
+func TestGenerateExample(t *testing.T) {
+	pkg, err := LoadPackage("example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var models []*StructInfo
+	for _, name := range []string{"Owner", "Pet"} {
+		si, err := pkg.ParseStruct(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		models = append(models, si)
+	}
+
+	crud := NewGenerator(pkg, pkg.Name, filepath.Join(pkg.Dir, "crud.go"))
+	crud.Generate(models)
+	store := NewGenerator(pkg, pkg.Name, filepath.Join(pkg.Dir, "store_crud.go"))
+	if !store.GenerateStore() {
+		t.Fatal("GenerateStore found no repository")
+	}
+	for _, g := range []*Generator{crud, store} {
+		src, err := g.Source()
+		if err != nil {
+			t.Fatalf("%s: %v", g.outFile, err)
+		}
+		want, err := os.ReadFile(g.outFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(src, want) {
+			t.Errorf("%s is out of date, run go generate ./example", filepath.Base(g.outFile))
+		}
+	}
+}
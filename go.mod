@@ -2,9 +2,11 @@ module github.com/nathanusask/gormaid
 
 go 1.19
 
-require gorm.io/gorm v1.25.4
-
 require (
-	github.com/jinzhu/inflection v1.0.0 // indirect
-	github.com/jinzhu/now v1.1.5 // indirect
+	github.com/jinzhu/inflection v1.0.0
+	github.com/mattn/go-sqlite3 v1.14.15
+	gorm.io/driver/sqlite v1.5.0
+	gorm.io/gorm v1.25.4
 )
+
+require github.com/jinzhu/now v1.1.5 // indirect
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Index is an index of a model table, merged from the index and
+// uniqueIndex tags of its fields the way gorm's schema.ParseIndexes does.
+type Index struct {
+	Name    string
+	Unique  bool
+	Class   string // UNIQUE | FULLTEXT | SPATIAL
+	Type    string // btree, hash, gist, spgist, gin, and brin
+	Where   string
+	Option  string
+	Comment string
+	// Fields are ordered by priority.
+	Fields []IndexField
+}
+
+// IndexField is one field of an index.
+type IndexField struct {
+	FieldName  string
+	Column     string
+	Sort       string // DESC, ASC
+	Length     int
+	Collate    string
+	Expression string
+	Priority   int
+}
+
+// FieldNames returns the Go selectors of the indexed fields.
+func (idx Index) FieldNames() []string {
+	names := make([]string, len(idx.Fields))
+	for i, f := range idx.Fields {
+		names[i] = f.FieldName
+	}
+	return names
+}
+
+func (idx Index) String() string {
+	s := idx.Name
+	if idx.Class != "" {
+		s += " " + idx.Class
+	}
+	if idx.Type != "" {
+		s += " using " + idx.Type
+	}
+	columns := make([]string, len(idx.Fields))
+	for i, f := range idx.Fields {
+		columns[i] = f.Column
+		if f.Length > 0 {
+			columns[i] += fmt.Sprintf("(%d)", f.Length)
+		}
+		if f.Sort != "" {
+			columns[i] += " " + f.Sort
+		}
+	}
+	s += " (" + strings.Join(columns, ", ") + ")"
+	if idx.Where != "" {
+		s += " where " + idx.Where
+	}
+	return s
+}
+
+// parseIndexes builds si.Indexes and si.UniqueIndices from the tags of the
+// column fields. Unnamed indices are named by the naming strategy after the
+// table and the field, or the composite option when given.
+func parseIndexes(si *StructInfo) {
+	byName := make(map[string]*Index)
+	for _, fi := range si.FieldInfo {
+		column, ok := si.ColumnMap[fi.FieldName]
+		if !ok || fi.Tag == nil {
+			continue
+		}
+		for _, it := range fi.Tag.Indexes {
+			name := it.Name
+			if name == "" {
+				subName := fi.Name()
+				if it.Composite != "" {
+					subName = it.Composite
+				}
+				name = ns.IndexName(si.TableName, subName)
+			}
+			idx, ok := byName[name]
+			if !ok {
+				idx = &Index{Name: name}
+				byName[name] = idx
+				si.Indexes = append(si.Indexes, idx)
+			}
+			if idx.Class == "" {
+				idx.Class = it.Class
+			}
+			if idx.Type == "" {
+				idx.Type = it.Type
+			}
+			if idx.Where == "" {
+				idx.Where = it.Where
+			}
+			if idx.Comment == "" {
+				idx.Comment = it.Comment
+			}
+			if idx.Option == "" {
+				idx.Option = it.Option
+			}
+			idx.Unique = idx.Class == "UNIQUE"
+			idx.Fields = append(idx.Fields, IndexField{
+				FieldName:  fi.FieldName,
+				Column:     column,
+				Sort:       it.Sort,
+				Length:     it.Length,
+				Collate:    it.Collate,
+				Expression: it.Expression,
+				Priority:   it.Priority,
+			})
+			sort.SliceStable(idx.Fields, func(i, j int) bool {
+				return idx.Fields[i].Priority < idx.Fields[j].Priority
+			})
+		}
+	}
+	for _, idx := range si.Indexes {
+		if idx.Unique {
+			si.UniqueIndices[idx.Name] = idx.FieldNames()
+		}
+	}
+}
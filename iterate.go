@@ -0,0 +1,109 @@
+package main
+
+// generateStop writes the ErrStop sentinel the iteration callbacks of every
+// repository of the output package return to stop early.
+func (g *Generator) generateStop() {
+	errors := g.use("errors")
+	g.Printf("// ErrStop is returned by the callback of an Each or ForEachBatch method to\n")
+	g.Printf("// stop iterating without error.\n")
+	g.Printf("var ErrStop = %s.New(\"stop iteration\")\n\n", errors)
+	g.Printf("// eachBatchSize is the number of rows the Each and Stream methods read\n")
+	g.Printf("// per query.\n")
+	g.Printf("const eachBatchSize = 500\n\n")
+}
+
+// generateIteration writes the ForEachBatch, Each and Stream repository
+// methods walking the rows matching a filter in primary key order, a batch
+// of rows per query. Batches resume after the key of the last row read, as
+// ListPage does, so that composite keys are supported where gorm's
+// FindInBatches only handles a single key column.
+func (g *Generator) generateIteration(si *StructInfo) {
+	name := si.StructName
+	model := g.model(name)
+	lower := lowerFirst(name)
+	repo := name + "Repo"
+	ctx, errors, fmt := g.use("context"), g.use("errors"), g.use("fmt")
+
+	g.Printf("// ForEachBatch calls fn with the rows matching filter, in primary key\n")
+	g.Printf("// order and batchSize rows at a time. It stops at the first error of fn,\n")
+	g.Printf("// returning it unless it is ErrStop, or when ctx is done.\n")
+	g.Printf("func (r *%s) ForEachBatch(ctx %s.Context, filter *%sFilter, batchSize int, fn func([]*%s) error) error {\n", repo, ctx, name, model)
+	g.Printf("if batchSize <= 0 {\n")
+	g.Printf("return %s.Errorf(\"batch size %%d is not positive\", batchSize)\n", fmt)
+	g.Printf("}\n")
+	g.Printf("var after []any\n")
+	g.Printf("for {\n")
+	g.Printf("if err := ctx.Err(); err != nil {\n")
+	g.Printf("return err\n")
+	g.Printf("}\n")
+	g.Printf("db := filter.apply(r.db.WithContext(ctx))\n")
+	g.Printf("if after != nil {\n")
+	g.Printf("db = db.Where(keysetAfter(%sKeyColumns, after))\n", lower)
+	g.Printf("}\n")
+	g.Printf("var ms []*%s\n", model)
+	g.Printf("if err := db.Clauses(keysetOrder(%sKeyColumns)).Limit(batchSize).Find(&ms).Error; err != nil {\n", lower)
+	g.Printf("return err\n")
+	g.Printf("}\n")
+	g.Printf("if len(ms) == 0 {\n")
+	g.Printf("return nil\n")
+	g.Printf("}\n")
+	g.Printf("// Read the key before fn gets a chance to change it.\n")
+	g.Printf("after = r.KeyOf(ms[len(ms)-1]).values()\n")
+	g.Printf("if err := fn(ms); err != nil {\n")
+	g.Printf("if %s.Is(err, ErrStop) {\n", errors)
+	g.Printf("return nil\n")
+	g.Printf("}\n")
+	g.Printf("return err\n")
+	g.Printf("}\n")
+	g.Printf("if len(ms) < batchSize {\n")
+	g.Printf("return nil\n")
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("}\n\n")
+
+	g.generateEach(si, repo)
+}
+
+// generateEach writes the Each and Stream methods of recv, a repository of
+// si with a ForEachBatch method.
+func (g *Generator) generateEach(si *StructInfo, recv string) {
+	name := si.StructName
+	model := g.model(name)
+	ctx := g.use("context")
+
+	g.Printf("// Each calls fn with every row matching filter, in primary key order,\n")
+	g.Printf("// holding only a batch of rows in memory. It stops like ForEachBatch.\n")
+	g.Printf("func (r *%s) Each(ctx %s.Context, filter *%sFilter, fn func(*%s) error) error {\n", recv, ctx, name, model)
+	g.Printf("return r.ForEachBatch(ctx, filter, eachBatchSize, func(ms []*%s) error {\n", model)
+	g.Printf("for _, m := range ms {\n")
+	g.Printf("if err := fn(m); err != nil {\n")
+	g.Printf("return err\n")
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("return nil\n")
+	g.Printf("})\n")
+	g.Printf("}\n\n")
+
+	g.Printf("// Stream sends the rows matching filter on the first channel, in primary\n")
+	g.Printf("// key order, and closes it after the last row, at the first error or\n")
+	g.Printf("// when ctx is done. The second channel then receives the error, nil when\n")
+	g.Printf("// every row was sent. Cancel ctx to stop reading early.\n")
+	g.Printf("func (r *%s) Stream(ctx %s.Context, filter *%sFilter) (<-chan *%s, <-chan error) {\n", recv, ctx, name, model)
+	g.Printf("ch := make(chan *%s)\n", model)
+	g.Printf("errc := make(chan error, 1)\n")
+	g.Printf("go func() {\n")
+	g.Printf("defer close(errc)\n")
+	g.Printf("err := r.Each(ctx, filter, func(m *%s) error {\n", model)
+	g.Printf("select {\n")
+	g.Printf("case ch <- m:\n")
+	g.Printf("return nil\n")
+	g.Printf("case <-ctx.Done():\n")
+	g.Printf("return ctx.Err()\n")
+	g.Printf("}\n")
+	g.Printf("})\n")
+	g.Printf("close(ch)\n")
+	g.Printf("errc <- err\n")
+	g.Printf("}()\n")
+	g.Printf("return ch, errc\n")
+	g.Printf("}\n\n")
+}
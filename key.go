@@ -0,0 +1,310 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// generateKeysIn writes the keysIn function the repositories share to match
+// a batch of primary keys.
+func (g *Generator) generateKeysIn() {
+	gorm, clause := g.use("gorm.io/gorm"), g.use("gorm.io/gorm/clause")
+	g.Printf("// maxKeyParams bounds the parameters of a statement matching a batch of\n")
+	g.Printf("// keys, below the limit of every dialect.\n")
+	g.Printf("const maxKeyParams = 999\n\n")
+	g.Printf("// keysIn matches the rows whose columns hold one of keys, each key listing\n")
+	g.Printf("// the values of columns in order. Composite keys are compared as row\n")
+	g.Printf("// values, except on SQL Server which lacks them. A lone Or would be joined\n")
+	g.Printf("// to the previous conditions with OR, hence the And around it.\n")
+	g.Printf("func keysIn(db *%s.DB, columns []string, keys [][]any) %s.Expression {\n", gorm, clause)
+	g.Printf("cols := make([]%s.Column, len(columns))\n", clause)
+	g.Printf("for i, column := range columns {\n")
+	g.Printf("cols[i] = %s.Column{Table: %[1]s.CurrentTable, Name: column}\n", clause)
+	g.Printf("}\n")
+	g.Printf("values := make([]any, len(keys))\n")
+	g.Printf("switch {\n")
+	g.Printf("case len(cols) == 1:\n")
+	g.Printf("for i, key := range keys {\n")
+	g.Printf("values[i] = key[0]\n")
+	g.Printf("}\n")
+	g.Printf("return %s.IN{Column: cols[0], Values: values}\n", clause)
+	g.Printf("case db.Dialector.Name() == \"sqlserver\":\n")
+	g.Printf("ors := make([]%s.Expression, len(keys))\n", clause)
+	g.Printf("for i, key := range keys {\n")
+	g.Printf("eqs := make([]%s.Expression, len(cols))\n", clause)
+	g.Printf("for j, col := range cols {\n")
+	g.Printf("eqs[j] = %s.Eq{Column: col, Value: key[j]}\n", clause)
+	g.Printf("}\n")
+	g.Printf("ors[i] = %s.And(eqs...)\n", clause)
+	g.Printf("}\n")
+	g.Printf("return %s.And(%[1]s.Or(ors...))\n", clause)
+	g.Printf("}\n")
+	g.Printf("for i, key := range keys {\n")
+	g.Printf("values[i] = key\n")
+	g.Printf("}\n")
+	g.Printf("return %s.IN{Column: cols, Values: values}\n", clause)
+	g.Printf("}\n\n")
+}
+
+// generateNilKey writes the ErrNilKey sentinel the writes of every repository
+// of the output package return for a row whose key would be stored as NULL.
+func (g *Generator) generateNilKey() {
+	errors := g.use("errors")
+	g.Printf("// ErrNilKey is wrapped by the errors of writes given a row whose nil\n")
+	g.Printf("// embedded struct holds primary key columns: they would be stored as NULL,\n")
+	g.Printf("// which no key matches.\n")
+	g.Printf("var ErrNilKey = %s.New(\"nil embedded struct in primary key\")\n\n", errors)
+}
+
+// generateKey writes the <Model>Key struct holding one primary key of the
+// model, with a field per key column.
+func (g *Generator) generateKey(si *StructInfo, keys []*FieldInfo) {
+	key := si.StructName + "Key"
+	clause := g.use("gorm.io/gorm/clause")
+	g.Printf("// %s is the primary key of a %s.\n", key, si.StructName)
+	g.Printf("type %s struct {\n", key)
+	for _, fi := range keys {
+		g.Printf("%s %s\n", keyField(fi), g.typeOf(fi))
+	}
+	g.Printf("}\n\n")
+
+	g.Printf("// condition matches the row with key k.\n")
+	g.Printf("func (k %s) condition() %s.Expression {\n", key, clause)
+	g.Printf("return %s.And(\n", clause)
+	for _, fi := range keys {
+		g.Printf("%s,\n", columnEq(clause, si.ColumnMap[fi.FieldName], "k."+keyField(fi)))
+	}
+	g.Printf(")\n")
+	g.Printf("}\n\n")
+
+	g.Printf("// values returns the key columns of k in order.\n")
+	g.Printf("func (k %s) values() []any {\n", key)
+	fields := make([]string, len(keys))
+	for i, fi := range keys {
+		fields[i] = "k." + keyField(fi)
+	}
+	g.Printf("return []any{%s}\n", strings.Join(fields, ", "))
+	g.Printf("}\n\n")
+}
+
+// generateKeyMethods writes the repository methods taking <Model>Key values.
+func (g *Generator) generateKeyMethods(si *StructInfo, keys []*FieldInfo) {
+	name := si.StructName
+	model := g.model(name)
+	lower := lowerFirst(name)
+	repo, key := name+"Repo", name+"Key"
+	ctx := g.use("context")
+
+	g.Printf("// KeyOf returns the primary key of m. Key fields of nil embedded structs\n")
+	g.Printf("// are zero, and the writes refuse such rows with ErrNilKey.\n")
+	g.Printf("func (r *%s) KeyOf(m *%s) %s {\n", repo, model, key)
+	var literal []string
+	guarded := make(map[string][]*FieldInfo)
+	var guards []string
+	for _, fi := range keys {
+		guard := nilGuard(fi, "m")
+		if guard == "" {
+			literal = append(literal, keyField(fi)+": m."+fi.FieldName)
+			continue
+		}
+		if _, ok := guarded[guard]; !ok {
+			guards = append(guards, guard)
+		}
+		guarded[guard] = append(guarded[guard], fi)
+	}
+	g.Printf("k := %s{%s}\n", key, strings.Join(literal, ", "))
+	for _, guard := range guards {
+		g.Printf("if %s {\n", guard)
+		for _, fi := range guarded[guard] {
+			g.Printf("k.%s = m.%s\n", keyField(fi), fi.FieldName)
+		}
+		g.Printf("}\n")
+	}
+	g.Printf("return k\n")
+	g.Printf("}\n\n")
+
+	if parents := nilKeyParents(keys); len(parents) > 0 {
+		fmt := g.use("fmt")
+		g.Printf("// check%sKey returns an error wrapping ErrNilKey when a nil embedded\n", name)
+		g.Printf("// struct of m holds primary key columns.\n")
+		g.Printf("func check%sKey(m *%s) error {\n", name, model)
+		for _, p := range parents {
+			g.Printf("if m.%s == nil {\n", p.FieldName)
+			g.Printf("return %s.Errorf(\"%%w: %s.%s\", ErrNilKey)\n", fmt, name, p.FieldName)
+			g.Printf("}\n")
+		}
+		g.Printf("return nil\n")
+		g.Printf("}\n\n")
+	}
+
+	g.Printf("// GetByKey returns the %s with primary key k, or a *NotFoundError.\n", name)
+	g.Printf("// Of opts, only WithDeleted and preloads apply.\n")
+	g.Printf("func (r *%s) GetByKey(ctx %s.Context, k %s, opts ...%s) (*%s, error) {\n", repo, ctx, key, queryOption(si), model)
+	g.Printf("var m %s\n", model)
+	g.Printf("db := %s(opts).read(r.db.WithContext(ctx))\n", optionsFunc(si))
+	g.Printf("if err := db.Where(k.condition()).Take(&m).Error; err != nil {\n")
+	g.Printf("return nil, notFound(err, %q, \"primary key\", k.values()...)\n", name)
+	g.Printf("}\n")
+	g.Printf("return &m, nil\n")
+	g.Printf("}\n\n")
+
+	g.Printf("// GetByKeys returns the %ss with the given primary keys, in no\n", name)
+	g.Printf("// particular order. Keys without row are left out. Large sets of keys\n")
+	g.Printf("// are looked up in several queries. Of opts, only WithDeleted and\n")
+	g.Printf("// preloads apply.\n")
+	g.Printf("func (r *%s) GetByKeys(ctx %s.Context, keys []%s, opts ...%s) ([]*%s, error) {\n", repo, ctx, key, queryOption(si), model)
+	g.Printf("o := %s(opts)\n", optionsFunc(si))
+	g.Printf("var ms []*%s\n", model)
+	g.Printf("size := maxKeyParams / len(%sKeyColumns)\n", lower)
+	g.Printf("for start := 0; start < len(keys); start += size {\n")
+	g.Printf("end := start + size\n")
+	g.Printf("if end > len(keys) {\n")
+	g.Printf("end = len(keys)\n")
+	g.Printf("}\n")
+	g.Printf("values := make([][]any, 0, end-start)\n")
+	g.Printf("for _, k := range keys[start:end] {\n")
+	g.Printf("values = append(values, k.values())\n")
+	g.Printf("}\n")
+	g.Printf("var batch []*%s\n", model)
+	g.Printf("if err := o.read(r.db.WithContext(ctx)).Where(keysIn(r.db, %sKeyColumns, values)).Find(&batch).Error; err != nil {\n", lower)
+	g.Printf("return nil, err\n")
+	g.Printf("}\n")
+	g.Printf("ms = append(ms, batch...)\n")
+	g.Printf("}\n")
+	g.Printf("return ms, nil\n")
+	g.Printf("}\n\n")
+
+	if si.SoftDelete {
+		g.Printf("// DeleteByKey soft deletes the %s with primary key k, or returns a\n", name)
+		g.Printf("// *NotFoundError. RestoreByKey brings it back, PurgeByKey removes it\n")
+		g.Printf("// for good.\n")
+	} else {
+		g.Printf("// DeleteByKey removes the %s with primary key k, or returns a\n", name)
+		g.Printf("// *NotFoundError.\n")
+	}
+	g.Printf("func (r *%s) DeleteByKey(ctx %s.Context, k %s) error {\n", repo, ctx, key)
+	g.Printf("res := r.db.WithContext(ctx).Where(k.condition()).Delete(&%s{})\n", model)
+	g.Printf("if res.Error != nil {\n")
+	g.Printf("return res.Error\n")
+	g.Printf("}\n")
+	g.Printf("if res.RowsAffected == 0 {\n")
+	g.Printf("return &NotFoundError{Model: %q, Index: \"primary key\", Key: k.values()}\n", name)
+	g.Printf("}\n")
+	g.Printf("return nil\n")
+	g.Printf("}\n\n")
+
+	g.Printf("// ExistsByKey reports whether a %s has primary key k.\n", name)
+	g.Printf("func (r *%s) ExistsByKey(ctx %s.Context, k %s) (bool, error) {\n", repo, ctx, key)
+	g.Printf("var n int64\n")
+	g.Printf("err := r.db.WithContext(ctx).Model(&%s{}).Where(k.condition()).Limit(1).Count(&n).Error\n", model)
+	g.Printf("return n > 0, err\n")
+	g.Printf("}\n\n")
+}
+
+// primaryKeys returns the primary key fields of si in key order.
+func (g *Generator) primaryKeys(si *StructInfo) []*FieldInfo {
+	var keys []*FieldInfo
+	for _, name := range si.PrimaryKeys {
+		if fi := si.LookUpField(name); fi != nil {
+			keys = append(keys, fi)
+		}
+	}
+	return keys
+}
+
+// keyField returns the name of the key struct field holding fi, e.g.
+// SourcePositionHouseID.
+func keyField(fi *FieldInfo) string {
+	return strings.ReplaceAll(fi.FieldName, ".", "")
+}
+
+// keyParams returns the parameter list taking the value of every key field.
+func (g *Generator) keyParams(keys []*FieldInfo) string {
+	params := make([]string, len(keys))
+	for i, fi := range keys {
+		params[i] = paramName(fi) + " " + g.typeOf(fi)
+	}
+	return strings.Join(params, ", ")
+}
+
+// keyArgs returns the arguments passing the key parameters.
+func keyArgs(keys []*FieldInfo) string {
+	args := make([]string, len(keys))
+	for i, fi := range keys {
+		args[i] = paramName(fi)
+	}
+	return strings.Join(args, ", ")
+}
+
+// keyLiteral returns the composite literal of type key made of the key
+// parameters.
+func keyLiteral(key string, keys []*FieldInfo) string {
+	fields := make([]string, len(keys))
+	for i, fi := range keys {
+		fields[i] = keyField(fi) + ": " + paramName(fi)
+	}
+	return key + "{" + strings.Join(fields, ", ") + "}"
+}
+
+// nilGuard returns the condition under which the selector of fi can be
+// evaluated on recv, or "" when it always can.
+func nilGuard(fi *FieldInfo, recv string) string {
+	var checks []string
+	for p := fi.Parent; p != nil; p = p.Parent {
+		if p.Type != nil && p.Type.Pointer || p.Type == nil && strings.HasPrefix(p.FieldType, "*") {
+			checks = append([]string{recv + "." + p.FieldName + " != nil"}, checks...)
+		}
+	}
+	return strings.Join(checks, " && ")
+}
+
+// nilKeyParents returns the pointer embedded structs holding some of keys,
+// outer ones first.
+func nilKeyParents(keys []*FieldInfo) []*FieldInfo {
+	var parents []*FieldInfo
+	seen := make(map[string]bool)
+	for _, fi := range keys {
+		var path []*FieldInfo
+		for p := fi.Parent; p != nil; p = p.Parent {
+			path = append([]*FieldInfo{p}, path...)
+		}
+		for _, p := range path {
+			if seen[p.FieldName] || !(p.Type != nil && p.Type.Pointer || p.Type == nil && strings.HasPrefix(p.FieldType, "*")) {
+				continue
+			}
+			seen[p.FieldName] = true
+			parents = append(parents, p)
+		}
+	}
+	return parents
+}
+
+// checkKeys writes the statements returning the error of check<Model>Key
+// for the row m, or for each row of ms when many is set. Nothing is written
+// when no key column of si sits in a pointer embedded struct.
+func (g *Generator) checkKeys(si *StructInfo, many bool) {
+	if len(nilKeyParents(g.primaryKeys(si))) == 0 {
+		return
+	}
+	if many {
+		g.Printf("for _, m := range ms {\n")
+	}
+	g.Printf("if err := check%sKey(m); err != nil {\n", si.StructName)
+	g.Printf("return err\n")
+	g.Printf("}\n")
+	if many {
+		g.Printf("}\n")
+	}
+}
+
+// columnNeq returns the expression testing that column of the current table
+// differs from value.
+func columnNeq(clause, column, value string) string {
+	return fmt.Sprintf("%s.Neq{Column: %[1]s.Column{Table: %[1]s.CurrentTable, Name: %q}, Value: %s}", clause, column, value)
+}
+
+// columnEq returns the expression comparing column of the current table to
+// value.
+func columnEq(clause, column, value string) string {
+	return fmt.Sprintf("%s.Eq{Column: %[1]s.Column{Table: %[1]s.CurrentTable, Name: %q}, Value: %s}", clause, column, value)
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// Package is a parsed and type-checked Go package holding gorm models.
+type Package struct {
+	Name   string
+	Path   string
+	Dir    string
+	Fset   *token.FileSet
+	Files  []*ast.File
+	Types  *types.Package
+	Info   *types.Info
+	Errors []error
+
+	scanner    *types.Interface
+	valuer     *types.Interface
+	serializer *types.Interface
+}
+
+// LoadPackage parses every Go file of the package in dir that matches the
+// current build context and type-checks it together with its imports, so
+// that field types declared in other files or packages can be resolved.
+//
+// Type errors do not abort loading, they are collected in Errors: the
+// package may reference code that is yet to be generated.
+func LoadPackage(dir string) (*Package, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	bp, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+	pkg := &Package{
+		Name: bp.Name,
+		Path: importPath(dir),
+		Dir:  dir,
+		Fset: token.NewFileSet(),
+		Info: &types.Info{
+			Types: make(map[ast.Expr]types.TypeAndValue),
+			Defs:  make(map[*ast.Ident]types.Object),
+			Uses:  make(map[*ast.Ident]types.Object),
+		},
+	}
+	for _, name := range bp.GoFiles {
+		file, err := parser.ParseFile(pkg.Fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		pkg.Files = append(pkg.Files, file)
+	}
+	imp := importer.ForCompiler(pkg.Fset, "source", nil).(types.ImporterFrom)
+	conf := types.Config{
+		Importer: imp,
+		Error: func(err error) {
+			pkg.Errors = append(pkg.Errors, err)
+		},
+	}
+	pkg.Types, _ = conf.Check(pkg.Path, pkg.Fset, pkg.Files, pkg.Info)
+
+	lookupInterface := func(pkgPath, name string) *types.Interface {
+		p, err := imp.ImportFrom(pkgPath, dir, 0)
+		if err != nil {
+			return nil
+		}
+		obj := p.Scope().Lookup(name)
+		if obj == nil {
+			return nil
+		}
+		iface, _ := obj.Type().Underlying().(*types.Interface)
+		return iface
+	}
+	pkg.scanner = lookupInterface("database/sql", "Scanner")
+	pkg.valuer = lookupInterface("database/sql/driver", "Valuer")
+	pkg.serializer = lookupInterface("gorm.io/gorm/schema", "SerializerInterface")
+	return pkg, nil
+}
+
+// ParseStruct returns the model of the struct named structName, with the
+// type of every field resolved.
+func (pkg *Package) ParseStruct(structName string) (*StructInfo, error) {
+	p := &structParser{fset: pkg.Fset, files: pkg.Files, pkg: pkg}
+	return p.parse(structName)
+}
+
+// typeInfo describes t in terms of what gorm cares about.
+func (pkg *Package) typeInfo(t types.Type) *TypeInfo {
+	ti := &TypeInfo{typ: t}
+	if ptr, ok := t.(*types.Pointer); ok {
+		ti.Pointer = true
+		t = ptr.Elem()
+	}
+	if named, ok := t.(*types.Named); ok {
+		ti.Name = named.Obj().Name()
+		if named.Obj().Pkg() != nil {
+			ti.PkgPath = named.Obj().Pkg().Path()
+		}
+	}
+	ti.Kind = kindOf(t.Underlying())
+	switch u := t.Underlying().(type) {
+	case *types.Slice:
+		ti.Elem = pkg.typeInfo(u.Elem())
+	case *types.Array:
+		ti.Elem = pkg.typeInfo(u.Elem())
+	case *types.Map:
+		ti.Elem = pkg.typeInfo(u.Elem())
+	}
+	implements := func(iface *types.Interface) bool {
+		if iface == nil {
+			return false
+		}
+		return types.Implements(t, iface) || types.Implements(types.NewPointer(t), iface)
+	}
+	ti.Scanner = implements(pkg.scanner)
+	ti.Valuer = implements(pkg.valuer)
+	ti.Serializer = implements(pkg.serializer)
+	// gorm looks for the DeleteClauses method of gorm.DeletedAt and of the
+	// types of the soft_delete plugin
+	ti.SoftDelete = types.NewMethodSet(t).Lookup(nil, "DeleteClauses") != nil
+	return ti
+}
+
+// kindOf maps an underlying type to the reflect.Kind gorm would see at
+// runtime.
+func kindOf(t types.Type) reflect.Kind {
+	switch u := t.(type) {
+	case *types.Basic:
+		switch u.Kind() {
+		case types.Bool, types.UntypedBool:
+			return reflect.Bool
+		case types.Int, types.UntypedInt:
+			return reflect.Int
+		case types.Int8:
+			return reflect.Int8
+		case types.Int16:
+			return reflect.Int16
+		case types.Int32, types.UntypedRune:
+			return reflect.Int32
+		case types.Int64:
+			return reflect.Int64
+		case types.Uint:
+			return reflect.Uint
+		case types.Uint8:
+			return reflect.Uint8
+		case types.Uint16:
+			return reflect.Uint16
+		case types.Uint32:
+			return reflect.Uint32
+		case types.Uint64:
+			return reflect.Uint64
+		case types.Uintptr:
+			return reflect.Uintptr
+		case types.Float32:
+			return reflect.Float32
+		case types.Float64, types.UntypedFloat:
+			return reflect.Float64
+		case types.Complex64:
+			return reflect.Complex64
+		case types.Complex128, types.UntypedComplex:
+			return reflect.Complex128
+		case types.String, types.UntypedString:
+			return reflect.String
+		case types.UnsafePointer:
+			return reflect.UnsafePointer
+		}
+	case *types.Struct:
+		return reflect.Struct
+	case *types.Slice:
+		return reflect.Slice
+	case *types.Array:
+		return reflect.Array
+	case *types.Map:
+		return reflect.Map
+	case *types.Pointer:
+		return reflect.Ptr
+	case *types.Interface:
+		return reflect.Interface
+	case *types.Chan:
+		return reflect.Chan
+	case *types.Signature:
+		return reflect.Func
+	}
+	return reflect.Invalid
+}
+
+// importPath derives the import path of dir from the nearest go.mod, or
+// falls back to the directory name outside of a module.
+func importPath(dir string) string {
+	for d := dir; ; d = filepath.Dir(d) {
+		if modPath := modulePath(filepath.Join(d, "go.mod")); modPath != "" {
+			rel, err := filepath.Rel(d, dir)
+			if err != nil || rel == "." {
+				return modPath
+			}
+			return path.Join(modPath, filepath.ToSlash(rel))
+		}
+		if filepath.Dir(d) == d {
+			return filepath.Base(dir)
+		}
+	}
+}
+
+func modulePath(gomod string) string {
+	f, err := os.Open(gomod)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "module") {
+			return strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module")), `"`)
+		}
+	}
+	return ""
+}
+
+// TypeInfo is the resolved type of a field.
+type TypeInfo struct {
+	Name       string
+	PkgPath    string
+	Kind       reflect.Kind
+	Pointer    bool
+	Elem       *TypeInfo
+	Scanner    bool
+	Valuer     bool
+	Serializer bool
+	SoftDelete bool
+
+	typ types.Type
+}
+
+func (ti TypeInfo) String() string {
+	s := ti.Kind.String()
+	if ti.Name != "" {
+		s = fmt.Sprintf("%s(%s)", ti.qualifiedName(), s)
+	}
+	if ti.Pointer {
+		s = "*" + s
+	}
+	if ti.Elem != nil {
+		s += "[" + ti.Elem.String() + "]"
+	}
+	if ti.Scanner {
+		s += " scanner"
+	}
+	if ti.Valuer {
+		s += " valuer"
+	}
+	if ti.Serializer {
+		s += " serializer"
+	}
+	if ti.SoftDelete {
+		s += " softdelete"
+	}
+	return s
+}
+
+func (ti TypeInfo) qualifiedName() string {
+	if ti.PkgPath == "" {
+		return ti.Name
+	}
+	return ti.PkgPath + "." + ti.Name
+}
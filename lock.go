@@ -0,0 +1,90 @@
+package main
+
+// generateLockOptions writes the LockOption type and the lockRows helper
+// shared by the ForUpdate methods of every repository of the output package.
+func (g *Generator) generateLockOptions() {
+	errors, fmt := g.use("errors"), g.use("fmt")
+	gorm, clause := g.use("gorm.io/gorm"), g.use("gorm.io/gorm/clause")
+	g.Printf("// ErrNotInTx is returned by the ForUpdate methods of a repository that was\n")
+	g.Printf("// not obtained from WithTx or RunInTx: outside of a transaction, row locks\n")
+	g.Printf("// are released as soon as the rows are read.\n")
+	g.Printf("var ErrNotInTx = %s.New(\"row locks need a transaction from WithTx or RunInTx\")\n\n", errors)
+
+	g.Printf("// LockOption tunes the row locks a ForUpdate method takes.\n")
+	g.Printf("type LockOption func(*%s.Locking)\n\n", clause)
+	g.Printf("// SkipLocked leaves out the rows another transaction has locked instead\n")
+	g.Printf("// of waiting for them.\n")
+	g.Printf("func SkipLocked() LockOption {\n")
+	g.Printf("return func(l *%s.Locking) { l.Options = \"SKIP LOCKED\" }\n", clause)
+	g.Printf("}\n\n")
+	g.Printf("// NoWait fails instead of waiting for the rows another transaction has\n")
+	g.Printf("// locked.\n")
+	g.Printf("func NoWait() LockOption {\n")
+	g.Printf("return func(l *%s.Locking) { l.Options = \"NOWAIT\" }\n", clause)
+	g.Printf("}\n\n")
+	g.Printf("// ForShare takes shared locks, which let other transactions read the rows\n")
+	g.Printf("// but not update them.\n")
+	g.Printf("func ForShare() LockOption {\n")
+	g.Printf("return func(l *%s.Locking) { l.Strength = \"SHARE\" }\n", clause)
+	g.Printf("}\n\n")
+
+	g.Printf("// lockRows locks the rows db reads as opts tell, provided db is the\n")
+	g.Printf("// transaction of a repository from WithTx or RunInTx. SQLite, which\n")
+	g.Printf("// locks the whole database instead of rows, reads them as they are.\n")
+	g.Printf("func lockRows(db *%s.DB, inTx bool, opts []LockOption) (*%[1]s.DB, error) {\n", gorm)
+	g.Printf("if _, ok := db.Statement.ConnPool.(%s.TxCommitter); !inTx || !ok {\n", gorm)
+	g.Printf("return nil, ErrNotInTx\n")
+	g.Printf("}\n")
+	g.Printf("l := %s.Locking{Strength: \"UPDATE\"}\n", clause)
+	g.Printf("for _, opt := range opts {\n")
+	g.Printf("opt(&l)\n")
+	g.Printf("}\n")
+	g.Printf("switch name := db.Dialector.Name(); name {\n")
+	g.Printf("case \"sqlite\":\n")
+	g.Printf("return db, nil\n")
+	g.Printf("case \"sqlserver\":\n")
+	g.Printf("return nil, %s.Errorf(\"row locks are not supported on %%s\", name)\n", fmt)
+	g.Printf("}\n")
+	g.Printf("return db.Clauses(l), nil\n")
+	g.Printf("}\n\n")
+}
+
+// generateForUpdate writes the GetForUpdate and ListForUpdate repository
+// methods reading rows and locking them until the end of the transaction.
+func (g *Generator) generateForUpdate(si *StructInfo, keys []*FieldInfo) {
+	name := si.StructName
+	model := g.model(name)
+	lower := lowerFirst(name)
+	repo := name + "Repo"
+	ctx := g.use("context")
+
+	g.Printf("// GetForUpdate returns the %s with the given primary key, locked until\n", name)
+	g.Printf("// the end of the transaction of r, or a *NotFoundError.\n")
+	g.Printf("func (r *%s) GetForUpdate(ctx %s.Context, %s, opts ...LockOption) (*%s, error) {\n", repo, ctx, g.keyParams(keys), model)
+	g.Printf("db, err := lockRows(r.db.WithContext(ctx), r.tx, opts)\n")
+	g.Printf("if err != nil {\n")
+	g.Printf("return nil, err\n")
+	g.Printf("}\n")
+	g.Printf("k := %s\n", keyLiteral(name+"Key", keys))
+	g.Printf("var m %s\n", model)
+	g.Printf("if err := db.Where(k.condition()).Take(&m).Error; err != nil {\n")
+	g.Printf("return nil, notFound(err, %q, \"primary key\", k.values()...)\n", name)
+	g.Printf("}\n")
+	g.Printf("return &m, nil\n")
+	g.Printf("}\n\n")
+
+	g.Printf("// ListForUpdate returns the rows matching filter, locked until the end of\n")
+	g.Printf("// the transaction of r. Rows are locked in primary key order, the same in\n")
+	g.Printf("// every transaction, to avoid deadlocks.\n")
+	g.Printf("func (r *%s) ListForUpdate(ctx %s.Context, filter *%sFilter, opts ...LockOption) ([]*%s, error) {\n", repo, ctx, name, model)
+	g.Printf("db, err := lockRows(r.db.WithContext(ctx), r.tx, opts)\n")
+	g.Printf("if err != nil {\n")
+	g.Printf("return nil, err\n")
+	g.Printf("}\n")
+	g.Printf("var ms []*%s\n", model)
+	g.Printf("if err := filter.apply(db).Clauses(keysetOrder(%sKeyColumns)).Find(&ms).Error; err != nil {\n", lower)
+	g.Printf("return nil, err\n")
+	g.Printf("}\n")
+	g.Printf("return ms, nil\n")
+	g.Printf("}\n\n")
+}
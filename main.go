@@ -1,38 +1,88 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"go/ast"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
-	"sync"
 
 	"gorm.io/gorm/schema"
 )
 
 type FieldInfo struct {
+	// FieldName is the Go selector of the field relative to the model, e.g.
+	// SourcePosition.HouseID for a field flattened from an embedded struct.
 	FieldName string
 	FieldType string
 	Ignored   bool
 	External  bool
+	Anonymous bool
+	// Type is only known when the field was parsed from a loaded package.
+	Type *TypeInfo
+	Tag  *GormTag
+	// Permission tells which of the generated reads and writes may touch
+	// the field.
+	Permission Permission
+	// Enum is set when the field has a named type with declared constants.
+	Enum *EnumInfo
+	// Parent is the embedded struct field this field was flattened from.
+	Parent *FieldInfo
+}
+
+// Name returns the name the field is declared with.
+func (fi FieldInfo) Name() string {
+	return fi.FieldName[strings.LastIndex(fi.FieldName, ".")+1:]
+}
+
+// Path returns the names of the fields leading from the model to fi,
+// including anonymous ones, e.g. [Model ID].
+func (fi FieldInfo) Path() []string {
+	if fi.Parent == nil {
+		return []string{fi.Name()}
+	}
+	return append(fi.Parent.Path(), fi.Name())
 }
 
 func (fi FieldInfo) String() string {
 	s := fmt.Sprintf("%s\t%s\t", fi.FieldName, fi.FieldType)
+	if fi.Type != nil {
+		s += fi.Type.String() + "\t"
+	}
 	if fi.Ignored {
 		s += "ignored\t"
 	}
 	if fi.External {
 		s += "external\t"
 	}
+	if fi.Enum != nil {
+		s += "enum:" + fi.Enum.TypeName + "\t"
+	}
+	if perm := fi.Permission; !fi.Ignored && (!perm.Read || !perm.Create || !perm.Update || !perm.Migrate) {
+		s += "perm:" + perm.String() + "\t"
+	}
 	return s
 }
 
 type StructInfo struct {
 	StructName    string
+	TableName     string
 	FieldInfo     []*FieldInfo
+	Indexes       []*Index
+	Relationships []*Relationship
 	UniqueIndices map[string][]string
 	PrimaryKeys   []string
 	ColumnMap     map[string]string
+	// SoftDelete is set when a gorm.DeletedAt column makes gorm keep
+	// deleted rows around.
+	SoftDelete bool
 }
 
 func (si StructInfo) String() string {
@@ -40,6 +90,18 @@ func (si StructInfo) String() string {
 	for _, fi := range si.FieldInfo {
 		s += fmt.Sprintln(fi)
 	}
+	if len(si.Indexes) > 0 {
+		s += fmt.Sprintln("Indexes:")
+		for _, idx := range si.Indexes {
+			s += fmt.Sprintln(idx)
+		}
+	}
+	if len(si.Relationships) > 0 {
+		s += fmt.Sprintln("Relationships:")
+		for _, rel := range si.Relationships {
+			s += fmt.Sprintln(rel)
+		}
+	}
 	if len(si.UniqueIndices) > 0 {
 		s += fmt.Sprintln("UniqueIndices:")
 		for u, is := range si.UniqueIndices {
@@ -49,6 +111,9 @@ func (si StructInfo) String() string {
 	if len(si.PrimaryKeys) > 0 {
 		s += fmt.Sprintln("PrimaryKeys:", strings.Join(si.PrimaryKeys, "+"))
 	}
+	if si.SoftDelete {
+		s += fmt.Sprintln("SoftDelete")
+	}
 	if len(si.ColumnMap) > 0 {
 		s += fmt.Sprintln("Column names:")
 		for fn, cn := range si.ColumnMap {
@@ -58,9 +123,16 @@ func (si StructInfo) String() string {
 	return s
 }
 
-var testStructs = []string{
-	"type Mouse struct {\n\tID           string         `gorm:\"primaryKey;comment:小鼠编号;\" json:\"id\"`\n\tYear         int            `json:\"year,omitempty\" gorm:\"index;comment:小鼠年份\"`\n\tNumber       int            `json:\"number,omitempty\" gorm:\"index;comment:小鼠编号数字部分\"`\n\tCreatedAt    time.Time      `json:\"created_at,omitempty\"`\n\tUpdatedAt    time.Time      `json:\"updated_at,omitempty\"`\n\tDeletedAt    gorm.DeletedAt `gorm:\"index\" json:\"-\"`\n\tGender       Gender         `json:\"gender\" swaggertype:\"primitive,string\" enums:\"male,female\" gorm:\"comment:性别\"`\n\tExperimentID string         `gorm:\"index;default:NULL;comment:实验项目号\" json:\"experiment_id,omitempty\"`\n\t// BirthdayOrArrivalDate is not necessarily the date of birth,\n\t// it can also be the arrival date depending on if the `WeekAgeOnArrival` is greater than 0\n\tBirthdayOrArrivalDate time.Time `json:\"boad\" gorm:\"column:boad;comment:出生日期/到货日期\"`\n\t// WeekAgeOnArrival is the week age of the mouse when it arrives at the facility,\n\t// if it's greater than 0 then the `BirthdayOrArrivalDate` means the arrival date\n\tWeekAgeOnArrival int                  `json:\"waoa,omitempty\" gorm:\"column:waoa;comment:到货周龄\"`\n\tProjectID        string               `gorm:\"index;comment:项目号\" json:\"project_id,omitempty\"`\n\tStrainID         uint                 `json:\"strain_id\" gorm:\"index;comment:品系号\"`\n\tStrain           Strain               `json:\"strain,omitempty\" gorm:\"foreignKey:StrainID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;\"`\n\tGenotype         *IdentifiedGenotypes `json:\"genotype,omitempty\" gorm:\"foreignKey:MouseID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;\"`\n\tGeneration       int                  `json:\"generation\" gorm:\"comment:代数\"`\n\tFather           string               `json:\"father,omitempty\" gorm:\"comment:父亲\"`\n\tMother1          string               `json:\"mother1,omitempty\" gorm:\"comment:母亲1\"`\n\tMother2          string               `json:\"mother2,omitempty\" gorm:\"comment:母亲2\"`\n\tSupplier         string               `gorm:\"index;default:'Sironax';comment:供应商\" json:\"supplier,omitempty\"`\n\tStatus           Status               `gorm:\"index;comment:小鼠状态\" json:\"status\" swaggertype:\"primitive,string\" enums:\"feeding,genotyping,genotypechecking,unidentified,breedingwaiting,experimentwaiting,transferwaiting,newborn,breeding,experimentongoing,sacrificewaiting,dead,breedingfinished,endpoint,sacrificed,performingexternalexperiment,tissuecollection,genotypesunknown\"`\n\tCreatorID        uint                 `json:\"creator_id,omitempty\" gorm:\"comment:创建人\"`\n\tExternal         string               `json:\"external,omitempty\" gorm:\"index;default:NULL;comment:外部小鼠记录\"`\n\tRemarks          string               `json:\"remarks,omitempty\"`\n}",
-	"type User struct {\n\tgorm.Model\n\tName     string   `json:\"name,omitempty\" gorm:\"uniqueIndex;comment:用户名\"`\n\tRealName string   `json:\"real_name,omitempty\" gorm:\"comment:用户真实姓名\"`\n\tPassword []byte   `json:\"-\" gorm:\"comment:用户密码哈希值\"`\n\tEmail    string   `json:\"email,omitempty\" gorm:\"uniqueIndex;comment:邮箱\"`\n\tPhone    string   `json:\"phone,omitempty\" gorm:\"uniqueIndex;comment:电话号码\"`\n\tRole     Role     `json:\"role,omitempty\" gorm:\"comment:用户角色\" swaggertype:\"primitive,string\" enums:\"watcher,identifier,experimenter,feeder,admin\"`\n\tToken    string   `json:\"token,omitempty\" gorm:\"-\"`\n\tConfig   []Config `json:\"config,omitempty\" gorm:\"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;\"`\n}\n",
+// Columns returns the fields of si stored in a column for which keep returns
+// true, in declaration order. A nil keep returns them all.
+func (si *StructInfo) Columns(keep func(*FieldInfo) bool) []*FieldInfo {
+	var fields []*FieldInfo
+	for _, fi := range si.FieldInfo {
+		if _, ok := si.ColumnMap[fi.FieldName]; ok && (keep == nil || keep(fi)) {
+			fields = append(fields, fi)
+		}
+	}
+	return fields
 }
 
 var ns = schema.NamingStrategy{}
@@ -84,27 +156,6 @@ func squeeze(s string, c byte) string {
 	return string(res)
 }
 
-const testGoFile = `package main
-
-// StrucA is a struct
-type StructA struct {
-	A int
-	B int /*comments*/
-	C string
-}
-
-/*
-comments
-comments
-//
-*/
-type StructB struct {
-	A uint // comments
-	B int8 // comments
-	C []byte
-}
-`
-
 // RemoveComments removes non-code
 func RemoveComments(content string) string {
 	var res []string
@@ -152,6 +203,8 @@ func FindStructBlock(structName string, fileContent string) string {
 	return fileContent[start : end+1]
 }
 
+// ParseStructBlock is the text based fallback of structParser. It only sees
+// the struct block itself, so embedded structs are not flattened.
 func ParseStructBlock(strStruct string) *StructInfo {
 	fieldNameProcess := func(fn string) string {
 		if strings.Contains(fn, ".") {
@@ -193,81 +246,194 @@ func ParseStructBlock(strStruct string) *StructInfo {
 			FieldName: fieldname,
 			FieldType: fieldtype,
 		}
-		if matched, _ := regexp.MatchString(`gorm:"-[^"]*"`, tag); matched {
+		gormTag := ParseGormTag(reflect.StructTag(strings.Trim(tag, "`")).Get("gorm"))
+		fieldInfo.Tag = gormTag
+		fieldInfo.Permission = gormTag.Permission()
+		if perm := fieldInfo.Permission; !perm.Read && !perm.Create && !perm.Update {
 			fieldInfo.Ignored = true
 			continue
 		}
-		if matched, _ := regexp.MatchString(`gorm:"[^"]+"`, tag); matched {
+		if len(gormTag.Settings) > 0 {
 			structInfo.ColumnMap[fieldname] = ns.ColumnName(structInfo.StructName, fieldname)
-			gormInfo := strings.TrimSpace(regexp.MustCompile(`gorm:"[^"]+"`).FindString(tag))
-			gormInfo = strings.TrimSpace(gormInfo[6 : len(gormInfo)-1])
-			gormInfos := strings.Split(gormInfo, ";")
-			for _, gi := range gormInfos {
-				if trmd := strings.ToLower(strings.TrimSpace(gi)); trmd != "" {
-					if strings.Contains(trmd, ":") {
-						splts := strings.Split(trmd, ":")
-						k, v := splts[0], splts[1]
-						if k == "uniqueindex" {
-							if _, ok := structInfo.UniqueIndices[v]; !ok {
-								structInfo.UniqueIndices[v] = []string{}
-							}
-							structInfo.UniqueIndices[v] = append(structInfo.UniqueIndices[v], fieldname)
-						} else if k == "column" {
-							structInfo.ColumnMap[fieldname] = v
-						} else if k == "foreignkey" {
-							fieldInfo.External = true
-							delete(structInfo.ColumnMap, fieldname)
-							break
-						} else if k == "embedded" {
-							// TODO: complete this section
-						} else if k == "embeddedprefix" {
-							// TODO: complete this section
-						}
-					} else {
-						if trmd == "uniqueindex" {
-							structInfo.UniqueIndices[fieldname] = []string{fieldname}
-						} else if trmd == "primarykey" {
-							structInfo.PrimaryKeys = append(structInfo.PrimaryKeys, fieldname)
-						}
-					}
-				}
+			if gormTag.Column != "" {
+				structInfo.ColumnMap[fieldname] = gormTag.Column
+			}
+			if gormTag.ForeignKey != "" {
+				fieldInfo.External = true
+				delete(structInfo.ColumnMap, fieldname)
+			}
+			if gormTag.PrimaryKey {
+				structInfo.PrimaryKeys = append(structInfo.PrimaryKeys, fieldname)
 			}
 		}
 		structInfo.FieldInfo = append(structInfo.FieldInfo, fieldInfo)
 	}
+	structInfo.TableName = ns.TableName(structInfo.StructName)
+	parseIndexes(structInfo)
 	return structInfo
 }
 
-func test() {
-	for _, testStruct := range testStructs {
-		structInfo := ParseStructBlock(RemoveComments(testStruct))
-		fmt.Println(*structInfo)
-	}
+var (
+	structNames = flag.String("struct", "", "comma-separated list of struct names; defaults to the struct following the go:generate directive")
+	packageName = flag.String("package", "", "package name of the generated file; defaults to $GOPACKAGE")
+	output      = flag.String("o", "", "output file name; defaults to <struct>_crud.go")
+	storeName   = flag.String("store", "store_crud.go", "file of the output directory the Store of every repository of the output package is written to; empty to write none")
+	enumText    = flag.Bool("enumtext", false, "generate MarshalText and UnmarshalText for enums, so that encoding/json writes their text form instead of their value")
+)
 
-	sa := FindStructBlock("StructA", RemoveComments(testGoFile))
-	fmt.Println(sa)
-	fmt.Println()
-	structA := ParseStructBlock(sa)
-	fmt.Println(*structA)
-	sb := FindStructBlock("StructB", RemoveComments(testGoFile))
-	fmt.Println(sb)
-	fmt.Println()
-	structB := ParseStructBlock(sb)
-	fmt.Println(*structB)
+// Usage is a replacement usage function for the flags package.
+func Usage() {
+	fmt.Fprintf(os.Stderr, "Usage of gormaid:\n")
+	fmt.Fprintf(os.Stderr, "\tgormaid [flags] -struct T [directory]\n")
+	fmt.Fprintf(os.Stderr, "\t//go:generate gormaid [flags] -struct T -package P -o path/t_crud.go\n")
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
 }
 
-func GetGormColumns(a any) (map[string]*schema.Field, error) {
-	s, err := schema.Parse(a, &sync.Map{}, schema.NamingStrategy{})
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("gormaid: ")
+	flag.Usage = Usage
+	flag.Parse()
+
+	dir := "."
+	if args := flag.Args(); len(args) == 1 {
+		dir = args[0]
+	} else if len(args) > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	pkg, err := LoadPackage(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	reportTypeErrors(pkg)
+
+	var names []string
+	for _, name := range strings.Split(*structNames, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		name, err := structAfterDirective(pkg, os.Getenv("GOFILE"), os.Getenv("GOLINE"))
+		if err != nil {
+			log.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	models := make([]*StructInfo, 0, len(names))
+	for _, name := range names {
+		si, err := pkg.ParseStruct(name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		models = append(models, si)
+	}
+
+	outPkg := *packageName
+	if outPkg == "" {
+		outPkg = os.Getenv("GOPACKAGE")
+	}
+	if outPkg == "" {
+		outPkg = pkg.Name
+	}
+	outFile := *output
+	if outFile == "" {
+		outFile = strings.ToLower(ns.ColumnName("", names[0])) + "_crud.go"
+	}
+	if !filepath.IsAbs(outFile) {
+		outFile = filepath.Join(dir, outFile)
+	}
+	outFile, err = filepath.Abs(outFile)
 	if err != nil {
-		return nil, err
+		log.Fatal(err)
+	}
+
+	var storeFile string
+	if *storeName != "" {
+		storeFile = filepath.Join(filepath.Dir(outFile), *storeName)
+		if storeFile == outFile {
+			log.Fatalf("-store and -o both name %s", outFile)
+		}
+	}
+
+	g := NewGenerator(pkg, outPkg, outFile)
+	g.enumText = *enumText
+	g.Generate(models)
+	src, srcErr := g.Source()
+	if err := os.MkdirAll(filepath.Dir(outFile), 0o755); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(outFile, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+	if srcErr != nil {
+		log.Fatalf("%s was written but is not valid Go syntax: %s", outFile, srcErr)
+	}
+
+	// The Store is written after outFile, so that it finds the repositories
+	// just generated along with those of the other runs.
+	if storeFile != "" {
+		sg := NewGenerator(pkg, outPkg, storeFile)
+		if !sg.GenerateStore() {
+			return
+		}
+		src, srcErr := sg.Source()
+		if err := os.WriteFile(storeFile, src, 0o644); err != nil {
+			log.Fatal(err)
+		}
+		if srcErr != nil {
+			log.Fatalf("%s was written but is not valid Go syntax: %s", storeFile, srcErr)
+		}
 	}
-	for _, f := range s.FieldsByDBName {
-		fmt.Printf("%+v\n", f)
+}
+
+// maxTypeErrors bounds the type errors reported, as the compiler does.
+const maxTypeErrors = 10
+
+// reportTypeErrors logs the errors of type-checking pkg as warnings: the
+// types of the fields they involve are not resolved, which may make gormaid
+// get their columns, relationships and enums wrong.
+func reportTypeErrors(pkg *Package) {
+	for i, err := range pkg.Errors {
+		if i == maxTypeErrors {
+			log.Printf("warning: %d more type errors in package %s", len(pkg.Errors)-i, pkg.Name)
+			break
+		}
+		log.Printf("warning: %s", err)
+	}
+	if len(pkg.Errors) > 0 {
+		log.Printf("warning: package %s does not type-check, the generated code may be wrong", pkg.Name)
 	}
-	return s.FieldsByDBName, nil
 }
 
-func main() {
-	//test()
-	_, _ = GetGormColumns(&Transfer{})
+// structAfterDirective returns the first struct declared after line goLine of
+// goFile, which is where go generate places the directive invoking gormaid.
+func structAfterDirective(pkg *Package, goFile, goLine string) (string, error) {
+	if goFile == "" || goLine == "" {
+		return "", errors.New("-struct is required outside of go generate")
+	}
+	line, err := strconv.Atoi(goLine)
+	if err != nil {
+		return "", fmt.Errorf("invalid GOLINE %q", goLine)
+	}
+	for _, file := range pkg.Files {
+		if filepath.Base(pkg.Fset.Position(file.Pos()).Filename) != goFile {
+			continue
+		}
+		for _, decl := range file.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE || pkg.Fset.Position(gd.Pos()).Line <= line {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if _, ok := ts.Type.(*ast.StructType); ok {
+					return ts.Name.Name, nil
+				}
+			}
+		}
+	}
+	return "", fmt.Errorf("no struct declared after %s:%d", goFile, line)
 }
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"log"
+	"strings"
+)
+
+// repoMethod is an exported method of a generated repository, with its
+// parameters and results written as in the output file.
+type repoMethod struct {
+	Name     string
+	Params   []repoParam
+	Results  string // e.g. (*Mouse, error)
+	Variadic bool   // the last parameter is variadic
+}
+
+type repoParam struct {
+	Name string
+	Type string // e.g. ...QueryOption
+}
+
+// signature returns the parameter list and results of m.
+func (m *repoMethod) signature() string {
+	params := make([]string, len(m.Params))
+	for i, p := range m.Params {
+		params[i] = p.Name + " " + p.Type
+	}
+	return "(" + strings.Join(params, ", ") + ") " + m.Results
+}
+
+// args returns the arguments passing the parameters of m on.
+func (m *repoMethod) args() string {
+	args := make([]string, len(m.Params))
+	for i, p := range m.Params {
+		args[i] = p.Name
+	}
+	if m.Variadic {
+		args[len(args)-1] += "..."
+	}
+	return strings.Join(args, ", ")
+}
+
+// repoMethods returns the exported methods of repo declared by src, the
+// code written for a model, in declaration order. Reading the methods back
+// from the code keeps the interface and the mock in step with every method
+// the repository gets.
+func repoMethods(src []byte, repo string) ([]*repoMethod, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", append([]byte("package p\n\n"), src...), parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	text := func(node ast.Node) string {
+		var buf bytes.Buffer
+		printer.Fprint(&buf, fset, node)
+		return buf.String()
+	}
+	var methods []*repoMethod
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil || !fn.Name.IsExported() {
+			continue
+		}
+		if star, ok := fn.Recv.List[0].Type.(*ast.StarExpr); !ok || text(star.X) != repo {
+			continue
+		}
+		m := &repoMethod{Name: fn.Name.Name}
+		for _, field := range fn.Type.Params.List {
+			_, m.Variadic = field.Type.(*ast.Ellipsis)
+			for _, name := range field.Names {
+				m.Params = append(m.Params, repoParam{Name: name.Name, Type: text(field.Type)})
+			}
+		}
+		if fn.Type.Results != nil {
+			var results []string
+			for _, field := range fn.Type.Results.List {
+				results = append(results, text(field.Type))
+			}
+			m.Results = strings.Join(results, ", ")
+			if len(results) > 1 {
+				m.Results = "(" + m.Results + ")"
+			}
+		}
+		methods = append(methods, m)
+	}
+	return methods, nil
+}
+
+// generateMock writes the <Model>Repository interface of the methods of the
+// repository of si, given the code written for it, and the
+// <Model>RepositoryMock implementing it with a func field per method and a
+// record of the calls, in the manner of moq.
+func (g *Generator) generateMock(si *StructInfo, src []byte) {
+	name := si.StructName
+	repo, iface, mock := name+"Repo", name+"Repository", name+"RepositoryMock"
+	if g.existing[iface] || g.existing[mock] {
+		log.Printf("warning: %s: %s is declared in another file, no mock is generated", name, iface)
+		return
+	}
+	methods, err := repoMethods(src, repo)
+	if err != nil {
+		log.Printf("warning: internal error: %s: %s", repo, err)
+		return
+	}
+	sync := g.use("sync")
+	// call returns the struct recording the parameters of a call to m.
+	call := func(m *repoMethod) string {
+		fields := make([]string, len(m.Params))
+		for i, p := range m.Params {
+			fields[i] = exportName(p.Name) + " " + strings.Replace(p.Type, "...", "[]", 1)
+		}
+		return "struct {\n" + strings.Join(fields, "\n") + "\n}"
+	}
+
+	g.Printf("// %s is the interface of %s, for code to be tested with a\n", iface, repo)
+	g.Printf("// %s instead of a database.\n", mock)
+	g.Printf("type %s interface {\n", iface)
+	for _, m := range methods {
+		g.Printf("%s%s\n", m.Name, m.signature())
+	}
+	g.Printf("}\n\n")
+	g.Printf("var (\n")
+	g.Printf("_ %s = (*%s)(nil)\n", iface, repo)
+	g.Printf("_ %s = (*%s)(nil)\n", iface, mock)
+	g.Printf(")\n\n")
+
+	g.Printf("// %s implements %s by calling the func field named after\n", mock, iface)
+	g.Printf("// each method, e.g. GetFunc for Get, and records the calls, which the\n")
+	g.Printf("// <Method>Calls methods return. Calling a method whose func is nil\n")
+	g.Printf("// panics. A %s is safe for concurrent use once its funcs are set.\n", mock)
+	g.Printf("type %s struct {\n", mock)
+	for _, m := range methods {
+		g.Printf("%sFunc func%s\n", m.Name, m.signature())
+	}
+	g.Printf("\n")
+	g.Printf("mu    %s.Mutex\n", sync)
+	g.Printf("calls struct {\n")
+	for _, m := range methods {
+		g.Printf("%s []%s\n", m.Name, call(m))
+	}
+	g.Printf("}\n")
+	g.Printf("}\n\n")
+
+	for _, m := range methods {
+		g.Printf("// %s calls %sFunc.\n", m.Name, m.Name)
+		g.Printf("func (mock *%s) %s%s {\n", mock, m.Name, m.signature())
+		g.Printf("if mock.%sFunc == nil {\n", m.Name)
+		g.Printf("panic(%q)\n", mock+"."+m.Name+"Func: method is nil but "+iface+"."+m.Name+" was just called")
+		g.Printf("}\n")
+		g.Printf("mock.mu.Lock()\n")
+		g.Printf("mock.calls.%s = append(mock.calls.%[1]s, %s{\n", m.Name, call(m))
+		for _, p := range m.Params {
+			g.Printf("%s: %s,\n", exportName(p.Name), p.Name)
+		}
+		g.Printf("})\n")
+		g.Printf("mock.mu.Unlock()\n")
+		if m.Results == "" {
+			g.Printf("mock.%sFunc(%s)\n", m.Name, m.args())
+		} else {
+			g.Printf("return mock.%sFunc(%s)\n", m.Name, m.args())
+		}
+		g.Printf("}\n\n")
+
+		g.Printf("// %sCalls returns the calls made to %s so far.\n", m.Name, m.Name)
+		g.Printf("func (mock *%s) %sCalls() []%s {\n", mock, m.Name, call(m))
+		g.Printf("mock.mu.Lock()\n")
+		g.Printf("defer mock.mu.Unlock()\n")
+		g.Printf("return append([]%s(nil), mock.calls.%s...)\n", call(m), m.Name)
+		g.Printf("}\n\n")
+	}
+}
+
+// exportName returns name with its first letter upper cased, e.g. Ctx.
+func exportName(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
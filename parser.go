@@ -0,0 +1,422 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ParseStructFile parses the Go source file at path and returns the model of
+// the struct named structName. When the file is not valid Go source the text
+// based FindStructBlock/ParseStructBlock pair is used as a fallback.
+func ParseStructFile(path, structName string) (*StructInfo, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, content, parser.ParseComments)
+	if err != nil {
+		return parseStructText(string(content), structName)
+	}
+	p := &structParser{fset: fset, files: []*ast.File{file}}
+	return p.parse(structName)
+}
+
+// ParseStructSource is like ParseStructFile but reads the declaration from
+// src, which may omit the package clause.
+func ParseStructSource(src, structName string) (*StructInfo, error) {
+	if !strings.HasPrefix(strings.TrimSpace(src), "package ") {
+		src = "package p\n" + src
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return parseStructText(src, structName)
+	}
+	p := &structParser{fset: fset, files: []*ast.File{file}}
+	return p.parse(structName)
+}
+
+func parseStructText(content, structName string) (*StructInfo, error) {
+	block := FindStructBlock(structName, RemoveComments(content))
+	if block == "" {
+		return nil, fmt.Errorf("struct %s not found", structName)
+	}
+	return ParseStructBlock(block), nil
+}
+
+// structParser builds StructInfo values from the syntax trees of one package.
+// When pkg is set the field types are resolved through its type information.
+type structParser struct {
+	fset  *token.FileSet
+	files []*ast.File
+	pkg   *Package
+}
+
+// lookup returns the struct type declared as name in the parsed files.
+func (p *structParser) lookup(name string) *ast.StructType {
+	for _, file := range p.files {
+		for _, decl := range file.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if ts.Name.Name != name {
+					continue
+				}
+				if st, ok := ts.Type.(*ast.StructType); ok {
+					return st
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (p *structParser) parse(structName string) (*StructInfo, error) {
+	st := p.lookup(structName)
+	if st == nil {
+		return nil, fmt.Errorf("struct %s not found", structName)
+	}
+	structInfo := p.parseFields(structName, p.astFields(st))
+	parseRelationships(p, structInfo)
+	return structInfo, nil
+}
+
+// parseFields builds the model of structName from its fields, leaving the
+// relationships out.
+func (p *structParser) parseFields(structName string, fields []rawField) *StructInfo {
+	structInfo := &StructInfo{
+		StructName:    structName,
+		TableName:     p.tableName(structName),
+		FieldInfo:     []*FieldInfo{},
+		UniqueIndices: make(map[string][]string),
+		PrimaryKeys:   []string{},
+		ColumnMap:     make(map[string]string),
+	}
+	p.addFields(structInfo, fields, nil, "", nil)
+	if len(structInfo.PrimaryKeys) == 0 {
+		for _, fi := range structInfo.FieldInfo {
+			if structInfo.ColumnMap[fi.FieldName] == "id" {
+				structInfo.PrimaryKeys = append(structInfo.PrimaryKeys, fi.FieldName)
+				break
+			}
+		}
+	}
+	parseIndexes(structInfo)
+	return structInfo
+}
+
+// tableName returns the table of the model: the string literal returned by
+// its TableName method if it has one, the naming strategy's choice otherwise.
+func (p *structParser) tableName(structName string) string {
+	for _, file := range p.files {
+		for _, decl := range file.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Name.Name != "TableName" || fd.Recv == nil || len(fd.Recv.List) != 1 || fd.Body == nil {
+				continue
+			}
+			if embeddedName(fd.Recv.List[0].Type) != structName || len(fd.Body.List) != 1 {
+				continue
+			}
+			ret, ok := fd.Body.List[0].(*ast.ReturnStmt)
+			if !ok || len(ret.Results) != 1 {
+				continue
+			}
+			if lit, ok := ret.Results[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				if name, err := strconv.Unquote(lit.Value); err == nil {
+					return name
+				}
+			}
+		}
+	}
+	return ns.TableName(structName)
+}
+
+// rawField is a struct field as declared, before its gorm tag is applied.
+type rawField struct {
+	name      string
+	typeExpr  string
+	typ       types.Type // nil without type information
+	tag       string
+	anonymous bool
+	inline    *ast.StructType // the struct type literal the field is declared with
+}
+
+func (p *structParser) astFields(st *ast.StructType) []rawField {
+	var fields []rawField
+	for _, field := range st.Fields.List {
+		var tag string
+		if field.Tag != nil {
+			if unquoted, err := strconv.Unquote(field.Tag.Value); err == nil {
+				tag = unquoted
+			}
+		}
+		var typ types.Type
+		if p.pkg != nil && valid(p.pkg.Info.TypeOf(field.Type)) {
+			typ = p.pkg.Info.TypeOf(field.Type)
+		}
+		if len(field.Names) == 0 {
+			fields = append(fields, rawField{
+				name:      embeddedName(field.Type),
+				typeExpr:  types.ExprString(field.Type),
+				typ:       typ,
+				tag:       tag,
+				anonymous: true,
+			})
+			continue
+		}
+		expr := field.Type
+		if star, ok := expr.(*ast.StarExpr); ok {
+			expr = star.X
+		}
+		inline, _ := expr.(*ast.StructType)
+		for _, name := range field.Names {
+			fields = append(fields, rawField{
+				name:     name.Name,
+				typeExpr: types.ExprString(field.Type),
+				typ:      typ,
+				tag:      tag,
+				inline:   inline,
+			})
+		}
+	}
+	return fields
+}
+
+// valid reports whether t was resolved: the type checker gives the types
+// of fields naming undeclared types as invalid, which the declared type
+// expression describes better.
+func valid(t types.Type) bool {
+	switch t := t.(type) {
+	case nil:
+		return false
+	case *types.Basic:
+		return t.Kind() != types.Invalid
+	case *types.Pointer:
+		return valid(t.Elem())
+	case *types.Slice:
+		return valid(t.Elem())
+	case *types.Array:
+		return valid(t.Elem())
+	case *types.Map:
+		return valid(t.Key()) && valid(t.Elem())
+	}
+	return true
+}
+
+func (p *structParser) typesFields(st *types.Struct) []rawField {
+	qualifier := func(other *types.Package) string {
+		if other == p.pkg.Types {
+			return ""
+		}
+		return other.Name()
+	}
+	fields := make([]rawField, 0, st.NumFields())
+	for i := 0; i < st.NumFields(); i++ {
+		v := st.Field(i)
+		fields = append(fields, rawField{
+			name:      v.Name(),
+			typeExpr:  types.TypeString(v.Type(), qualifier),
+			typ:       v.Type(),
+			tag:       st.Tag(i),
+			anonymous: v.Embedded(),
+		})
+	}
+	return fields
+}
+
+// embeddedFields returns the fields of the struct type f refers to, through
+// the type information when available and the package syntax otherwise.
+func (p *structParser) embeddedFields(f rawField) ([]rawField, bool) {
+	if f.typ != nil {
+		t := f.typ
+		if ptr, ok := t.(*types.Pointer); ok {
+			t = ptr.Elem()
+		}
+		if st, ok := t.Underlying().(*types.Struct); ok {
+			return p.typesFields(st), true
+		}
+		return nil, false
+	}
+	if f.inline != nil {
+		return p.astFields(f.inline), true
+	}
+	name := strings.TrimPrefix(f.typeExpr, "*")
+	if name == "gorm.Model" {
+		return gormModelFields, true
+	}
+	if !token.IsIdentifier(name) {
+		return nil, false
+	}
+	if st := p.lookup(name); st != nil {
+		return p.astFields(st), true
+	}
+	return nil, false
+}
+
+// gormModelFields is what gorm.Model declares, for when the parser has no
+// type information to look it up.
+var gormModelFields = []rawField{
+	{name: "ID", typeExpr: "uint", tag: `gorm:"primarykey"`},
+	{name: "CreatedAt", typeExpr: "time.Time"},
+	{name: "UpdatedAt", typeExpr: "time.Time"},
+	{name: "DeletedAt", typeExpr: "gorm.DeletedAt", tag: `gorm:"index"`},
+}
+
+// promoted reports whether gorm flattens the anonymous field f like an
+// embedded struct: time, byte slices and valuers are plain columns.
+func (p *structParser) promoted(f rawField) bool {
+	if !f.anonymous {
+		return false
+	}
+	if f.typ == nil {
+		name := strings.TrimPrefix(f.typeExpr, "*")
+		return name != "time.Time" && name != "gorm.DeletedAt"
+	}
+	ti := p.pkg.typeInfo(f.typ)
+	if ti.Kind != reflect.Struct || ti.Valuer {
+		return false
+	}
+	return ti.qualifiedName() != "time.Time"
+}
+
+// addFields records fields together with what their gorm tags say about
+// columns, keys and indices. Fields of embedded structs are flattened into
+// si with parent as their Parent and prefix prepended to their columns.
+// Names in shadowed are declared closer to the model and hide promoted
+// fields of the same name, as they do in Go.
+func (p *structParser) addFields(si *StructInfo, fields []rawField, parent *FieldInfo, prefix string, shadowed map[string]bool) {
+	for _, f := range fields {
+		if !token.IsExported(f.name) || shadowed[f.name] {
+			continue
+		}
+		fieldInfo := &FieldInfo{
+			FieldName: selectorPrefix(parent) + f.name,
+			FieldType: f.typeExpr,
+			Anonymous: f.anonymous,
+			Parent:    parent,
+		}
+		if f.typ != nil {
+			fieldInfo.Type = p.pkg.typeInfo(f.typ)
+		}
+		fieldInfo.Tag = ParseGormTag(reflect.StructTag(f.tag).Get("gorm"))
+		fieldInfo.Permission = fieldInfo.Tag.Permission()
+		if perm := fieldInfo.Permission; !perm.Read && !perm.Create && !perm.Update {
+			fieldInfo.Ignored = true
+			si.FieldInfo = append(si.FieldInfo, fieldInfo)
+			continue
+		}
+		if fieldInfo.Tag.Embedded || p.promoted(f) {
+			if children, ok := p.embeddedFields(f); ok {
+				var hide map[string]bool
+				if f.anonymous {
+					hide = make(map[string]bool)
+					for name := range shadowed {
+						hide[name] = true
+					}
+					for _, sibling := range fields {
+						if sibling.name != f.name {
+							hide[sibling.name] = true
+						}
+					}
+				}
+				p.addFields(si, children, fieldInfo, prefix+fieldInfo.Tag.EmbeddedPrefix, hide)
+				continue
+			}
+		}
+		si.FieldInfo = append(si.FieldInfo, fieldInfo)
+		p.applyTag(si, fieldInfo, prefix)
+		if _, ok := si.ColumnMap[fieldInfo.FieldName]; ok && f.typ != nil {
+			if enum := p.pkg.enumOf(f.typ); enum != nil {
+				fieldInfo.Enum = enum.withTag(reflect.StructTag(f.tag).Get("enums"))
+			}
+		}
+	}
+}
+
+// applyTag records what the gorm tag of fieldInfo says about columns, keys
+// and indices.
+func (p *structParser) applyTag(si *StructInfo, fieldInfo *FieldInfo, prefix string) {
+	fieldName, tag := fieldInfo.FieldName, fieldInfo.Tag
+	if tag.ForeignKey != "" || tag.Many2Many != "" || tag.Polymorphic != "" || isAssociation(fieldInfo) {
+		fieldInfo.External = true
+		return
+	}
+	if isSoftDelete(fieldInfo) {
+		si.SoftDelete = true
+	}
+	if tag.Column != "" {
+		si.ColumnMap[fieldName] = prefix + tag.Column
+	} else {
+		si.ColumnMap[fieldName] = prefix + ns.ColumnName(si.StructName, fieldInfo.Name())
+	}
+	if tag.PrimaryKey {
+		si.PrimaryKeys = append(si.PrimaryKeys, fieldName)
+	}
+}
+
+// isAssociation reports whether the resolved type of fi makes gorm treat it
+// as a relationship rather than a column: a struct, or a slice of structs,
+// that is neither a time nor handled by a serializer or a Scanner/Valuer.
+func isAssociation(fi *FieldInfo) bool {
+	if fi.Type == nil || fi.Tag.Serializer != "" {
+		return false
+	}
+	model := func(ti *TypeInfo) bool {
+		return ti != nil && ti.Kind == reflect.Struct && !ti.Scanner && !ti.Valuer && ti.qualifiedName() != "time.Time"
+	}
+	switch fi.Type.Kind {
+	case reflect.Slice, reflect.Array:
+		return model(fi.Type.Elem)
+	}
+	return model(fi.Type)
+}
+
+// isSoftDelete reports whether the field makes gorm delete rows by setting
+// it instead of removing them, as gorm.DeletedAt does.
+func isSoftDelete(fi *FieldInfo) bool {
+	if fi.Type == nil {
+		return strings.TrimPrefix(fi.FieldType, "*") == "gorm.DeletedAt"
+	}
+	return fi.Type.SoftDelete
+}
+
+// selectorPrefix returns what precedes the name of a field flattened from
+// parent in a Go selector: fields of anonymous structs are promoted.
+func selectorPrefix(parent *FieldInfo) string {
+	if parent == nil {
+		return ""
+	}
+	if parent.Anonymous {
+		return selectorPrefix(parent.Parent)
+	}
+	return parent.FieldName + "."
+}
+
+// embeddedName returns the implicit field name of an anonymous field, that is
+// the type name without pointer and package qualifier.
+func embeddedName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.Ident:
+		return t.Name
+	case *ast.IndexExpr:
+		return embeddedName(t.X)
+	case *ast.IndexListExpr:
+		return embeddedName(t.X)
+	}
+	return types.ExprString(expr)
+}
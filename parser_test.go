@@ -0,0 +1,180 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm/schema"
+)
+
+// inlineEmbedded nests anonymous struct types, which only the syntax
+// declares when the parser has no type information.
+type inlineEmbedded struct {
+	ID uint
+	C  struct {
+		D int
+		E struct {
+			F string
+		} `gorm:"embedded;embeddedPrefix:e_"`
+	} `gorm:"embedded;embeddedPrefix:c_"`
+}
+
+// gormModel is what schema.Parse makes of a model, in the terms of
+// StructInfo: column of every field by Go selector, primary key columns and
+// indexes as name: columns.
+type gormModel struct {
+	table   string
+	columns map[string]string
+	keys    []string
+	indexes []string
+}
+
+func parseGorm(t *testing.T, model any) *gormModel {
+	t.Helper()
+	s, err := schema.Parse(model, &sync.Map{}, ns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &gormModel{table: s.Table, columns: make(map[string]string)}
+	for _, f := range s.Fields {
+		if f.DBName == "" {
+			continue
+		}
+		m.columns[gormSelector(s, f)] = f.DBName
+	}
+	m.keys = append(m.keys, s.PrimaryFieldDBNames...)
+	for _, idx := range s.ParseIndexes() {
+		columns := make([]string, len(idx.Fields))
+		for i, f := range idx.Fields {
+			columns[i] = f.DBName
+		}
+		m.indexes = append(m.indexes, idx.Name+": "+strings.Join(columns, ","))
+	}
+	sort.Strings(m.indexes)
+	return m
+}
+
+// gormSelector returns the Go selector of f, leaving out anonymous fields as
+// FieldInfo.FieldName does.
+func gormSelector(s *schema.Schema, f *schema.Field) string {
+	var names []string
+	typ := s.ModelType
+	for _, i := range f.StructField.Index[:len(f.StructField.Index)-1] {
+		if typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+		if i < 0 { // gorm's mark of a pointer to an embedded struct
+			i = -i - 1
+		}
+		sf := typ.Field(i)
+		if !sf.Anonymous {
+			names = append(names, sf.Name)
+		}
+		typ = sf.Type
+	}
+	return strings.Join(append(names, f.Name), ".")
+}
+
+// model returns si in the terms of gormModel.
+func model(si *StructInfo) *gormModel {
+	m := &gormModel{table: si.TableName, columns: si.ColumnMap}
+	for _, key := range si.PrimaryKeys {
+		m.keys = append(m.keys, si.ColumnMap[key])
+	}
+	for _, idx := range si.Indexes {
+		columns := make([]string, len(idx.Fields))
+		for i, f := range idx.Fields {
+			columns[i] = f.Column
+		}
+		m.indexes = append(m.indexes, idx.Name+": "+strings.Join(columns, ","))
+	}
+	sort.Strings(m.indexes)
+	return m
+}
+
+func TestParseStruct(t *testing.T) {
+	pkg, err := LoadPackage(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		file  string
+		model any
+	}{
+		{"mouse.go", &StrainType{}},
+		{"mouse.go", &Genotype{}},
+		{"mouse.go", &Strain{}},
+		{"mouse.go", &IdentifiedGenotypes{}},
+		{"mouse.go", &Mouse{}},
+		{"cage_position.go", &Position{}},
+		{"cage_position.go", &AssociateCagePosition{}},
+		{"cage_position.go", &Transfer{}},
+		{"parser_test.go", &inlineEmbedded{}},
+	}
+	for _, tt := range tests {
+		name := reflect.TypeOf(tt.model).Elem().Name()
+		t.Run(name, func(t *testing.T) {
+			want := parseGorm(t, tt.model)
+			parsers := map[string]func() (*StructInfo, error){
+				"syntax": func() (*StructInfo, error) { return ParseStructFile(tt.file, name) },
+			}
+			if tt.file != "parser_test.go" {
+				parsers["types"] = func() (*StructInfo, error) { return pkg.ParseStruct(name) }
+			}
+			for by, parse := range parsers {
+				si, err := parse()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := model(si); !reflect.DeepEqual(got, want) {
+					t.Errorf("parsed with %s:\n got %+v\nwant %+v", by, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFindStructBlock(t *testing.T) {
+	const src = `package main
+
+// StructA is a struct
+type StructA struct {
+	A int
+	B int /*comments*/
+	C string
+}
+
+/*
+comments
+//
+*/
+type StructB struct {
+	A uint // comments
+	B int8 // comments
+	C []byte
+}
+`
+	tests := []struct {
+		name   string
+		fields []string
+	}{
+		{"StructA", []string{"A int", "B int", "C string"}},
+		{"StructB", []string{"A uint", "B int8", "C []byte"}},
+	}
+	for _, tt := range tests {
+		si := ParseStructBlock(FindStructBlock(tt.name, RemoveComments(src)))
+		if si.StructName != tt.name {
+			t.Errorf("%s: parsed %s", tt.name, si.StructName)
+		}
+		var fields []string
+		for _, fi := range si.FieldInfo {
+			fields = append(fields, fi.FieldName+" "+fi.FieldType)
+		}
+		if !reflect.DeepEqual(fields, tt.fields) {
+			t.Errorf("%s: fields %q, want %q", tt.name, fields, tt.fields)
+		}
+	}
+}
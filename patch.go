@@ -0,0 +1,75 @@
+package main
+
+import "strings"
+
+// generatePatch writes the <Model>Patch struct, with a pointer field per
+// updatable column, for UpdateFields to write the fields that are set, zero
+// values included. Columns gorm sets on update, such as UpdatedAt, are left
+// out since gorm overwrites them anyway.
+func (g *Generator) generatePatch(si *StructInfo) {
+	name := si.StructName
+	patch := name + "Patch"
+	model := g.model(name)
+	fields := si.Columns(func(fi *FieldInfo) bool {
+		return fi.Permission.Update && !isPrimaryKey(si, fi) && !isAutoCreateTime(fi) && !isAutoUpdateTime(fi) && !isSoftDelete(fi)
+	})
+
+	g.Printf("// %s lists the columns of a %s to update. Nil fields are left\n", patch, name)
+	g.Printf("// untouched, the others are written even when they hold a zero value.\n")
+	g.Printf("type %s struct {\n", patch)
+	for _, fi := range fields {
+		g.Printf("%s *%s // %s\n", keyField(fi), g.typeOf(fi), si.ColumnMap[fi.FieldName])
+	}
+	g.Printf("}\n\n")
+
+	g.Printf("// apply copies the fields set in p to m and returns their columns.\n")
+	g.Printf("func (p *%s) apply(m *%s) []string {\n", patch, model)
+	g.Printf("var columns []string\n")
+	for _, fi := range fields {
+		g.Printf("if p.%s != nil {\n", keyField(fi))
+		g.allocParents(fi, "m")
+		g.Printf("m.%s = *p.%s\n", fi.FieldName, keyField(fi))
+		g.Printf("columns = append(columns, %q)\n", si.ColumnMap[fi.FieldName])
+		g.Printf("}\n")
+	}
+	g.Printf("return columns\n")
+	g.Printf("}\n\n")
+}
+
+// generateUpdateFields writes the UpdateFields repository method applying a
+// patch to the row with a given key.
+func (g *Generator) generateUpdateFields(si *StructInfo) {
+	name := si.StructName
+	model := g.model(name)
+	repo := name + "Repo"
+	ctx := g.use("context")
+	g.Printf("// UpdateFields writes the fields set in p to the row with primary key k,\n")
+	g.Printf("// or returns a *NotFoundError. Columns gorm updates automatically, such\n")
+	g.Printf("// as UpdatedAt, are bumped. An empty p writes nothing.\n")
+	g.Printf("func (r *%s) UpdateFields(ctx %s.Context, k %sKey, p *%sPatch) error {\n", repo, ctx, name, name)
+	g.Printf("var m %s\n", model)
+	g.Printf("columns := p.apply(&m)\n")
+	g.Printf("if len(columns) == 0 {\n")
+	g.Printf("return nil\n")
+	g.Printf("}\n")
+	g.Printf("res := r.db.WithContext(ctx).Model(&m).Where(k.condition()).Select(columns).Updates(&m)\n")
+	g.Printf("return updated(res, func() (bool, error) { return r.ExistsByKey(ctx, k) }, %q, k.values())\n", name)
+	g.Printf("}\n\n")
+}
+
+// allocParents writes the statements allocating the nil embedded struct
+// pointers on the way from recv to fi.
+func (g *Generator) allocParents(fi *FieldInfo, recv string) {
+	var parents []*FieldInfo
+	for p := fi.Parent; p != nil; p = p.Parent {
+		parents = append([]*FieldInfo{p}, parents...)
+	}
+	for _, p := range parents {
+		if !(p.Type != nil && p.Type.Pointer || p.Type == nil && strings.HasPrefix(p.FieldType, "*")) {
+			continue
+		}
+		g.Printf("if %s.%s == nil {\n", recv, p.FieldName)
+		g.Printf("%s.%s = new(%s)\n", recv, p.FieldName, g.typeOf(p)[1:])
+		g.Printf("}\n")
+	}
+}
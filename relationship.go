@@ -0,0 +1,293 @@
+package main
+
+import (
+	"fmt"
+	"go/types"
+	"reflect"
+	"strings"
+
+	"github.com/jinzhu/inflection"
+)
+
+// RelationshipKind is the kind of association a field holds.
+type RelationshipKind string
+
+const (
+	HasOne    RelationshipKind = "has_one"
+	HasMany   RelationshipKind = "has_many"
+	BelongsTo RelationshipKind = "belongs_to"
+	Many2Many RelationshipKind = "many_to_many"
+)
+
+// Relationship is an association of a model, resolved the way gorm's
+// schema.parseRelation does.
+type Relationship struct {
+	// FieldName is the field holding the associated value(s).
+	FieldName string
+	Kind      RelationshipKind
+	// Related is the struct name of the associated model, declared in the
+	// package RelatedPkgPath.
+	Related        string
+	RelatedPkgPath string
+	// ForeignKeys are the fields holding the foreign key and References the
+	// fields they refer to. For belongs-to the foreign keys are fields of
+	// the model and the references fields of Related, it is the other way
+	// around for has-one and has-many. For many2many, ForeignKeys are fields
+	// of the model and References fields of Related, both referred to by
+	// the join table.
+	ForeignKeys []string
+	References  []string
+	// JoinTable, JoinForeignKeys and JoinReferences describe the join
+	// table of a many2many relationship and its columns referring to the
+	// model and to Related.
+	JoinTable       string
+	JoinForeignKeys []string
+	JoinReferences  []string
+	OnUpdate        string
+	OnDelete        string
+}
+
+func (rel Relationship) String() string {
+	s := fmt.Sprintf("%s %s %s", rel.FieldName, rel.Kind, rel.Related)
+	if len(rel.ForeignKeys) > 0 {
+		s += fmt.Sprintf(" foreignKey:%s references:%s", strings.Join(rel.ForeignKeys, ","), strings.Join(rel.References, ","))
+	}
+	if rel.JoinTable != "" {
+		s += fmt.Sprintf(" joinTable:%s(%s;%s)", rel.JoinTable, strings.Join(rel.JoinForeignKeys, ","), strings.Join(rel.JoinReferences, ","))
+	}
+	if rel.OnUpdate != "" {
+		s += " OnUpdate:" + rel.OnUpdate
+	}
+	if rel.OnDelete != "" {
+		s += " OnDelete:" + rel.OnDelete
+	}
+	return s
+}
+
+// LookUpField finds a field by Go selector, column or declared name, like
+// gorm's Schema.LookUpField.
+func (si *StructInfo) LookUpField(name string) *FieldInfo {
+	for _, fi := range si.FieldInfo {
+		if fi.FieldName == name {
+			return fi
+		}
+	}
+	for _, fi := range si.FieldInfo {
+		if column, ok := si.ColumnMap[fi.FieldName]; ok && column == name {
+			return fi
+		}
+	}
+	for _, fi := range si.FieldInfo {
+		if fi.Name() == name && !fi.Ignored {
+			return fi
+		}
+	}
+	return nil
+}
+
+// parseRelationships fills si.Relationships from its association fields.
+func parseRelationships(p *structParser, si *StructInfo) {
+	for _, fi := range si.FieldInfo {
+		if !fi.External {
+			continue
+		}
+		rel := &Relationship{
+			FieldName: fi.FieldName,
+			OnUpdate:  fi.Tag.OnUpdate,
+			OnDelete:  fi.Tag.OnDelete,
+		}
+		related, many := p.related(fi)
+		if related != nil {
+			rel.Related = related.StructName
+		} else {
+			rel.Related = strings.TrimLeft(fi.FieldType, "[]*")
+			rel.Related = rel.Related[strings.LastIndex(rel.Related, ".")+1:]
+		}
+		if fi.Type != nil {
+			ti := fi.Type
+			if many && ti.Elem != nil {
+				ti = ti.Elem
+			}
+			if p.pkg.Types == nil || ti.PkgPath != p.pkg.Types.Path() {
+				rel.RelatedPkgPath = ti.PkgPath
+			}
+		}
+		if fi.Tag.Many2Many != "" {
+			buildMany2Many(si, related, fi, rel)
+		} else {
+			guessRelation(si, related, fi, rel, many)
+		}
+		si.Relationships = append(si.Relationships, rel)
+	}
+}
+
+// related parses the model fi refers to and reports whether fi holds many
+// of them. The model is nil when it cannot be resolved.
+func (p *structParser) related(fi *FieldInfo) (*StructInfo, bool) {
+	if fi.Type != nil {
+		ti, many := fi.Type, false
+		if (ti.Kind == reflect.Slice || ti.Kind == reflect.Array) && ti.Elem != nil {
+			ti, many = ti.Elem, true
+		}
+		t := ti.typ
+		if ptr, ok := t.(*types.Pointer); ok {
+			t = ptr.Elem()
+		}
+		st, ok := t.Underlying().(*types.Struct)
+		if !ok || ti.Name == "" {
+			return nil, many
+		}
+		return p.parseFields(ti.Name, p.typesFields(st)), many
+	}
+	many := strings.HasPrefix(fi.FieldType, "[]")
+	name := strings.TrimLeft(fi.FieldType, "[]*")
+	if st := p.lookup(name); st != nil {
+		return p.parseFields(name, p.astFields(st)), many
+	}
+	return nil, many
+}
+
+// buildMany2Many follows gorm's buildMany2ManyRelation: the join table
+// refers to the primary keys of both sides unless foreignKey and references
+// pick other fields, its columns are named after the models unless
+// joinForeignKey and joinReferences name them.
+func buildMany2Many(si, related *StructInfo, fi *FieldInfo, rel *Relationship) {
+	rel.Kind = Many2Many
+	rel.JoinTable = ns.JoinTableName(fi.Tag.Many2Many)
+	rel.ForeignKeys = si.PrimaryKeys
+	if fi.Tag.ForeignKey != "" {
+		rel.ForeignKeys = lookUpFields(si, toColumns(fi.Tag.ForeignKey))
+	}
+	if related != nil {
+		rel.References = related.PrimaryKeys
+		if fi.Tag.References != "" {
+			rel.References = lookUpFields(related, toColumns(fi.Tag.References))
+		}
+	}
+	joinForeignKeys := toColumns(fi.Tag.JoinForeignKey)
+	joinReferences := toColumns(fi.Tag.JoinReferences)
+	own := make(map[string]bool)
+	for i, fk := range rel.ForeignKeys {
+		name := strings.Title(si.StructName) + lastName(fk)
+		if i < len(joinForeignKeys) {
+			name = strings.Title(joinForeignKeys[i])
+		}
+		own[name] = true
+		rel.JoinForeignKeys = append(rel.JoinForeignKeys, ns.ColumnName(rel.JoinTable, name))
+	}
+	for i, ref := range rel.References {
+		name := strings.Title(rel.Related) + lastName(ref)
+		if own[name] {
+			if fi.Name() != rel.Related {
+				name = inflection.Singular(fi.Name()) + lastName(ref)
+			} else {
+				name += "Reference"
+			}
+		}
+		if i < len(joinReferences) {
+			name = strings.Title(joinReferences[i])
+		}
+		rel.JoinReferences = append(rel.JoinReferences, ns.ColumnName(rel.JoinTable, name))
+	}
+}
+
+// guessRelation follows gorm's guessRelation: a has-one or has-many
+// relationship when the foreign key is found on the related model, a
+// belongs-to one when it is found on the model itself.
+func guessRelation(si, related *StructInfo, fi *FieldInfo, rel *Relationship, many bool) {
+	has := HasOne
+	if many {
+		has = HasMany
+	}
+	if related == nil {
+		rel.Kind = has
+		if !many && fi.Tag.ForeignKey != "" && si.LookUpField(fi.Tag.ForeignKey) != nil {
+			rel.Kind = BelongsTo
+			rel.ForeignKeys = lookUpFields(si, toColumns(fi.Tag.ForeignKey))
+		}
+		return
+	}
+	kinds := []RelationshipKind{has, BelongsTo}
+	if related.StructName == si.StructName {
+		kinds = []RelationshipKind{BelongsTo, has}
+	}
+	for _, kind := range kinds {
+		primary, foreign := si, related
+		if kind == BelongsTo {
+			primary, foreign = related, si
+		}
+		var primaryFields, foreignFields []string
+		if fi.Tag.ForeignKey != "" {
+			foreignFields = lookUpFields(foreign, toColumns(fi.Tag.ForeignKey))
+			if len(foreignFields) != len(toColumns(fi.Tag.ForeignKey)) {
+				continue
+			}
+			primaryFields = primary.PrimaryKeys
+		} else {
+			candidates := primary.PrimaryKeys
+			if fi.Tag.References != "" {
+				candidates = lookUpFields(primary, toColumns(fi.Tag.References))
+			}
+			for _, pf := range candidates {
+				lookUpName := primary.StructName + lastName(pf)
+				if kind == BelongsTo {
+					lookUpName = fi.Name() + lastName(pf)
+				}
+				lookUpNames := []string{lookUpName}
+				if len(candidates) == 1 {
+					base := strings.TrimSuffix(lookUpName, lastName(pf))
+					lookUpNames = append(lookUpNames, base+"ID", base+"Id", ns.ColumnName(foreign.TableName, base+"ID"))
+				}
+				for _, name := range lookUpNames {
+					if f := foreign.LookUpField(name); f != nil {
+						foreignFields = append(foreignFields, f.FieldName)
+						primaryFields = append(primaryFields, pf)
+						break
+					}
+				}
+			}
+			if len(foreignFields) == 0 {
+				continue
+			}
+		}
+		if fi.Tag.References != "" {
+			references := toColumns(fi.Tag.References)
+			primaryFields = lookUpFields(primary, references)
+			if len(primaryFields) != len(references) {
+				continue
+			}
+		}
+		if len(primaryFields) > len(foreignFields) {
+			primaryFields = primaryFields[:len(foreignFields)]
+		}
+		rel.Kind = kind
+		rel.ForeignKeys = foreignFields
+		rel.References = primaryFields
+		return
+	}
+}
+
+// lookUpFields returns the selectors of the named fields of si that exist.
+func lookUpFields(si *StructInfo, names []string) []string {
+	var fields []string
+	for _, name := range names {
+		if fi := si.LookUpField(name); fi != nil {
+			fields = append(fields, fi.FieldName)
+		}
+	}
+	return fields
+}
+
+// toColumns splits a comma separated list of names.
+func toColumns(val string) (results []string) {
+	if val != "" {
+		for _, v := range strings.Split(val, ",") {
+			results = append(results, strings.TrimSpace(v))
+		}
+	}
+	return
+}
+
+func lastName(selector string) string {
+	return selector[strings.LastIndex(selector, ".")+1:]
+}
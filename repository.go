@@ -0,0 +1,206 @@
+package main
+
+// generateQueryOptions writes the QueryOption type shared by the read
+// methods of every repository of the output package.
+func (g *Generator) generateQueryOptions() {
+	gorm, clause := g.use("gorm.io/gorm"), g.use("gorm.io/gorm/clause")
+	g.Printf("// QueryOption tunes the rows a Get, List or Count method reads.\n")
+	g.Printf("type QueryOption func(*queryOptions)\n\n")
+	g.Printf("type queryOptions struct {\n")
+	g.Printf("limit  int\n")
+	g.Printf("offset int\n")
+	g.Printf("orders []%s.OrderByColumn\n", clause)
+	g.Printf("deleted bool\n")
+	g.Printf("preloads []string\n")
+	g.Printf("}\n\n")
+	g.Printf("// WithLimit reads at most n rows.\n")
+	g.Printf("func WithLimit(n int) QueryOption {\n")
+	g.Printf("return func(o *queryOptions) { o.limit = n }\n")
+	g.Printf("}\n\n")
+	g.Printf("// WithOffset skips the first n rows.\n")
+	g.Printf("func WithOffset(n int) QueryOption {\n")
+	g.Printf("return func(o *queryOptions) { o.offset = n }\n")
+	g.Printf("}\n\n")
+	g.Printf("// WithOrder sorts the rows by column, in descending order when desc is\n")
+	g.Printf("// set. Repeat it to sort on several columns. Rows are sorted by primary\n")
+	g.Printf("// key after the given orders, or alone when there are none.\n")
+	g.Printf("func WithOrder(column string, desc bool) QueryOption {\n")
+	g.Printf("return func(o *queryOptions) {\n")
+	g.Printf("o.orders = append(o.orders, %s.OrderByColumn{Column: %[1]s.Column{Table: %[1]s.CurrentTable, Name: column}, Desc: desc})\n", clause)
+	g.Printf("}\n")
+	g.Printf("}\n\n")
+	g.Printf("// WithDeleted also reads the soft deleted rows.\n")
+	g.Printf("func WithDeleted() QueryOption {\n")
+	g.Printf("return func(o *queryOptions) { o.deleted = true }\n")
+	g.Printf("}\n\n")
+	g.Printf("func newQueryOptions(opts []QueryOption) *queryOptions {\n")
+	g.Printf("o := &queryOptions{limit: -1, offset: -1}\n")
+	g.Printf("for _, opt := range opts {\n")
+	g.Printf("opt(o)\n")
+	g.Printf("}\n")
+	g.Printf("return o\n")
+	g.Printf("}\n\n")
+	g.Printf("// scope adds the soft deleted rows to those of db when asked to.\n")
+	g.Printf("func (o *queryOptions) scope(db *%s.DB) *%[1]s.DB {\n", gorm)
+	g.Printf("if o.deleted {\n")
+	g.Printf("return db.Unscoped()\n")
+	g.Printf("}\n")
+	g.Printf("return db\n")
+	g.Printf("}\n\n")
+	g.Printf("// read scopes db and preloads the associations of the rows it reads.\n")
+	g.Printf("func (o *queryOptions) read(db *%s.DB) *%[1]s.DB {\n", gorm)
+	g.Printf("db = o.scope(db)\n")
+	g.Printf("for _, association := range o.preloads {\n")
+	g.Printf("db = db.Preload(association)\n")
+	g.Printf("}\n")
+	g.Printf("return db\n")
+	g.Printf("}\n\n")
+	g.Printf("// page reads, sorts, skips and limits the rows of db, sorting last by\n")
+	g.Printf("// keyColumns for rows tied on the orders to keep the pages stable.\n")
+	g.Printf("func (o *queryOptions) page(db *%s.DB, keyColumns []string) *%[1]s.DB {\n", gorm)
+	g.Printf("db = o.read(db)\n")
+	g.Printf("orders := append([]%s.OrderByColumn{}, o.orders...)\n", clause)
+	g.Printf("for _, column := range keyColumns {\n")
+	g.Printf("orders = append(orders, %s.OrderByColumn{Column: %[1]s.Column{Table: %[1]s.CurrentTable, Name: column}})\n", clause)
+	g.Printf("}\n")
+	g.Printf("if len(orders) > 0 {\n")
+	g.Printf("db = db.Clauses(%s.OrderBy{Columns: orders})\n", clause)
+	g.Printf("}\n")
+	g.Printf("return db.Limit(o.limit).Offset(o.offset)\n")
+	g.Printf("}\n\n")
+}
+
+// generateRepository writes the <Model>Repo type wrapping a *gorm.DB with the
+// basic CRUD methods of the model. Rows are identified by their full primary
+// key, each column being a parameter of Get and Delete.
+func (g *Generator) generateRepository(si *StructInfo) {
+	name := si.StructName
+	model := g.model(name)
+	lower := lowerFirst(name)
+	repo := name + "Repo"
+	ctx, gorm := g.use("context"), g.use("gorm.io/gorm")
+
+	g.Printf("// %s reads and writes the rows of table %q.\n", repo, si.TableName)
+	g.Printf("type %s struct {\n", repo)
+	g.Printf("db *%s.DB\n", gorm)
+	g.Printf("tx bool // db is a transaction from WithTx\n")
+	g.Printf("}\n\n")
+	g.Printf("// New%s returns a %s using db. Unless db is opened with\n", repo, repo)
+	g.Printf("// gorm.Config{TranslateError: true}, writes conflicting with the primary\n")
+	g.Printf("// key or a unique index return the error of the driver rather than one\n")
+	g.Printf("// matching gorm.ErrDuplicatedKey, as %sRepositoryFake returns.\n", si.StructName)
+	g.Printf("func New%s(db *%s.DB) *%s {\n", repo, gorm, repo)
+	g.Printf("return &%s{db: db}\n", repo)
+	g.Printf("}\n\n")
+	g.generateWithTx(si)
+
+	g.Printf("// Create inserts m.\n")
+	g.Printf("func (r *%s) Create(ctx %s.Context, m *%s) error {\n", repo, ctx, model)
+	g.checkKeys(si, false)
+	g.Printf("return r.db.WithContext(ctx).Select(%sCreateColumns).Create(m).Error\n", lower)
+	g.Printf("}\n\n")
+
+	g.Printf("// CreateInBatches inserts ms, batchSize rows per statement.\n")
+	g.Printf("func (r *%s) CreateInBatches(ctx %s.Context, ms []*%s, batchSize int) error {\n", repo, ctx, model)
+	g.checkKeys(si, true)
+	g.Printf("return r.db.WithContext(ctx).Select(%sCreateColumns).CreateInBatches(ms, batchSize).Error\n", lower)
+	g.Printf("}\n\n")
+
+	keys := g.primaryKeys(si)
+	if len(keys) == 0 {
+		g.Printf("// %s has no primary key: Get, Update and Delete are not generated.\n\n", name)
+	} else {
+		key := name + "Key"
+		g.generateKey(si, keys)
+		g.generatePatch(si)
+
+		g.Printf("// Get returns the %s with the given primary key, or a\n", name)
+		g.Printf("// *NotFoundError. Of opts, only WithDeleted and preloads apply.\n")
+		g.Printf("func (r *%s) Get(ctx %s.Context, %s, opts ...%s) (*%s, error) {\n", repo, ctx, g.keyParams(keys), queryOption(si), model)
+		g.Printf("return r.GetByKey(ctx, %s, opts...)\n", keyLiteral(key, keys))
+		g.Printf("}\n\n")
+
+		g.Printf("// Update writes the updatable columns of m, zero values included, to\n")
+		g.Printf("// the row with the primary key of m, or returns a *NotFoundError.\n")
+		if len(updateFields(si)) == 0 {
+			g.Printf("// %s has no updatable column: Update only checks that the row exists.\n", name)
+		}
+		g.Printf("func (r *%s) Update(ctx %s.Context, m *%s) error {\n", repo, ctx, model)
+		g.checkKeys(si, false)
+		g.Printf("k := r.KeyOf(m)\n")
+		if len(updateFields(si)) == 0 {
+			g.Printf("if ok, err := r.ExistsByKey(ctx, k); err != nil || ok {\n")
+			g.Printf("return err\n")
+			g.Printf("}\n")
+			g.Printf("return &NotFoundError{Model: %q, Index: \"primary key\", Key: k.values()}\n", name)
+		} else {
+			g.Printf("res := r.db.WithContext(ctx).Model(m).Where(k.condition()).Select(%sUpdateColumns).Updates(m)\n", lower)
+			g.Printf("return updated(res, func() (bool, error) { return r.ExistsByKey(ctx, k) }, %q, k.values())\n", name)
+		}
+		g.Printf("}\n\n")
+		g.generateUpdateFields(si)
+
+		if si.SoftDelete {
+			g.Printf("// Delete soft deletes the %s with the given primary key, or returns\n", name)
+			g.Printf("// a *NotFoundError.\n")
+		} else {
+			g.Printf("// Delete removes the %s with the given primary key, or returns a\n", name)
+			g.Printf("// *NotFoundError.\n")
+		}
+		g.Printf("func (r *%s) Delete(ctx %s.Context, %s) error {\n", repo, ctx, g.keyParams(keys))
+		g.Printf("return r.DeleteByKey(ctx, %s)\n", keyLiteral(key, keys))
+		g.Printf("}\n\n")
+
+		g.generateKeyMethods(si, keys)
+		if si.SoftDelete {
+			g.generateSoftDelete(si, keys)
+		}
+	}
+	g.generateUniqueLookups(si)
+	g.generateUpserts(si)
+	g.generateAssociations(si)
+
+	g.Printf("// List returns the rows matching filter, all of them when filter is nil,\n")
+	g.Printf("// sorted and paged by opts. Soft deleted rows are left out unless opts\n")
+	g.Printf("// include WithDeleted.\n")
+	g.Printf("func (r *%s) List(ctx %s.Context, filter *%sFilter, opts ...%s) ([]*%s, error) {\n", repo, ctx, name, queryOption(si), model)
+	g.Printf("var ms []*%s\n", model)
+	g.Printf("db := filter.apply(r.db.WithContext(ctx))\n")
+	g.Printf("if err := %s(opts).page(db, %sKeyColumns).Find(&ms).Error; err != nil {\n", optionsFunc(si), lower)
+	g.Printf("return nil, err\n")
+	g.Printf("}\n")
+	g.Printf("return ms, nil\n")
+	g.Printf("}\n\n")
+
+	if len(keys) > 0 {
+		g.generateListPage(si)
+		g.generateIteration(si)
+		g.generateForUpdate(si, keys)
+	}
+
+	g.Printf("// Count returns the number of rows matching filter, of all rows when\n")
+	g.Printf("// filter is nil. Of opts, only WithDeleted applies.\n")
+	g.Printf("func (r *%s) Count(ctx %s.Context, filter *%sFilter, opts ...QueryOption) (int64, error) {\n", repo, ctx, name)
+	g.Printf("var n int64\n")
+	g.Printf("err := filter.apply(newQueryOptions(opts).scope(r.db.WithContext(ctx).Model(&%s{}))).Count(&n).Error\n", model)
+	g.Printf("return n, err\n")
+	g.Printf("}\n\n")
+
+	if si.SoftDelete {
+		g.Printf("// DeleteWhere soft deletes the rows matching filter and returns how many\n")
+		g.Printf("// there were. Like gorm, it refuses to delete every row: filter must set\n")
+		g.Printf("// a predicate.\n")
+	} else {
+		g.Printf("// DeleteWhere removes the rows matching filter and returns how many there\n")
+		g.Printf("// were. Like gorm, it refuses to delete every row: filter must set a\n")
+		g.Printf("// predicate.\n")
+	}
+	g.Printf("func (r *%s) DeleteWhere(ctx %s.Context, filter *%sFilter) (int64, error) {\n", repo, ctx, name)
+	g.Printf("res := filter.apply(r.db.WithContext(ctx)).Delete(&%s{})\n", model)
+	g.Printf("return res.RowsAffected, res.Error\n")
+	g.Printf("}\n\n")
+
+	if si.SoftDelete {
+		g.generateListDeleted(si)
+	}
+}
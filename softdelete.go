@@ -0,0 +1,86 @@
+package main
+
+// generateSoftDelete writes the Restore and Purge repository methods of a
+// soft deletable model, whose Delete methods only mark rows as deleted.
+func (g *Generator) generateSoftDelete(si *StructInfo, keys []*FieldInfo) {
+	name := si.StructName
+	model := g.model(name)
+	repo, key := name+"Repo", name+"Key"
+	ctx, clause := g.use("context"), g.use("gorm.io/gorm/clause")
+	deletedAt := softDeleteColumn(si)
+
+	g.Printf("// Restore brings back the soft deleted %s with the given primary key,\n", name)
+	g.Printf("// or returns a *NotFoundError.\n")
+	g.Printf("func (r *%s) Restore(ctx %s.Context, %s) error {\n", repo, ctx, g.keyParams(keys))
+	g.Printf("return r.RestoreByKey(ctx, %s)\n", keyLiteral(key, keys))
+	g.Printf("}\n\n")
+
+	g.Printf("// RestoreByKey brings back the soft deleted %s with primary key k, or\n", name)
+	g.Printf("// returns a *NotFoundError.\n")
+	g.Printf("func (r *%s) RestoreByKey(ctx %s.Context, k %s) error {\n", repo, ctx, key)
+	g.Printf("res := r.db.WithContext(ctx).Unscoped().Model(&%s{}).Where(k.condition(), %s).Update(%q, nil)\n", model, columnNeq(clause, deletedAt, "nil"), deletedAt)
+	g.Printf("if res.Error != nil {\n")
+	g.Printf("return res.Error\n")
+	g.Printf("}\n")
+	g.Printf("if res.RowsAffected == 0 {\n")
+	g.Printf("return &NotFoundError{Model: %q, Index: \"primary key\", Key: k.values()}\n", name)
+	g.Printf("}\n")
+	g.Printf("return nil\n")
+	g.Printf("}\n\n")
+
+	g.Printf("// Purge removes for good the %s with the given primary key, deleted or\n", name)
+	g.Printf("// not, or returns a *NotFoundError.\n")
+	g.Printf("func (r *%s) Purge(ctx %s.Context, %s) error {\n", repo, ctx, g.keyParams(keys))
+	g.Printf("return r.PurgeByKey(ctx, %s)\n", keyLiteral(key, keys))
+	g.Printf("}\n\n")
+
+	g.Printf("// PurgeByKey removes for good the %s with primary key k, deleted or\n", name)
+	g.Printf("// not, or returns a *NotFoundError.\n")
+	g.Printf("func (r *%s) PurgeByKey(ctx %s.Context, k %s) error {\n", repo, ctx, key)
+	g.Printf("res := r.db.WithContext(ctx).Unscoped().Where(k.condition()).Delete(&%s{})\n", model)
+	g.Printf("if res.Error != nil {\n")
+	g.Printf("return res.Error\n")
+	g.Printf("}\n")
+	g.Printf("if res.RowsAffected == 0 {\n")
+	g.Printf("return &NotFoundError{Model: %q, Index: \"primary key\", Key: k.values()}\n", name)
+	g.Printf("}\n")
+	g.Printf("return nil\n")
+	g.Printf("}\n\n")
+}
+
+// generateListDeleted writes the ListDeleted and PurgeWhere repository
+// methods of a soft deletable model.
+func (g *Generator) generateListDeleted(si *StructInfo) {
+	name := si.StructName
+	model := g.model(name)
+	lower := lowerFirst(name)
+	repo := name + "Repo"
+	ctx, clause := g.use("context"), g.use("gorm.io/gorm/clause")
+
+	g.Printf("// ListDeleted returns the soft deleted rows matching filter, sorted and\n")
+	g.Printf("// paged by opts.\n")
+	g.Printf("func (r *%s) ListDeleted(ctx %s.Context, filter *%sFilter, opts ...%s) ([]*%s, error) {\n", repo, ctx, name, queryOption(si), model)
+	g.Printf("var ms []*%s\n", model)
+	g.Printf("db := filter.apply(r.db.WithContext(ctx).Unscoped().Where(%s))\n", columnNeq(clause, softDeleteColumn(si), "nil"))
+	g.Printf("if err := %s(opts).page(db, %sKeyColumns).Find(&ms).Error; err != nil {\n", optionsFunc(si), lower)
+	g.Printf("return nil, err\n")
+	g.Printf("}\n")
+	g.Printf("return ms, nil\n")
+	g.Printf("}\n\n")
+
+	g.Printf("// PurgeWhere removes for good the rows matching filter, deleted or not,\n")
+	g.Printf("// and returns how many there were. Like DeleteWhere, it refuses to\n")
+	g.Printf("// remove every row.\n")
+	g.Printf("func (r *%s) PurgeWhere(ctx %s.Context, filter *%sFilter) (int64, error) {\n", repo, ctx, name)
+	g.Printf("res := filter.apply(r.db.WithContext(ctx).Unscoped()).Delete(&%s{})\n", model)
+	g.Printf("return res.RowsAffected, res.Error\n")
+	g.Printf("}\n\n")
+}
+
+// softDeleteColumn returns the column marking the soft deleted rows of si.
+func softDeleteColumn(si *StructInfo) string {
+	for _, fi := range si.Columns(isSoftDelete) {
+		return si.ColumnMap[fi.FieldName]
+	}
+	return ""
+}
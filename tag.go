@@ -0,0 +1,313 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// GormTag is the content of a gorm struct tag broken down the way gorm reads
+// it. Keys are matched case-insensitively while values keep their case.
+type GormTag struct {
+	Column        string
+	Type          string
+	Size          int
+	Precision     int
+	Scale         int
+	PrimaryKey    bool
+	Unique        bool
+	NotNull       bool
+	Default       string
+	HasDefault    bool
+	Comment       string
+	Serializer    string
+	AutoIncrement bool
+	// AutoIncrementIncrement is the step of autoIncrementIncrement, 0 when
+	// unset.
+	AutoIncrementIncrement int64
+	AutoCreateTime         AutoTime
+	AutoUpdateTime         AutoTime
+	Check                  string
+	Indexes                []IndexTag
+
+	Embedded       bool
+	EmbeddedPrefix string
+
+	ForeignKey       string
+	References       string
+	Many2Many        string
+	JoinForeignKey   string
+	JoinReferences   string
+	Polymorphic      string
+	PolymorphicValue string
+	OnUpdate         string
+	OnDelete         string
+
+	// Write, Read and Ignore are the values of the <-, -> and - permission
+	// flags; a flag given without value holds its own name, e.g. "<-".
+	Write  string
+	Read   string
+	Ignore string
+
+	// Settings maps every upper-cased key to its value, like
+	// schema.ParseTagSetting does.
+	Settings map[string]string
+}
+
+// Permission is what gorm lets code do with a field, as set by the <-, ->
+// and - flags of its tag.
+type Permission struct {
+	Read    bool
+	Create  bool
+	Update  bool
+	Migrate bool
+}
+
+func (perm Permission) String() string {
+	s := []byte("----")
+	for i, ok := range []bool{perm.Read, perm.Create, perm.Update, perm.Migrate} {
+		if ok {
+			s[i] = "rcum"[i]
+		}
+	}
+	return string(s)
+}
+
+// Permission applies the permission flags the way gorm's schema.ParseField
+// does: - ignores the field, -> makes it read-only or unreadable and <-
+// restricts writes to creates and/or updates.
+func (gt *GormTag) Permission() Permission {
+	perm := Permission{Read: true, Create: true, Update: true, Migrate: true}
+	if _, ok := gt.Settings["-"]; ok {
+		switch strings.ToLower(strings.TrimSpace(gt.Ignore)) {
+		case "-":
+			perm = Permission{Migrate: true}
+		case "all":
+			perm = Permission{}
+		case "migration":
+			perm.Migrate = false
+		}
+	}
+	if _, ok := gt.Settings["->"]; ok {
+		perm.Create = false
+		perm.Update = false
+		perm.Read = strings.ToLower(gt.Read) != "false"
+	}
+	if _, ok := gt.Settings["<-"]; ok {
+		perm.Create = true
+		perm.Update = true
+		if gt.Write != "<-" {
+			perm.Create = strings.Contains(gt.Write, "create")
+			perm.Update = strings.Contains(gt.Write, "update")
+		}
+	}
+	return perm
+}
+
+// AutoTime is the setting of autoCreateTime and autoUpdateTime.
+type AutoTime int
+
+const (
+	AutoTimeUnset AutoTime = iota
+	AutoTimeOff
+	AutoTimeSecond
+	AutoTimeMilli
+	AutoTimeNano
+)
+
+func (at AutoTime) String() string {
+	switch at {
+	case AutoTimeOff:
+		return "false"
+	case AutoTimeSecond:
+		return "true"
+	case AutoTimeMilli:
+		return "milli"
+	case AutoTimeNano:
+		return "nano"
+	}
+	return ""
+}
+
+// IndexTag is one index or uniqueIndex entry of a gorm tag, e.g.
+// uniqueIndex:ui_sg,priority:1.
+type IndexTag struct {
+	Unique bool
+	// Name is empty when gorm derives it from the table and field names.
+	Name      string
+	Composite string
+	// Priority orders the fields of a composite index, gorm defaults to 10.
+	Priority   int
+	Sort       string
+	Collate    string
+	Length     int
+	Class      string
+	Type       string
+	Where      string
+	Option     string
+	Comment    string
+	Expression string
+}
+
+// tagSetting is one key:value pair of a tag, with the key upper-cased.
+type tagSetting struct {
+	Key   string
+	Value string
+}
+
+// splitTagSettings splits str on sep following schema.ParseTagSetting: a
+// separator escaped with a backslash belongs to the value, the key ends at
+// the first colon and a key without value is its own value. Unlike
+// ParseTagSetting, repeated keys are all kept, in order.
+func splitTagSettings(str string, sep string) []tagSetting {
+	var settings []tagSetting
+	names := strings.Split(str, sep)
+	for i := 0; i < len(names); i++ {
+		j := i
+		if len(names[j]) > 0 {
+			for names[j][len(names[j])-1] == '\\' && i+1 < len(names) {
+				i++
+				names[j] = names[j][0:len(names[j])-1] + sep + names[i]
+				names[i] = ""
+			}
+		}
+		values := strings.Split(names[j], ":")
+		k := strings.TrimSpace(strings.ToUpper(values[0]))
+		if len(values) >= 2 {
+			settings = append(settings, tagSetting{Key: k, Value: strings.Join(values[1:], ":")})
+		} else if k != "" {
+			settings = append(settings, tagSetting{Key: k, Value: k})
+		}
+	}
+	return settings
+}
+
+// checkTruth mirrors utils.CheckTruth: a setting is true unless it is empty
+// or "false".
+func checkTruth(vals ...string) bool {
+	for _, val := range vals {
+		if val != "" && !strings.EqualFold(val, "false") {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseGormTag parses the value of a gorm struct tag.
+func ParseGormTag(tag string) *GormTag {
+	gt := &GormTag{Settings: make(map[string]string)}
+	settings := splitTagSettings(tag, ";")
+	for _, s := range settings {
+		gt.Settings[s.Key] = s.Value
+	}
+	get := func(key string) (string, bool) {
+		v, ok := gt.Settings[key]
+		return v, ok
+	}
+	atoi := func(key string) int {
+		n, _ := strconv.Atoi(strings.TrimSpace(gt.Settings[key]))
+		return n
+	}
+
+	gt.Column = gt.Settings["COLUMN"]
+	gt.Type = gt.Settings["TYPE"]
+	gt.Size = atoi("SIZE")
+	gt.Precision = atoi("PRECISION")
+	gt.Scale = atoi("SCALE")
+	gt.PrimaryKey = checkTruth(gt.Settings["PRIMARYKEY"], gt.Settings["PRIMARY_KEY"])
+	gt.Unique = checkTruth(gt.Settings["UNIQUE"])
+	gt.NotNull = checkTruth(gt.Settings["NOT NULL"], gt.Settings["NOTNULL"])
+	gt.Default, gt.HasDefault = get("DEFAULT")
+	gt.Comment = gt.Settings["COMMENT"]
+	gt.Serializer = gt.Settings["SERIALIZER"]
+	gt.AutoIncrement = checkTruth(gt.Settings["AUTOINCREMENT"])
+	gt.AutoIncrementIncrement, _ = strconv.ParseInt(gt.Settings["AUTOINCREMENTINCREMENT"], 10, 64)
+	gt.AutoCreateTime = parseAutoTime(get("AUTOCREATETIME"))
+	gt.AutoUpdateTime = parseAutoTime(get("AUTOUPDATETIME"))
+	gt.Check = gt.Settings["CHECK"]
+
+	_, gt.Embedded = get("EMBEDDED")
+	gt.EmbeddedPrefix = gt.Settings["EMBEDDEDPREFIX"]
+
+	gt.ForeignKey = gt.Settings["FOREIGNKEY"]
+	gt.References = gt.Settings["REFERENCES"]
+	gt.Many2Many = gt.Settings["MANY2MANY"]
+	gt.JoinForeignKey = gt.Settings["JOINFOREIGNKEY"]
+	gt.JoinReferences = gt.Settings["JOINREFERENCES"]
+	gt.Polymorphic = gt.Settings["POLYMORPHIC"]
+	gt.PolymorphicValue = gt.Settings["POLYMORPHICVALUE"]
+	if constraint, ok := get("CONSTRAINT"); ok {
+		cs := make(map[string]string)
+		for _, s := range splitTagSettings(constraint, ",") {
+			cs[s.Key] = s.Value
+		}
+		gt.OnUpdate = cs["ONUPDATE"]
+		gt.OnDelete = cs["ONDELETE"]
+	}
+
+	gt.Write = gt.Settings["<-"]
+	gt.Read = gt.Settings["->"]
+	gt.Ignore = gt.Settings["-"]
+
+	for _, s := range settings {
+		if s.Key == "INDEX" || s.Key == "UNIQUEINDEX" {
+			gt.Indexes = append(gt.Indexes, parseIndexTag(s.Key, s.Value))
+		}
+	}
+	return gt
+}
+
+func parseAutoTime(value string, ok bool) AutoTime {
+	if !ok {
+		return AutoTimeUnset
+	}
+	switch strings.ToUpper(strings.TrimSpace(value)) {
+	case "NANO":
+		return AutoTimeNano
+	case "MILLI":
+		return AutoTimeMilli
+	}
+	if checkTruth(value) {
+		return AutoTimeSecond
+	}
+	return AutoTimeOff
+}
+
+// parseIndexTag follows the parseFieldIndexes function of gorm: the value is
+// the index name followed by comma separated options.
+func parseIndexTag(key, value string) IndexTag {
+	it := IndexTag{Unique: key == "UNIQUEINDEX", Priority: 10}
+	if it.Unique {
+		it.Class = "UNIQUE"
+	}
+	if value == key {
+		return it
+	}
+	name, options, _ := strings.Cut(value, ",")
+	it.Name = strings.TrimSpace(name)
+	settings := make(map[string]string)
+	for _, s := range splitTagSettings(options, ",") {
+		settings[s.Key] = s.Value
+	}
+	if settings["UNIQUE"] != "" {
+		it.Unique = true
+	}
+	it.Class = settings["CLASS"]
+	if it.Unique {
+		it.Class = "UNIQUE"
+	}
+	if composite, ok := settings["COMPOSITE"]; ok && composite != "COMPOSITE" {
+		it.Composite = composite
+	}
+	if priority, err := strconv.Atoi(settings["PRIORITY"]); err == nil {
+		it.Priority = priority
+	}
+	it.Length, _ = strconv.Atoi(settings["LENGTH"])
+	it.Sort = settings["SORT"]
+	it.Collate = settings["COLLATE"]
+	it.Type = settings["TYPE"]
+	it.Where = settings["WHERE"]
+	it.Option = settings["OPTION"]
+	it.Comment = settings["COMMENT"]
+	it.Expression = settings["EXPRESSION"]
+	return it
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm/schema"
+)
+
+var tags = []string{
+	"",
+	"primaryKey",
+	"column:src_cage_id;primaryKey;comment:来源笼位",
+	"comment:小鼠编号;serializer:json",
+	`default:a\;b;comment:semi\;colon`,
+	`check:price > 0\;;not null`,
+	"comment:带: 冒号的注释;index",
+	"PrimaryKey;AutoIncrement:false;notNull",
+	"Column:Name;TYPE:varchar(20);Size:20",
+	"uniqueIndex:idx_name,sort:desc;index:idx_age,priority:2",
+	"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;foreignKey:OwnerID",
+	"<-",
+	"<-:create",
+	"<-:update;->:false",
+	"->",
+	"->:false;<-:create",
+	"-",
+	"-:all",
+	"-:migration",
+	" primaryKey ; column : x ;",
+	";;",
+}
+
+func TestParseGormTagSettings(t *testing.T) {
+	for _, tag := range tags {
+		want := schema.ParseTagSetting(tag, ";")
+		if got := ParseGormTag(tag).Settings; !reflect.DeepEqual(got, want) {
+			t.Errorf("ParseGormTag(%q).Settings = %q, want %q", tag, got, want)
+		}
+	}
+}
+
+func TestGormTagPermission(t *testing.T) {
+	for _, tag := range tags {
+		model := reflect.New(reflect.StructOf([]reflect.StructField{
+			{Name: "ID", Type: reflect.TypeOf(uint(0))},
+			{Name: "Field", Type: reflect.TypeOf(""), Tag: reflect.StructTag(`gorm:"` + tag + `"`)},
+		})).Interface()
+		s, err := schema.Parse(model, &sync.Map{}, ns)
+		if err != nil {
+			t.Fatalf("%q: %v", tag, err)
+		}
+		f := s.LookUpField("Field")
+		if f == nil {
+			t.Fatalf("%q: gorm parsed no field", tag)
+		}
+		want := Permission{Read: f.Readable, Create: f.Creatable, Update: f.Updatable, Migrate: !f.IgnoreMigration}
+		if got := ParseGormTag(tag).Permission(); got != want {
+			t.Errorf("ParseGormTag(%q).Permission() = %s, want %s", tag, got, want)
+		}
+	}
+}
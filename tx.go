@@ -0,0 +1,124 @@
+package main
+
+import (
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// generateWithTx writes the WithTx method returning a copy of the repository
+// of si using a transaction.
+func (g *Generator) generateWithTx(si *StructInfo) {
+	repo := si.StructName + "Repo"
+	gorm := g.use("gorm.io/gorm")
+	g.Printf("// WithTx returns a %s reading and writing through tx, a transaction\n", repo)
+	g.Printf("// begun by the caller, e.g. in a gorm Transaction callback.\n")
+	g.Printf("func (r *%s) WithTx(tx *%s.DB) %sRepository {\n", repo, gorm, si.StructName)
+	g.Printf("return &%s{db: tx, tx: true}\n", repo)
+	g.Printf("}\n\n")
+}
+
+// GenerateStore writes the Store type grouping the repositories declared by
+// the other files of the output package and running them in transactions.
+// It reports whether it wrote one: there may be no repository yet, or
+// another file may declare Store.
+func (g *Generator) GenerateStore() bool {
+	if g.existing["Store"] {
+		log.Printf("warning: Store is declared in another file, %s is not written", filepath.Base(g.outFile))
+		return false
+	}
+	names := repositories(g.existing)
+	if len(names) == 0 {
+		return false
+	}
+	ctx, gorm := g.use("context"), g.use("gorm.io/gorm")
+	g.Printf("// Store groups the repositories of the package, sharing one connection\n")
+	g.Printf("// or transaction. Its fields are interfaces, so that tests can build a\n")
+	g.Printf("// Store of mocks or fakes.\n")
+	g.Printf("type Store struct {\n")
+	g.Printf("db *%s.DB\n\n", gorm)
+	for _, name := range names {
+		g.Printf("%s %sRepository\n", name, name)
+	}
+	g.Printf("}\n\n")
+
+	g.Printf("// NewStore returns a Store whose repositories use db.\n")
+	g.Printf("func NewStore(db *%s.DB) *Store {\n", gorm)
+	g.Printf("return &Store{\n")
+	g.Printf("db: db,\n")
+	for _, name := range names {
+		g.Printf("%s: New%sRepo(db),\n", name, name)
+	}
+	g.Printf("}\n")
+	g.Printf("}\n\n")
+
+	g.Printf("// RunInTx calls fn with a Store whose repositories run in a transaction,\n")
+	g.Printf("// committed when fn returns nil and rolled back when it returns an error\n")
+	g.Printf("// or panics. Called on the Store of a transaction, RunInTx nests a\n")
+	g.Printf("// savepoint, so that a failing fn only rolls back its own writes. Each\n")
+	g.Printf("// repository joins the transaction through its WithTx method, so mocks\n")
+	g.Printf("// and fakes set in the fields are kept. A Store not returned by\n")
+	g.Printf("// NewStore, e.g. one of fakes, has no transaction to begin and calls fn\n")
+	g.Printf("// with itself. Either way, the rows of fakes are restored when fn fails.\n")
+	g.Printf("func (s *Store) RunInTx(ctx %s.Context, fn func(*Store) error) error {\n", ctx)
+	g.Printf("restore := s.snapshot()\n")
+	g.Printf("committed := false\n")
+	g.Printf("defer func() {\n")
+	g.Printf("if !committed {\n")
+	g.Printf("restore()\n")
+	g.Printf("}\n")
+	g.Printf("}()\n")
+	g.Printf("var err error\n")
+	g.Printf("if s.db == nil {\n")
+	g.Printf("err = fn(s)\n")
+	g.Printf("} else {\n")
+	g.Printf("err = s.db.WithContext(ctx).Transaction(func(tx *%s.DB) error {\n", gorm)
+	g.Printf("return fn(&Store{\n")
+	g.Printf("db: tx,\n")
+	for _, name := range names {
+		g.Printf("%s: s.%[1]s.WithTx(tx),\n", name)
+	}
+	g.Printf("})\n")
+	g.Printf("})\n")
+	g.Printf("}\n")
+	g.Printf("committed = err == nil\n")
+	g.Printf("return err\n")
+	g.Printf("}\n\n")
+
+	fields := make([]string, len(names))
+	for i, name := range names {
+		fields[i] = "s." + name
+	}
+	g.Printf("// snapshot saves the rows of the repositories of s held in memory, such\n")
+	g.Printf("// as fakes, and returns the func restoring them.\n")
+	g.Printf("func (s *Store) snapshot() func() {\n")
+	g.Printf("var restores []func()\n")
+	g.Printf("for _, repo := range []any{%s} {\n", strings.Join(fields, ", "))
+	g.Printf("if r, ok := repo.(interface{ snapshot() func() }); ok {\n")
+	g.Printf("restores = append(restores, r.snapshot())\n")
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("return func() {\n")
+	g.Printf("for _, restore := range restores {\n")
+	g.Printf("restore()\n")
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("}\n\n")
+	return true
+}
+
+// repositories returns the sorted names of the models whose repository,
+// constructor and interface are among names, e.g. Mouse for MouseRepo,
+// NewMouseRepo and MouseRepository.
+func repositories(names map[string]bool) []string {
+	var models []string
+	for name := range names {
+		model := strings.TrimSuffix(name, "Repository")
+		if model != name && names[model+"Repo"] && names["New"+model+"Repo"] {
+			models = append(models, model)
+		}
+	}
+	sort.Strings(models)
+	return models
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"log"
+	"strings"
+)
+
+// generateNotFoundError writes the NotFoundError type every lookup of the
+// repositories returns when no row matches.
+func (g *Generator) generateNotFoundError() {
+	errors, fmt, gorm := g.use("errors"), g.use("fmt"), g.use("gorm.io/gorm")
+	g.Printf("// NotFoundError is returned when no row matches a lookup by primary key or\n")
+	g.Printf("// unique index. errors.Is matches it with gorm.ErrRecordNotFound.\n")
+	g.Printf("type NotFoundError struct {\n")
+	g.Printf("Model string // the model looked up, e.g. User\n")
+	g.Printf("Index string // the unique index used, or \"primary key\"\n")
+	g.Printf("Key   []any  // the values looked up, in index order\n")
+	g.Printf("}\n\n")
+	g.Printf("func (e *NotFoundError) Error() string {\n")
+	g.Printf("return %s.Sprintf(\"%%s not found by %%s %%v\", e.Model, e.Index, e.Key)\n", fmt)
+	g.Printf("}\n\n")
+	g.Printf("// Is reports whether target is gorm.ErrRecordNotFound.\n")
+	g.Printf("func (e *NotFoundError) Is(target error) bool {\n")
+	g.Printf("return target == %s.ErrRecordNotFound\n", gorm)
+	g.Printf("}\n\n")
+	g.Printf("// notFound turns the gorm.ErrRecordNotFound of a lookup into a\n")
+	g.Printf("// *NotFoundError, other errors are returned as is.\n")
+	g.Printf("func notFound(err error, model, index string, key ...any) error {\n")
+	g.Printf("if %s.Is(err, %s.ErrRecordNotFound) {\n", errors, gorm)
+	g.Printf("return &NotFoundError{Model: model, Index: index, Key: key}\n")
+	g.Printf("}\n")
+	g.Printf("return err\n")
+	g.Printf("}\n\n")
+	g.Printf("// updated returns the error of res, an update by primary key, or a\n")
+	g.Printf("// *NotFoundError when no row has the key. Some databases, such as MySQL,\n")
+	g.Printf("// count the rows an update changes rather than those it matches, so an\n")
+	g.Printf("// update affecting no row asks exists whether the row is there.\n")
+	g.Printf("func updated(res *%s.DB, exists func() (bool, error), model string, key []any) error {\n", gorm)
+	g.Printf("if res.Error != nil || res.RowsAffected > 0 {\n")
+	g.Printf("return res.Error\n")
+	g.Printf("}\n")
+	g.Printf("if ok, err := exists(); err != nil || ok {\n")
+	g.Printf("return err\n")
+	g.Printf("}\n")
+	g.Printf("return &NotFoundError{Model: model, Index: \"primary key\", Key: key}\n")
+	g.Printf("}\n\n")
+}
+
+// uniqueKey is a unique index of a model and the fields it covers, named
+// after them, e.g. StrainTypeAndGenotype for ui_sg.
+type uniqueKey struct {
+	Index  *Index
+	Fields []*FieldInfo
+	Name   string
+}
+
+// uniqueKeys returns the unique indexes of si in order. Indexes named like
+// an earlier one, or like the Key and Keys methods, are skipped with a
+// warning.
+func uniqueKeys(si *StructInfo) []*uniqueKey {
+	names := map[string]bool{"Key": true, "Keys": true}
+	var uks []*uniqueKey
+	for _, idx := range si.Indexes {
+		if !idx.Unique {
+			continue
+		}
+		uk := &uniqueKey{Index: idx}
+		var fieldNames []string
+		for _, f := range idx.Fields {
+			if fi := si.LookUpField(f.FieldName); fi != nil {
+				uk.Fields = append(uk.Fields, fi)
+				fieldNames = append(fieldNames, keyField(fi))
+			}
+		}
+		uk.Name = strings.Join(fieldNames, "And")
+		if names[uk.Name] {
+			log.Printf("warning: %s: a key named %s already exists, unique index %s is skipped", si.StructName, uk.Name, idx.Name)
+			continue
+		}
+		names[uk.Name] = true
+		uks = append(uks, uk)
+	}
+	return uks
+}
+
+// generateUniqueLookups writes a GetBy<Fields> repository method for every
+// unique index of si, e.g. GetByStrainTypeAndGenotype for ui_sg.
+func (g *Generator) generateUniqueLookups(si *StructInfo) {
+	name := si.StructName
+	model := g.model(name)
+	repo := name + "Repo"
+	ctx, clause := g.use("context"), g.use("gorm.io/gorm/clause")
+	for _, uk := range uniqueKeys(si) {
+		method := "GetBy" + uk.Name
+		g.Printf("// %s returns the %s with the given %s, unique by index %s,\n", method, name, strings.Join(columnNames(si, uk.Fields), " and "), uk.Index.Name)
+		g.Printf("// or a *NotFoundError. Of opts, only WithDeleted and preloads apply.\n")
+		g.Printf("func (r *%s) %s(ctx %s.Context, %s, opts ...%s) (*%s, error) {\n", repo, method, ctx, g.keyParams(uk.Fields), queryOption(si), model)
+		g.Printf("var m %s\n", model)
+		g.Printf("err := %s(opts).read(r.db.WithContext(ctx)).Where(\n", optionsFunc(si))
+		for _, fi := range uk.Fields {
+			g.Printf("%s,\n", columnEq(clause, si.ColumnMap[fi.FieldName], paramName(fi)))
+		}
+		g.Printf(").Take(&m).Error\n")
+		g.Printf("if err != nil {\n")
+		g.Printf("return nil, notFound(err, %q, %q, %s)\n", name, uk.Index.Name, keyArgs(uk.Fields))
+		g.Printf("}\n")
+		g.Printf("return &m, nil\n")
+		g.Printf("}\n\n")
+	}
+}
+
+// columnNames returns the columns of fields.
+func columnNames(si *StructInfo, fields []*FieldInfo) []string {
+	columns := make([]string, len(fields))
+	for i, fi := range fields {
+		columns[i] = si.ColumnMap[fi.FieldName]
+	}
+	return columns
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// generateUpsertOptions writes the UpsertOption type shared by the Upsert
+// methods of every repository of the output package.
+func (g *Generator) generateUpsertOptions() {
+	fmt, clause := g.use("fmt"), g.use("gorm.io/gorm/clause")
+	g.Printf("// UpsertOption tells an Upsert method what to do with the row already\n")
+	g.Printf("// holding the key of a row it inserts. By default every updatable column\n")
+	g.Printf("// of that row is overwritten.\n")
+	g.Printf("type UpsertOption func(*upsertOptions)\n\n")
+	g.Printf("type upsertOptions struct {\n")
+	g.Printf("columns   []string\n")
+	g.Printf("doNothing bool\n")
+	g.Printf("}\n\n")
+	g.Printf("// UpdateAll overwrites every updatable column of the existing row.\n")
+	g.Printf("func UpdateAll() UpsertOption {\n")
+	g.Printf("return func(o *upsertOptions) { o.columns, o.doNothing = nil, false }\n")
+	g.Printf("}\n\n")
+	g.Printf("// UpdateOnly overwrites the given columns of the existing row. They must\n")
+	g.Printf("// all be updatable. Without columns, the row is kept as with DoNothing.\n")
+	g.Printf("func UpdateOnly(columns ...string) UpsertOption {\n")
+	g.Printf("return func(o *upsertOptions) { o.columns, o.doNothing = append([]string{}, columns...), false }\n")
+	g.Printf("}\n\n")
+	g.Printf("// DoNothing keeps the existing row as it is.\n")
+	g.Printf("func DoNothing() UpsertOption {\n")
+	g.Printf("return func(o *upsertOptions) { o.columns, o.doNothing = nil, true }\n")
+	g.Printf("}\n\n")
+	g.Printf("// onConflict returns the clause resolving conflicts on the target columns\n")
+	g.Printf("// with the options in opts, where the columns of updatable may be\n")
+	g.Printf("// overwritten. A non empty where restricts target to a partial index.\n")
+	g.Printf("func onConflict(target []string, where string, updatable []string, opts []UpsertOption) (%s.OnConflict, error) {\n", clause)
+	g.Printf("var o upsertOptions\n")
+	g.Printf("for _, opt := range opts {\n")
+	g.Printf("opt(&o)\n")
+	g.Printf("}\n")
+	g.Printf("c := %s.OnConflict{Columns: make([]%[1]s.Column, len(target))}\n", clause)
+	g.Printf("for i, column := range target {\n")
+	g.Printf("c.Columns[i] = %s.Column{Name: column}\n", clause)
+	g.Printf("}\n")
+	g.Printf("if where != \"\" {\n")
+	g.Printf("c.TargetWhere = %s.Where{Exprs: []%[1]s.Expression{%[1]s.Expr{SQL: where}}}\n", clause)
+	g.Printf("}\n")
+	g.Printf("columns := updatable\n")
+	g.Printf("if o.columns != nil {\n")
+	g.Printf("columns = o.columns\n")
+	g.Printf("allowed := make(map[string]bool, len(updatable))\n")
+	g.Printf("for _, column := range updatable {\n")
+	g.Printf("allowed[column] = true\n")
+	g.Printf("}\n")
+	g.Printf("for _, column := range columns {\n")
+	g.Printf("if !allowed[column] {\n")
+	g.Printf("return c, %s.Errorf(\"upsert: column %%q cannot be updated\", column)\n", fmt)
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("}\n")
+	g.Printf("if o.doNothing || len(columns) == 0 {\n")
+	g.Printf("c.DoNothing = true\n")
+	g.Printf("} else {\n")
+	g.Printf("c.DoUpdates = %s.AssignmentColumns(columns)\n", clause)
+	g.Printf("}\n")
+	g.Printf("return c, nil\n")
+	g.Printf("}\n\n")
+}
+
+// generateUpserts writes the Upsert and UpsertBatch repository methods
+// resolving conflicts on the primary key, and an UpsertBy<Fields> and
+// UpsertBatchBy<Fields> pair for every unique index.
+func (g *Generator) generateUpserts(si *StructInfo) {
+	lower := lowerFirst(si.StructName)
+	if len(si.PrimaryKeys) > 0 {
+		g.generateUpsert(si, "", "primary key", lower+"KeyColumns", "")
+	}
+	for _, uk := range uniqueKeys(si) {
+		columns := columnNames(si, uk.Fields)
+		for i, column := range columns {
+			columns[i] = strconv.Quote(column)
+		}
+		target := "[]string{" + strings.Join(columns, ", ") + "}"
+		g.generateUpsert(si, "By"+uk.Name, "unique index "+uk.Index.Name, target, uk.Index.Where)
+	}
+}
+
+// generateUpsert writes a pair of upsert methods named after suffix, which
+// resolve conflicts on the target columns.
+func (g *Generator) generateUpsert(si *StructInfo, suffix, key, target, where string) {
+	name := si.StructName
+	model := g.model(name)
+	lower := lowerFirst(name)
+	repo := name + "Repo"
+	ctx := g.use("context")
+
+	g.Printf("// Upsert%s inserts m or, when a row has the same %s,\n", suffix, key)
+	g.Printf("// updates that row as opts tell. MySQL resolves conflicts on any unique\n")
+	g.Printf("// key of the table.\n")
+	g.Printf("func (r *%s) Upsert%s(ctx %s.Context, m *%s, opts ...UpsertOption) error {\n", repo, suffix, ctx, model)
+	g.checkKeys(si, false)
+	g.Printf("c, err := onConflict(%s, %q, %sUpdateColumns, opts)\n", target, where, lower)
+	g.Printf("if err != nil {\n")
+	g.Printf("return err\n")
+	g.Printf("}\n")
+	g.Printf("return r.db.WithContext(ctx).Clauses(c).Select(%sCreateColumns).Create(m).Error\n", lower)
+	g.Printf("}\n\n")
+
+	g.Printf("// UpsertBatch%s upserts ms like Upsert%s, batchSize rows per\n", suffix, suffix)
+	g.Printf("// statement.\n")
+	g.Printf("func (r *%s) UpsertBatch%s(ctx %s.Context, ms []*%s, batchSize int, opts ...UpsertOption) error {\n", repo, suffix, ctx, model)
+	g.checkKeys(si, true)
+	g.Printf("c, err := onConflict(%s, %q, %sUpdateColumns, opts)\n", target, where, lower)
+	g.Printf("if err != nil {\n")
+	g.Printf("return err\n")
+	g.Printf("}\n")
+	g.Printf("return r.db.WithContext(ctx).Clauses(c).Select(%sCreateColumns).CreateInBatches(ms, batchSize).Error\n", lower)
+	g.Printf("}\n\n")
+}